@@ -0,0 +1,54 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/ingress"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/songtianyi/rrframework/logs"
+)
+
+// Batch run commands on many devices like grpc Batch, streamed as newline delimited json,
+// a message with result once each device is done, then one with response
+func Batch(c *gin.Context) {
+	var req protocol.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, &protocol.BatchStreamMessage{Response: &protocol.BatchResponse{Retcode: common.ErrBadRequest, Message: err.Error()}})
+		return
+	}
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	enc := json.NewEncoder(c.Writer)
+	send := func(msg *protocol.BatchStreamMessage) {
+		if err := enc.Encode(msg); err != nil {
+			logs.Error("[ batch ] [", req.Session, "] send error:", err)
+			return
+		}
+		c.Writer.Flush()
+	}
+	var res protocol.BatchResponse
+	// devices not started yet are skipped once client gone
+	new(ingress.BatchHandler).RunContext(c.Request.Context(), &req, &res, func(r *protocol.BatchResult) {
+		send(&protocol.BatchStreamMessage{Result: r})
+	})
+	res.Results = nil
+	send(&protocol.BatchStreamMessage{Response: &res})
+}
@@ -0,0 +1,52 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sky-cloud-tec/netd/archive"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/ingress"
+	"github.com/sky-cloud-tec/netd/protocol"
+)
+
+// ConfigDiff return unified diff between archived configs, or archived and device current config
+func ConfigDiff(c *gin.Context) {
+	var req protocol.ConfigDiffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, &protocol.ConfigDiffResponse{Retcode: common.ErrArchive, Message: err.Error()})
+		return
+	}
+	var res protocol.ConfigDiffResponse
+	new(ingress.ConfigHandler).DiffConfigContext(c.Request.Context(), &req, &res)
+	c.JSON(http.StatusOK, &res)
+}
+
+// ConfigVersions list archived config versions of device
+func ConfigVersions(c *gin.Context) {
+	if archive.Instance == nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrArchive, "Message": "config archive disabled"})
+		return
+	}
+	versions, err := archive.Instance.Versions(c.Query("device"))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrArchive, "Message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Retcode": common.OK, "Message": "OK", "Versions": versions})
+}
@@ -0,0 +1,58 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/ingress"
+	"github.com/sky-cloud-tec/netd/protocol"
+)
+
+// JobSubmit create job of cli request like jrpc JobHandler.Submit
+func JobSubmit(c *gin.Context) {
+	var req protocol.CliRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, &protocol.JobResponse{Retcode: common.ErrBadRequest, Message: err.Error()})
+		return
+	}
+	var res protocol.JobResponse
+	new(ingress.JobHandler).SubmitContext(c.Request.Context(), &req, &res)
+	c.JSON(Status(res.Retcode), &res)
+}
+
+// JobStatus return job of id
+func JobStatus(c *gin.Context) {
+	var res protocol.JobResponse
+	new(ingress.JobHandler).StatusContext(c.Request.Context(), &protocol.JobRequest{ID: c.Param("id")}, &res)
+	c.JSON(Status(res.Retcode), &res)
+}
+
+// JobList list jobs filtered by device and state
+func JobList(c *gin.Context) {
+	var res protocol.JobListResponse
+	new(ingress.JobHandler).ListContext(c.Request.Context(), &protocol.JobListRequest{Device: c.Query("device"), State: c.Query("state")}, &res)
+	c.JSON(Status(res.Retcode), &res)
+}
+
+// JobCancel cancel job of id
+func JobCancel(c *gin.Context) {
+	var res protocol.JobResponse
+	new(ingress.JobHandler).CancelContext(c.Request.Context(), &protocol.JobRequest{ID: c.Param("id")}, &res)
+	c.JSON(Status(res.Retcode), &res)
+}
@@ -0,0 +1,80 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/record"
+)
+
+// Recordings list session recordings of device
+func Recordings(c *gin.Context) {
+	if record.Instance == nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrRecording, "Message": "session recording disabled"})
+		return
+	}
+	infos, err := record.Instance.List(c.Query("device"))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrRecording, "Message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Retcode": common.OK, "Message": "OK", "Recordings": infos})
+}
+
+// RecordingDownload return asciicast file of recording, play it with asciinema
+func RecordingDownload(c *gin.Context) {
+	if record.Instance == nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrRecording, "Message": "session recording disabled"})
+		return
+	}
+	id := c.Query("id")
+	f, err := record.Instance.Open(c.Query("device"), id)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrRecording, "Message": err.Error()})
+		return
+	}
+	defer f.Close()
+	c.Header("Content-Disposition", "attachment; filename="+strconv.Quote(id))
+	c.DataFromReader(http.StatusOK, -1, "application/x-asciicast", f, nil)
+}
+
+// RecordingReplay stream device output of recording with recorded timing,
+// eg. curl -N 'host:8189/api/recordings/replay?device=fw1&id=...&speed=2&maxIdle=1'
+func RecordingReplay(c *gin.Context) {
+	if record.Instance == nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrRecording, "Message": "session recording disabled"})
+		return
+	}
+	f, err := record.Instance.Open(c.Query("device"), c.Query("id"))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrRecording, "Message": err.Error()})
+		return
+	}
+	defer f.Close()
+	speed, _ := strconv.ParseFloat(c.DefaultQuery("speed", "1"), 64)
+	maxIdle, _ := strconv.ParseFloat(c.DefaultQuery("maxIdle", "0"), 64)
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := record.Replay(f, c.Writer, speed, time.Duration(maxIdle*float64(time.Second)), c.Writer.Flush); err != nil {
+		// headers sent, tell in stream
+		c.Writer.WriteString("\nreplay error: " + err.Error() + "\n")
+	}
+}
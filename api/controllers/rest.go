@@ -0,0 +1,89 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sky-cloud-tec/netd/cli/conn"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/ingress"
+	"github.com/sky-cloud-tec/netd/protocol"
+)
+
+// Status map retcode to http status
+func Status(retcode int) int {
+	switch retcode {
+	case common.OK:
+		return http.StatusOK
+	case common.ErrBadRequest, common.ErrNoMode, common.ErrNoOpFound, common.ErrNoTx, common.ErrNoBackup:
+		return http.StatusBadRequest
+	case common.ErrAcquireConn, common.ErrCliExec, common.ErrTxConfirm, common.ErrPortClosed:
+		return http.StatusBadGateway
+	case common.ErrTimeout:
+		return http.StatusGatewayTimeout
+	case common.ErrNoJob:
+		return http.StatusNotFound
+	case common.ErrJobState:
+		return http.StatusConflict
+	case common.ErrDenied:
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// CliExec execute cli commands like jrpc CliHandler.Handle
+func CliExec(c *gin.Context) {
+	var req protocol.CliRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, &protocol.CliResponse{Retcode: common.ErrBadRequest, Message: err.Error()})
+		return
+	}
+	var res protocol.CliResponse
+	// commands stop once client gone
+	new(ingress.CliHandler).HandleContext(c.Request.Context(), &req, &res, nil)
+	c.JSON(Status(res.Retcode), &res)
+}
+
+// ConfigFetch dump device config like jrpc ConfigHandler.FetchConfig
+func ConfigFetch(c *gin.Context) {
+	var req protocol.CliRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, &protocol.ConfigResponse{Retcode: common.ErrBadRequest, Message: err.Error()})
+		return
+	}
+	var res protocol.ConfigResponse
+	new(ingress.ConfigHandler).FetchConfigContext(c.Request.Context(), &req, &res)
+	c.JSON(Status(res.Retcode), &res)
+}
+
+// PortCheck check port reachability like jrpc UtilsHandler.CheckPort
+func PortCheck(c *gin.Context) {
+	var req protocol.PortCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, &protocol.PortCheckResponse{Retcode: common.ErrBadRequest, Message: err.Error()})
+		return
+	}
+	var res protocol.PortCheckResponse
+	new(ingress.UtilsHandler).CheckPort(&req, &res)
+	c.JSON(Status(res.Retcode), &res)
+}
+
+// Connections list cached cli connections
+func Connections(c *gin.Context) {
+	c.JSON(http.StatusOK, &protocol.ConnListResponse{Retcode: common.OK, Message: "OK", Connections: conn.List()})
+}
@@ -0,0 +1,67 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sky-cloud-tec/netd/ingress"
+	"github.com/songtianyi/rrframework/logs"
+)
+
+// TerminalOrigins are origins of pages allowed to open terminals besides api host itself, eg. https://portal:8443
+var TerminalOrigins []string
+
+// upgrader checks origin, cross site pages can not open terminals with user's cookies
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     checkOrigin,
+}
+
+// checkOrigin allow clients which are not browsers, pages of api host and TerminalOrigins
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, v := range TerminalOrigins {
+		if strings.EqualFold(strings.TrimSuffix(v, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil || !strings.EqualFold(u.Host, r.Host) {
+		logs.Error("terminal from origin", origin, "refused")
+		return false
+	}
+	return true
+}
+
+// Terminal upgrade to websocket and serve interactive terminal, see ingress.ServeTerminal
+func Terminal(c *gin.Context) {
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// upgrader replied error
+		logs.Error("upgrade terminal websocket error:", err)
+		return
+	}
+	ingress.ServeTerminal(c.Request.Context(), ws)
+}
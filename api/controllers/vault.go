@@ -0,0 +1,125 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/vault"
+)
+
+// fallbacksRequest set credential fallbacks of device
+type fallbacksRequest struct {
+	Device      string   `json:"device"`
+	Credentials []string `json:"credentials"`
+}
+
+func vaultDisabled(c *gin.Context) bool {
+	if vault.Instance == nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrVault, "Message": "credential vault disabled"})
+		return true
+	}
+	return false
+}
+
+// VaultCredentials list credentials of vault, secrets not included
+func VaultCredentials(c *gin.Context) {
+	if vaultDisabled(c) {
+		return
+	}
+	cs, err := vault.Instance.List()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrVault, "Message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Retcode": common.OK, "Message": "OK", "Credentials": cs})
+}
+
+// VaultCreate add credential to vault
+func VaultCreate(c *gin.Context) {
+	vaultWrite(c, func(cred *vault.Credential) (*vault.Credential, error) {
+		return vault.Instance.Create(cred)
+	})
+}
+
+// VaultRotate replace secrets of credential, conns logged in keep working
+func VaultRotate(c *gin.Context) {
+	vaultWrite(c, func(cred *vault.Credential) (*vault.Credential, error) {
+		return vault.Instance.Rotate(cred)
+	})
+}
+
+func vaultWrite(c *gin.Context, write func(*vault.Credential) (*vault.Credential, error)) {
+	if vaultDisabled(c) {
+		return
+	}
+	var req vault.Credential
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrVault, "Message": err.Error()})
+		return
+	}
+	cred, err := write(&req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrVault, "Message": err.Error()})
+		return
+	}
+	cred.Password, cred.EnablePwd = "", ""
+	c.JSON(http.StatusOK, gin.H{"Retcode": common.OK, "Message": "OK", "Credential": cred})
+}
+
+// VaultDelete remove credential from vault and fallbacks
+func VaultDelete(c *gin.Context) {
+	if vaultDisabled(c) {
+		return
+	}
+	if err := vault.Instance.Delete(c.Query("id")); err != nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrVault, "Message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Retcode": common.OK, "Message": "OK"})
+}
+
+// VaultFallbacks return credential ids tried in order for device
+func VaultFallbacks(c *gin.Context) {
+	if vaultDisabled(c) {
+		return
+	}
+	ids, err := vault.Instance.Fallbacks(c.Query("device"))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrVault, "Message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Retcode": common.OK, "Message": "OK", "Credentials": ids})
+}
+
+// VaultSetFallbacks set credential ids tried in order for device, empty list removes them
+func VaultSetFallbacks(c *gin.Context) {
+	if vaultDisabled(c) {
+		return
+	}
+	var req fallbacksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrVault, "Message": err.Error()})
+		return
+	}
+	if err := vault.Instance.SetFallbacks(req.Device, req.Credentials); err != nil {
+		c.JSON(http.StatusOK, gin.H{"Retcode": common.ErrVault, "Message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Retcode": common.OK, "Message": "OK"})
+}
@@ -0,0 +1,178 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package openapi builds OpenAPI 3 document from operations registered with gin,
+// schemas are reflected from request and response types, json tags respected.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation is an endpoint served by Handler and described in document
+type Operation struct {
+	Method   string
+	Path     string
+	Summary  string
+	Query    []string          // query parameters, strings
+	Request  interface{}       // request body sample, nil if no body
+	Response interface{}       // response body sample
+	Statuses []int             // statuses besides 200, response body is the same
+	Guards   []gin.HandlerFunc // run before Handler, eg. admin check
+	Handler  gin.HandlerFunc
+}
+
+// Register serve operations on r, document served at path
+func Register(r gin.IRoutes, path, title, version string, ops []*Operation) {
+	for _, v := range ops {
+		r.Handle(v.Method, v.Path, append(append([]gin.HandlerFunc{}, v.Guards...), v.Handler)...)
+	}
+	doc := Document(title, version, ops)
+	r.GET(path, func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	})
+}
+
+// Document return OpenAPI 3 document of operations
+func Document(title, version string, ops []*Operation) map[string]interface{} {
+	g := &generator{schemas: map[string]interface{}{}}
+	paths := map[string]map[string]interface{}{}
+	for _, v := range ops {
+		op := map[string]interface{}{"summary": v.Summary}
+		// gin path params to openapi ones
+		path := v.Path
+		var params []interface{}
+		for _, seg := range strings.Split(v.Path, "/") {
+			if strings.HasPrefix(seg, ":") {
+				path = strings.Replace(path, seg, "{"+seg[1:]+"}", 1)
+				params = append(params, map[string]interface{}{
+					"name": seg[1:], "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+				})
+			}
+		}
+		for _, q := range v.Query {
+			params = append(params, map[string]interface{}{
+				"name": q, "in": "query", "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if v.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(v.Request))}},
+			}
+		}
+		body := map[string]interface{}{"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(v.Response))}}
+		responses := map[string]interface{}{"200": map[string]interface{}{"description": http.StatusText(http.StatusOK), "content": body}}
+		for _, code := range v.Statuses {
+			responses[strconv.Itoa(code)] = map[string]interface{}{"description": http.StatusText(code), "content": body}
+		}
+		op["responses"] = responses
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(v.Method)] = op
+	}
+	return map[string]interface{}{
+		"openapi":    "3.0.3",
+		"info":       map[string]interface{}{"title": title, "version": version},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.schemas},
+	}
+}
+
+// generator collects named struct schemas as components
+type generator struct {
+	schemas map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *generator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		// durations are nanoseconds, except request timeouts in seconds
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case t.Kind() == reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			// placeholder first, types may refer to themselves
+			g.schemas[t.Name()] = nil
+			g.schemas[t.Name()] = g.object(t)
+		}
+		return ref
+	}
+	// interface{} and others, any value
+	return map[string]interface{}{}
+}
+
+// object return schema of struct, embedded struct fields are inlined like encoding/json does
+func (g *generator) object(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	g.fields(t, props)
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+func (g *generator) fields(t reflect.Type, props map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if v := strings.Split(tag, ",")[0]; v != "" {
+			name = v
+		}
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, props)
+			continue
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		props[name] = g.schema(f.Type)
+	}
+}
@@ -0,0 +1,115 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type base struct {
+	Retcode int
+	Message string
+}
+
+type node struct {
+	Name     string            `json:"name"`
+	Children []*node           `json:"children,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Created  time.Time         `json:"created"`
+	Secret   string            `json:"-"`
+	hidden   int
+}
+
+type nodeResponse struct {
+	base
+	Node *node
+}
+
+func TestDocument(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ops := []*Operation{
+		{
+			Method: http.MethodPost, Path: "/api/nodes/:id", Summary: "update node",
+			Request: node{}, Response: nodeResponse{}, Statuses: []int{http.StatusBadRequest},
+			Handler: func(c *gin.Context) { c.JSON(http.StatusOK, &nodeResponse{Node: &node{Name: c.Param("id")}}) },
+		},
+		{
+			Method: http.MethodGet, Path: "/api/nodes", Summary: "list nodes", Query: []string{"name"},
+			Response: []node{},
+			Handler:  func(c *gin.Context) { c.JSON(http.StatusOK, []node{}) },
+		},
+		{
+			Method: http.MethodGet, Path: "/api/secrets", Summary: "list secrets",
+			Response: []node{}, Statuses: []int{http.StatusForbidden},
+			Guards:  []gin.HandlerFunc{func(c *gin.Context) { c.AbortWithStatus(http.StatusForbidden) }},
+			Handler: func(c *gin.Context) { c.JSON(http.StatusOK, []node{{Secret: "x"}}) },
+		},
+	}
+	Convey("document reflected from operations", t, func() {
+		doc := Document("test", "v1", ops)
+		paths := doc["paths"].(map[string]map[string]interface{})
+		So(paths, ShouldContainKey, "/api/nodes/{id}")
+		So(paths["/api/nodes/{id}"], ShouldContainKey, "post")
+		So(paths["/api/nodes"]["get"].(map[string]interface{}), ShouldContainKey, "parameters")
+		params := paths["/api/nodes/{id}"]["post"].(map[string]interface{})["parameters"].([]interface{})
+		So(params[0].(map[string]interface{})["in"], ShouldEqual, "path")
+		responses := paths["/api/nodes/{id}"]["post"].(map[string]interface{})["responses"].(map[string]interface{})
+		So(responses, ShouldContainKey, "200")
+		So(responses, ShouldContainKey, "400")
+
+		schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+		props := schemas["node"].(map[string]interface{})["properties"].(map[string]interface{})
+		So(props, ShouldContainKey, "name")
+		So(props, ShouldContainKey, "labels")
+		So(props, ShouldNotContainKey, "Secret")
+		So(props, ShouldNotContainKey, "hidden")
+		So(props["created"], ShouldResemble, map[string]interface{}{"type": "string", "format": "date-time"})
+		So(props["children"].(map[string]interface{})["items"], ShouldResemble, map[string]interface{}{"$ref": "#/components/schemas/node"})
+		// embedded fields inlined
+		props = schemas["nodeResponse"].(map[string]interface{})["properties"].(map[string]interface{})
+		So(props, ShouldContainKey, "Retcode")
+		So(props, ShouldContainKey, "Node")
+	})
+	Convey("operations and document served", t, func() {
+		r := gin.New()
+		Register(r, "/api/openapi.json", "test", "v1", ops)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+		So(w.Code, ShouldEqual, http.StatusOK)
+		var doc map[string]interface{}
+		So(json.Unmarshal(w.Body.Bytes(), &doc), ShouldBeNil)
+		So(doc["openapi"], ShouldEqual, "3.0.3")
+
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/nodes/n1", nil))
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"name":"n1"`)
+
+		// guards abort before handler
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/secrets", nil))
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(w.Body.Len(), ShouldEqual, 0)
+	})
+}
@@ -1,13 +1,20 @@
 package routers
 
 import (
+	"crypto/tls"
+	"net"
 	"net/http"
 
 	"github.com/sky-cloud-tec/netd/api/controllers"
+	"github.com/sky-cloud-tec/netd/api/openapi"
+	"github.com/sky-cloud-tec/netd/auth"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/protocol"
 	//swaggerFiles "github.com/swaggo/files"
 	//"github.com/swaggo/gin-swagger"
 
 	"github.com/gin-gonic/gin"
+	"github.com/songtianyi/rrframework/logs"
 )
 
 // SetupRouter create gin router and return
@@ -19,13 +26,99 @@ func SetupRouter(addr string) *gin.Engine {
 		c.String(http.StatusOK, "pong!")
 	})
 	r.Use(cors())
+	r.Use(identity())
 
-	r.POST("/api/operator/hotfix", controllers.OperatorHotfix)
-	r.POST("/api/operator/dump", controllers.OperatorDump)
+	// operators patched live, admin only
+	o := r.Group("/api/operator", admin())
+	o.POST("/hotfix", controllers.OperatorHotfix)
+	o.POST("/dump", controllers.OperatorDump)
+
+	// archives and recordings are keyed by device name of requests, admin only
+	a := r.Group("/api", admin())
+	a.POST("/config/diff", controllers.ConfigDiff)
+	a.GET("/config/versions", controllers.ConfigVersions)
+	a.GET("/recordings", controllers.Recordings)
+	a.GET("/recordings/download", controllers.RecordingDownload)
+	a.GET("/recordings/replay", controllers.RecordingReplay)
+
+	// web terminal over websocket
+	r.GET("/api/terminal", controllers.Terminal)
+
+	// rest endpoints, documented at /api/openapi.json
+	openapi.Register(r, "/api/openapi.json", "netd", "v1", restOps)
+
+	// credential admin, loopback and admin only
+	v := r.Group("/api/vault", localOnly(), admin())
+	v.GET("/credentials", controllers.VaultCredentials)
+	v.POST("/credentials", controllers.VaultCreate)
+	v.POST("/credentials/rotate", controllers.VaultRotate)
+	v.DELETE("/credentials", controllers.VaultDelete)
+	v.GET("/fallbacks", controllers.VaultFallbacks)
+	v.POST("/fallbacks", controllers.VaultSetFallbacks)
 
 	return r
 }
 
+// restOps are endpoints described in openapi document, they share protocol types and retcodes with jrpc,
+// http status is derived from retcode, see controllers.Status
+var restOps = []*openapi.Operation{
+	{
+		Method: http.MethodPost, Path: "/api/cli/exec", Summary: "execute cli commands",
+		Request: protocol.CliRequest{}, Response: protocol.CliResponse{},
+		Statuses: []int{http.StatusBadRequest, http.StatusBadGateway, http.StatusGatewayTimeout},
+		Handler:  controllers.CliExec,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/config/fetch", Summary: "dump device config",
+		Request: protocol.CliRequest{}, Response: protocol.ConfigResponse{},
+		Statuses: []int{http.StatusBadRequest, http.StatusBadGateway, http.StatusGatewayTimeout},
+		Handler:  controllers.ConfigFetch,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/port/check", Summary: "check port reachability",
+		Request: protocol.PortCheckRequest{}, Response: protocol.PortCheckResponse{},
+		Statuses: []int{http.StatusBadRequest, http.StatusBadGateway, http.StatusGatewayTimeout},
+		Handler:  controllers.PortCheck,
+	},
+	{
+		Method: http.MethodGet, Path: "/api/connections", Summary: "list cached cli connections, admin only",
+		Response: protocol.ConnListResponse{},
+		Statuses: []int{http.StatusForbidden},
+		Guards:   []gin.HandlerFunc{admin()}, // usernames and credential ids of every device
+		Handler:  controllers.Connections,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/batch", Summary: "run commands on many devices, streamed as newline delimited json",
+		Request: protocol.BatchRequest{}, Response: protocol.BatchStreamMessage{},
+		Statuses: []int{http.StatusBadRequest},
+		Handler:  controllers.Batch,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/jobs", Summary: "submit cli request as job",
+		Request: protocol.CliRequest{}, Response: protocol.JobResponse{},
+		Statuses: []int{http.StatusBadRequest, http.StatusInternalServerError},
+		Handler:  controllers.JobSubmit,
+	},
+	{
+		Method: http.MethodGet, Path: "/api/jobs", Summary: "list jobs", Query: []string{"device", "state"},
+		Response: protocol.JobListResponse{},
+		Statuses: []int{http.StatusInternalServerError},
+		Handler:  controllers.JobList,
+	},
+	{
+		Method: http.MethodGet, Path: "/api/jobs/:id", Summary: "job status and results so far",
+		Response: protocol.JobResponse{},
+		Statuses: []int{http.StatusNotFound, http.StatusInternalServerError},
+		Handler:  controllers.JobStatus,
+	},
+	{
+		Method: http.MethodPost, Path: "/api/jobs/:id/cancel", Summary: "cancel job",
+		Response: protocol.JobResponse{},
+		Statuses: []int{http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+		Handler:  controllers.JobCancel,
+	},
+}
+
 // Cors 跨域设置
 func cors() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -45,3 +138,55 @@ func cors() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// Serve api on addr, over tls if cfg not nil
+func Serve(addr string, cfg *tls.Config) error {
+	srv := &http.Server{Addr: addr, Handler: SetupRouter(addr), TLSConfig: cfg}
+	if cfg == nil {
+		return srv.ListenAndServe()
+	}
+	// certificates served by cfg
+	return srv.ListenAndServeTLS("", "")
+}
+
+// identity put client identity in request ctx, named after api token of Authorization header
+// or client certificate verified over tls, clients without role are rejected once authorization enabled
+func identity() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := auth.Authenticate(c.Request.TLS, c.GetHeader("Authorization"), c.Request.RemoteAddr)
+		if err != nil {
+			logs.Error("api client", auth.Describe(id), "denied:", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"Retcode": common.ErrDenied, "Message": err.Error()})
+			return
+		}
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// admin reject clients without admin role once authorization enabled
+func admin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if auth.Instance != nil {
+			id := auth.FromContext(c.Request.Context())
+			if err := auth.Instance.Admin(id); err != nil {
+				logs.Error("api client", auth.Describe(id), "denied:", err)
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"Retcode": common.ErrDenied, "Message": err.Error()})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// localOnly reject requests not from loopback
+func localOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	}
+}
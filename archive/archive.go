@@ -0,0 +1,178 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package archive stores fetched configs by device, configs are content-addressed
+// by sha256 under objects dir, every device has an index of its versions.
+package archive
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sky-cloud-tec/netd/cli"
+)
+
+// Instance is the archive configs stored to, nil if archiving disabled
+var Instance *Archive
+
+// Version of archived config
+type Version struct {
+	Version  int       `json:"version"`  // version number, starts from 1
+	Checksum string    `json:"checksum"` // sha256 hex of config, object name
+	Format   string    `json:"format"`   // config format
+	Time     time.Time `json:"time"`     // fetched time
+}
+
+// Archive is a content-addressed config store keyed by device
+type Archive struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// New create archive in dir
+func New(dir string) (*Archive, error) {
+	for _, v := range []string{"objects", "devices"} {
+		if err := os.MkdirAll(filepath.Join(dir, v), 0700); err != nil {
+			return nil, fmt.Errorf("create archive dir error: %s", err)
+		}
+	}
+	return &Archive{dir: dir}, nil
+}
+
+// Store archive config of device, no new version if it equals the latest one
+func (s *Archive) Store(device, format, config string) (*Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions, err := s.versions(device)
+	if err != nil {
+		return nil, err
+	}
+	sum := cli.Checksum(config)
+	if n := len(versions); n > 0 && versions[n-1].Checksum == sum && versions[n-1].Format == format {
+		return versions[n-1], nil
+	}
+	obj := s.object(sum)
+	if _, err := os.Stat(obj); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(obj), 0700); err != nil {
+			return nil, fmt.Errorf("create object dir error: %s", err)
+		}
+		// write then rename, so objects are always complete
+		if err := ioutil.WriteFile(obj+".tmp", []byte(config), 0600); err != nil {
+			return nil, fmt.Errorf("write object error: %s", err)
+		}
+		if err := os.Rename(obj+".tmp", obj); err != nil {
+			return nil, fmt.Errorf("write object error: %s", err)
+		}
+	}
+	v := &Version{Version: len(versions) + 1, Checksum: sum, Format: format, Time: time.Now()}
+	b, _ := json.Marshal(v)
+	f, err := os.OpenFile(s.index(device), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open index error: %s", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return nil, fmt.Errorf("write index error: %s", err)
+	}
+	return v, nil
+}
+
+// Versions return archived versions of device, oldest first
+func (s *Archive) Versions(device string) ([]*Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versions(device)
+}
+
+// Get return config of version, 0 for the latest one
+func (s *Archive) Get(device string, version int) (string, *Version, error) {
+	versions, err := s.Versions(device)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(versions) == 0 {
+		return "", nil, fmt.Errorf("no config archived for %s", device)
+	}
+	if version == 0 {
+		version = len(versions)
+	}
+	if version < 0 || version > len(versions) {
+		return "", nil, fmt.Errorf("version %d of %s not found", version, device)
+	}
+	v := versions[version-1]
+	b, err := ioutil.ReadFile(s.object(v.Checksum))
+	if err != nil {
+		return "", nil, fmt.Errorf("read object error: %s", err)
+	}
+	return string(b), v, nil
+}
+
+func (s *Archive) versions(device string) ([]*Version, error) {
+	f, err := os.Open(s.index(device))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open index error: %s", err)
+	}
+	defer f.Close()
+	versions := make([]*Version, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		v := new(Version)
+		if err := json.Unmarshal(scanner.Bytes(), v); err != nil {
+			return nil, fmt.Errorf("bad index of %s: %s", device, err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, scanner.Err()
+}
+
+func (s *Archive) index(device string) string {
+	return filepath.Join(s.dir, "devices", url.PathEscape(device)+".idx")
+}
+
+func (s *Archive) object(sum string) string {
+	return filepath.Join(s.dir, "objects", sum[:2], sum)
+}
+
+// Diff return unified diff from a to b
+func Diff(a, b, fromName, toName string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(a),
+		B:        splitLines(b),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+}
+
+// splitLines split text into lines with linebreak kept, no empty line for the tailing linebreak
+func splitLines(x string) []string {
+	lines := strings.SplitAfter(x, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,64 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestArchive(t *testing.T) {
+	Convey("archive configs", t, func() {
+		dir, err := ioutil.TempDir("", "netd-archive")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		a, err := New(dir)
+		So(err, ShouldBeNil)
+
+		v, err := a.Store("fw/01", "set", "set a\nset b\n")
+		So(err, ShouldBeNil)
+		So(v.Version, ShouldEqual, 1)
+		// same config, no new version
+		v, err = a.Store("fw/01", "set", "set a\nset b\n")
+		So(err, ShouldBeNil)
+		So(v.Version, ShouldEqual, 1)
+		v, err = a.Store("fw/01", "set", "set a\nset c\n")
+		So(err, ShouldBeNil)
+		So(v.Version, ShouldEqual, 2)
+
+		versions, err := a.Versions("fw/01")
+		So(err, ShouldBeNil)
+		So(versions, ShouldHaveLength, 2)
+		versions, err = a.Versions("fw/02")
+		So(err, ShouldBeNil)
+		So(versions, ShouldBeEmpty)
+
+		c1, _, err := a.Get("fw/01", 1)
+		So(err, ShouldBeNil)
+		c2, v, err := a.Get("fw/01", 0)
+		So(err, ShouldBeNil)
+		So(v.Version, ShouldEqual, 2)
+		_, _, err = a.Get("fw/01", 3)
+		So(err, ShouldNotBeNil)
+
+		diff, err := Diff(c1, c2, "fw/01@1", "fw/01@2")
+		So(err, ShouldBeNil)
+		So(diff, ShouldEqual, "--- fw/01@1\n+++ fw/01@2\n@@ -1,2 +1,2 @@\n set a\n-set b\n+set c\n")
+	})
+}
@@ -0,0 +1,99 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package auth tells who the client of a request is and what it may run. Ingresses put the client identity
+// in ctx of request, handlers take it from there and authorize requests with roles of the client, see Policy.
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+
+	"github.com/sky-cloud-tec/netd/protocol"
+)
+
+type identityKey struct{}
+
+// NewContext return ctx carrying client identity
+func NewContext(ctx context.Context, id *protocol.Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext return client identity of ctx, an anonymous one if not set
+func FromContext(ctx context.Context) *protocol.Identity {
+	if id, ok := ctx.Value(identityKey{}).(*protocol.Identity); ok && id != nil {
+		return id
+	}
+	return &protocol.Identity{}
+}
+
+// FromTLS return identity of client at addr, named after its certificate if it's verified,
+// state may be nil for plain connections
+func FromTLS(state *tls.ConnectionState, addr string) *protocol.Identity {
+	id := &protocol.Identity{Address: addr}
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return id
+	}
+	cert := state.VerifiedChains[0][0]
+	id.Method = protocol.IdentityCertificate
+	id.Name = cert.Subject.CommonName
+	id.Subject = cert.Subject.String()
+	id.SANs = append(id.SANs, cert.DNSNames...)
+	id.SANs = append(id.SANs, cert.EmailAddresses...)
+	for _, v := range cert.URIs {
+		id.SANs = append(id.SANs, v.String())
+	}
+	return id
+}
+
+// Describe return identity for logs, eg. ops-portal@10.0.0.8:51234
+func Describe(id *protocol.Identity) string {
+	name := id.Name
+	if name == "" {
+		name = "anonymous"
+	}
+	if id.Address == "" {
+		return name
+	}
+	return name + "@" + id.Address
+}
+
+// Authenticate return identity of client at addr from its authorization header value, eg. "Bearer xxx",
+// and tls state, token identity wins over certificate one. Error if token unknown or client has no role,
+// never if authorization disabled
+func Authenticate(state *tls.ConnectionState, authorization, addr string) (*protocol.Identity, error) {
+	id := FromTLS(state, addr)
+	if Instance == nil {
+		return id, nil
+	}
+	if token := bearer(authorization); token != "" {
+		tid, err := Instance.Token(token, addr)
+		if err != nil {
+			return id, err
+		}
+		id = tid
+	}
+	return id, Instance.Authenticated(id)
+}
+
+// bearer return token of authorization header value
+func bearer(authorization string) string {
+	const prefix = "bearer "
+	if len(authorization) > len(prefix) && strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return strings.TrimSpace(authorization[len(prefix):])
+	}
+	return ""
+}
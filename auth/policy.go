@@ -0,0 +1,366 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sky-cloud-tec/netd/cli"
+	"github.com/sky-cloud-tec/netd/inventory"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"gopkg.in/yaml.v2"
+)
+
+// Instance is the policy requests are authorized with, nil if authorization disabled
+var Instance *Policy
+
+// Role restricts devices, modes and commands clients may run, empty allow list allows any
+type Role struct {
+	Devices      []string `yaml:"devices"`      // regexps of device address or inventory name of address
+	Modes        []string `yaml:"modes"`        // regexps of modes allowed
+	DenyModes    []string `yaml:"denyModes"`    // regexps of modes denied, eg. ^configure
+	Commands     []string `yaml:"commands"`     // regexps every command must match
+	DenyCommands []string `yaml:"denyCommands"` // regexps of commands denied, eg. ^(reload|erase)
+	Terminal     bool     `yaml:"terminal"`     // web terminal allowed, commands typed there are not checked
+	Admin        bool     `yaml:"admin"`        // anything allowed, operator hotfix and vault admin included
+
+	devices, modes, denyModes, commands, denyCommands []*regexp.Regexp
+}
+
+// Token is an api token, only its sha256 is kept
+type Token struct {
+	Name   string   `yaml:"name"`
+	SHA256 string   `yaml:"sha256"` // hex sha256 of token
+	Roles  []string `yaml:"roles"`
+}
+
+// policyFile is the yaml policy file
+type policyFile struct {
+	Roles     map[string]*Role    `yaml:"roles"`
+	Clients   map[string][]string `yaml:"clients"` // roles of client certificate common names
+	Tokens    []*Token            `yaml:"tokens"`
+	Anonymous []string            `yaml:"anonymous"` // roles of clients not authenticated
+}
+
+// Policy maps clients to roles and authorizes their requests, it's loaded from yaml file
+// and loaded again on Reload
+type Policy struct {
+	path string
+
+	mu     sync.RWMutex
+	file   *policyFile
+	tokens map[string]*Token // keyed by sha256
+	names  map[string]*Token // keyed by name
+}
+
+// Load policy from yaml file
+func Load(path string) (*Policy, error) {
+	p := &Policy{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload load policy file again, policy in use is kept if it fails
+func (p *Policy) Reload() error {
+	b, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("read auth policy error: %s", err)
+	}
+	var f policyFile
+	if err := yaml.UnmarshalStrict(b, &f); err != nil {
+		return fmt.Errorf("parse auth policy %s error: %s", p.path, err)
+	}
+	for name, r := range f.Roles {
+		if r == nil {
+			return fmt.Errorf("role %s is empty", name)
+		}
+		for _, v := range []struct {
+			patterns []string
+			compiled *[]*regexp.Regexp
+		}{
+			{r.Devices, &r.devices},
+			{r.Modes, &r.modes},
+			{r.DenyModes, &r.denyModes},
+			{r.Commands, &r.commands},
+			{r.DenyCommands, &r.denyCommands},
+		} {
+			for _, x := range v.patterns {
+				re, err := regexp.Compile(x)
+				if err != nil {
+					return fmt.Errorf("role %s: %s", name, err)
+				}
+				*v.compiled = append(*v.compiled, re)
+			}
+		}
+	}
+	check := func(who string, roles []string) error {
+		for _, v := range roles {
+			if _, ok := f.Roles[v]; !ok {
+				return fmt.Errorf("%s: role %s not defined", who, v)
+			}
+		}
+		return nil
+	}
+	for name, roles := range f.Clients {
+		if err := check("client "+name, roles); err != nil {
+			return err
+		}
+	}
+	if err := check("anonymous", f.Anonymous); err != nil {
+		return err
+	}
+	tokens := make(map[string]*Token, len(f.Tokens))
+	names := make(map[string]*Token, len(f.Tokens))
+	for i, v := range f.Tokens {
+		if v.Name == "" {
+			return fmt.Errorf("token %d: name required", i)
+		}
+		if _, ok := names[v.Name]; ok {
+			return fmt.Errorf("token %s duplicated", v.Name)
+		}
+		sum := strings.ToLower(v.SHA256)
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("token %s: sha256 of token in hex required", v.Name)
+		}
+		if err := check("token "+v.Name, v.Roles); err != nil {
+			return err
+		}
+		if _, ok := tokens[sum]; ok {
+			return fmt.Errorf("token %s duplicated", v.Name)
+		}
+		tokens[sum], names[v.Name] = v, v
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.file, p.tokens, p.names = &f, tokens, names
+	return nil
+}
+
+// Token return identity of api token, error if token unknown
+func (p *Policy) Token(token, addr string) (*protocol.Identity, error) {
+	sum := sha256.Sum256([]byte(token))
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	t, ok := p.tokens[hex.EncodeToString(sum[:])]
+	if !ok {
+		return nil, fmt.Errorf("unknown token")
+	}
+	return &protocol.Identity{Method: protocol.IdentityToken, Name: t.Name, Address: addr}, nil
+}
+
+// roles return roles of client, p.mu held
+func (p *Policy) roles(id *protocol.Identity) []*Role {
+	var names []string
+	switch id.Method {
+	case protocol.IdentityCertificate:
+		names = p.file.Clients[id.Name]
+	case protocol.IdentityToken:
+		if t, ok := p.names[id.Name]; ok {
+			names = t.Roles
+		}
+	default:
+		names = p.file.Anonymous
+	}
+	roles := make([]*Role, 0, len(names))
+	for _, v := range names {
+		if r, ok := p.file.Roles[v]; ok {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+// Authenticated return error unless client has a role
+func (p *Policy) Authenticated(id *protocol.Identity) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.roles(id)) == 0 {
+		return fmt.Errorf("client %s has no role", Describe(id))
+	}
+	return nil
+}
+
+// Admin return error unless client has admin role
+func (p *Policy) Admin(id *protocol.Identity) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, r := range p.roles(id) {
+		if r.Admin {
+			return nil
+		}
+	}
+	return fmt.Errorf("client %s is not admin", Describe(id))
+}
+
+// Authorize return error unless a role of client allows device, mode and commands of req
+func (p *Policy) Authorize(id *protocol.Identity, req *protocol.CliRequest) error {
+	return p.authorize(id, req, false)
+}
+
+// AuthorizeTerminal return error unless a role of client allows web terminal to device of req
+func (p *Policy) AuthorizeTerminal(id *protocol.Identity, req *protocol.CliRequest) error {
+	return p.authorize(id, req, true)
+}
+
+func (p *Policy) authorize(id *protocol.Identity, req *protocol.CliRequest, terminal bool) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	roles := p.roles(id)
+	if len(roles) == 0 {
+		return fmt.Errorf("client %s has no role", Describe(id))
+	}
+	var entered [][]string
+	if !terminal {
+		entered = enteredModes(req)
+	}
+	reasons := make(map[string]bool)
+	for _, r := range roles {
+		reason := r.deny(req, terminal, entered)
+		if reason == "" {
+			return nil
+		}
+		reasons[reason] = true
+	}
+	s := make([]string, 0, len(reasons))
+	for k := range reasons {
+		s = append(s, k)
+	}
+	sort.Strings(s)
+	return fmt.Errorf("client %s denied, %s", Describe(id), strings.Join(s, "; "))
+}
+
+// deny return why role denies req, empty if allowed, entered are modes each command enters
+func (r *Role) deny(req *protocol.CliRequest, terminal bool, entered [][]string) string {
+	if r.Admin {
+		return ""
+	}
+	if terminal && !r.Terminal {
+		return "terminal not allowed"
+	}
+	if len(r.devices) > 0 && !r.device(req.Address) {
+		return fmt.Sprintf("device %s not allowed", req.Address)
+	}
+	if terminal {
+		// modes and commands are up to keystrokes
+		return ""
+	}
+	if !r.mode(req.Mode) {
+		return fmt.Sprintf("mode %s not allowed", req.Mode)
+	}
+	for i, v := range req.Commands {
+		cmd := strings.TrimSpace(v)
+		if (len(r.commands) > 0 && !matchAny(r.commands, cmd)) || matchAny(r.denyCommands, cmd) {
+			return fmt.Sprintf("command %q not allowed", cli.Redact(nil, cmd))
+		}
+		for _, m := range entered[i] {
+			if !r.mode(m) {
+				return fmt.Sprintf("command %q enters mode %s not allowed", cli.Redact(nil, cmd), m)
+			}
+		}
+	}
+	return ""
+}
+
+// mode return true if role allows mode m
+func (r *Role) mode(m string) bool {
+	return (len(r.modes) == 0 || matchAny(r.modes, m)) && !matchAny(r.denyModes, m)
+}
+
+// enteredModes return modes each command of req enters by transitions of its operator,
+// so a denied mode can't be entered by commands sent in an allowed one
+func enteredModes(req *protocol.CliRequest) [][]string {
+	entered := make([][]string, len(req.Commands))
+	op := cli.OperatorManagerInstance.Get(strings.Join([]string{req.Vendor, req.Type, req.Version}, "."))
+	if op == nil {
+		return entered
+	}
+	modes := cli.GetModes(op)
+	if req.Mode != "" {
+		modes = append(modes, req.Mode)
+	}
+	for _, from := range modes {
+		for _, to := range modes {
+			if from == to {
+				continue
+			}
+			for _, t := range op.GetTransitions(from, to) {
+				// enable password follows linebreak
+				t = strings.SplitN(strings.Replace(t, "\r", "\n", -1), "\n", 2)[0]
+				for i, cmd := range req.Commands {
+					if enters(cmd, t) && !contains(entered[i], to) {
+						entered[i] = append(entered[i], to)
+					}
+				}
+			}
+		}
+	}
+	return entered
+}
+
+// enters return true if cmd is transition command t, words of both may be abbreviated and cmd may
+// have more, eg. `conf t` and `configure terminal` are `config terminal`, `configure private` is `configure`
+func enters(cmd, t string) bool {
+	cw, tw := strings.Fields(strings.ToLower(cmd)), strings.Fields(strings.ToLower(t))
+	if len(tw) == 0 || len(cw) < len(tw) {
+		return false
+	}
+	for i, w := range tw {
+		if !strings.HasPrefix(w, cw[i]) && !strings.HasPrefix(cw[i], w) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(s []string, x string) bool {
+	for _, v := range s {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+// device return true if role allows device of address, device name of request is up to client
+// and not trusted, name of the inventory device at address is
+func (r *Role) device(addr string) bool {
+	if matchAny(r.devices, addr) {
+		return true
+	}
+	if inventory.Instance == nil {
+		return false
+	}
+	d := inventory.Instance.ByAddress(addr)
+	return d != nil && matchAny(r.devices, d.Name)
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, v := range res {
+		if v.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
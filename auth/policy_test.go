@@ -0,0 +1,166 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/sky-cloud-tec/netd/cli/juniper/srx" // load juniper srx
+	"github.com/sky-cloud-tec/netd/inventory"
+	"github.com/sky-cloud-tec/netd/protocol"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testPolicy = `roles:
+  readonly:
+    modes: ["^login$"]
+    commands: ["^show ", "^ping "]
+  operator:
+    devices: ["^fw-", "^10\\.9\\."]
+    denyModes: ["^configure"]
+    denyCommands: ["^request system reboot", "^(delete|erase)"]
+    terminal: true
+  admin:
+    admin: true
+clients:
+  ops-portal: [operator]
+tokens:
+  - name: compliance-bot
+    sha256: ed55d950c5f4d763e35c62f0f3149e005947542ddf7c4a24a5e1db08372cfde3
+    roles: [readonly]
+  - name: root
+    sha256: 77A4E206296282B0C1ACEBC0BEBFF60856CF558F731762D241CB9BE07B60119A
+    roles: [admin]
+`
+
+func TestPolicy(t *testing.T) {
+	Convey("clients authorized by roles", t, func() {
+		dir, err := ioutil.TempDir("", "auth")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "policy.yaml")
+		So(ioutil.WriteFile(path, []byte(testPolicy), 0600), ShouldBeNil)
+		p, err := Load(path)
+		So(err, ShouldBeNil)
+
+		bot, err := p.Token("s3cret-bot", "10.0.0.9:5000")
+		So(err, ShouldBeNil)
+		So(bot.Method, ShouldEqual, protocol.IdentityToken)
+		So(bot.Name, ShouldEqual, "compliance-bot")
+		root, err := p.Token("s3cret-admin", "")
+		So(err, ShouldBeNil)
+		_, err = p.Token("guess", "")
+		So(err, ShouldNotBeNil)
+		portal := &protocol.Identity{Method: protocol.IdentityCertificate, Name: "ops-portal"}
+		stranger := &protocol.Identity{Method: protocol.IdentityCertificate, Name: "stranger"}
+		anonymous := &protocol.Identity{Address: "10.0.0.9:5000"}
+
+		So(p.Authenticated(bot), ShouldBeNil)
+		So(p.Authenticated(portal), ShouldBeNil)
+		So(p.Authenticated(stranger), ShouldNotBeNil)
+		So(p.Authenticated(anonymous), ShouldNotBeNil)
+		So(p.Admin(root), ShouldBeNil)
+		So(p.Admin(portal), ShouldNotBeNil)
+
+		inventory.Instance = &inventory.Inventory{Devices: []*inventory.Device{
+			{Name: "fw-01", Address: "10.0.0.1:22"},
+			{Name: "sw-01", Address: "10.0.0.2:22"},
+		}}
+		defer func() { inventory.Instance = nil }()
+		req := func(device, mode string, commands ...string) *protocol.CliRequest {
+			r := &protocol.CliRequest{Device: device, Address: "10.0.0.2:22", Mode: mode, Commands: commands}
+			if device == "fw-01" {
+				r.Address = "10.0.0.1:22"
+			}
+			return r
+		}
+		So(p.Authorize(bot, req("sw-01", "login", "show version", " ping 10.0.0.1")), ShouldBeNil)
+		So(p.Authorize(bot, req("sw-01", "configure", "show version")), ShouldNotBeNil)
+		err = p.Authorize(bot, req("sw-01", "login", "show version", "request system reboot"))
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "compliance-bot")
+		So(err.Error(), ShouldContainSubstring, `command "request system reboot" not allowed`)
+
+		So(p.Authorize(portal, req("fw-01", "login", "show version", "clear arp")), ShouldBeNil)
+		So(p.Authorize(portal, req("sw-01", "login", "show version")), ShouldNotBeNil)
+		// device name is up to client, address decides
+		spoofed := req("sw-01", "login", "show version")
+		spoofed.Device = "fw-01"
+		So(p.Authorize(portal, spoofed), ShouldNotBeNil)
+		spoofed.Address = "10.9.0.1:22"
+		So(p.Authorize(portal, spoofed), ShouldBeNil)
+		So(p.Authorize(portal, req("fw-01", "configure_private", "set system host-name x")), ShouldNotBeNil)
+		So(p.Authorize(portal, req("fw-01", "login", "request system reboot")), ShouldNotBeNil)
+		So(p.Authorize(portal, req("fw-01", "login", "erase startup-config")), ShouldNotBeNil)
+		// denied modes can't be entered by transition commands sent in an allowed mode
+		srx := func(commands ...string) *protocol.CliRequest {
+			r := req("fw-01", "login", commands...)
+			r.Vendor, r.Type, r.Version = "juniper", "srx", "12.1"
+			return r
+		}
+		err = p.Authorize(portal, srx("configure", "delete security policies", "commit"))
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, `command "configure" enters mode configure not allowed`)
+		So(p.Authorize(portal, srx("show version", "conf private")), ShouldNotBeNil)
+		So(p.Authorize(portal, srx("show configuration", "show conf")), ShouldBeNil)
+		So(enters("conf t", "config terminal"), ShouldBeTrue)
+		So(enters("configure terminal", "config terminal"), ShouldBeTrue)
+		So(enters("show configuration", "configure"), ShouldBeFalse)
+		So(p.Authorize(root, req("sw-01", "configure", "request system reboot")), ShouldBeNil)
+		So(p.Authorize(anonymous, req("fw-01", "login", "show version")), ShouldNotBeNil)
+
+		So(p.AuthorizeTerminal(portal, req("fw-01", "")), ShouldBeNil)
+		So(p.AuthorizeTerminal(portal, req("sw-01", "")), ShouldNotBeNil)
+		So(p.AuthorizeTerminal(bot, req("sw-01", "login")), ShouldNotBeNil)
+
+		// broken policy not loaded, the one in use kept
+		So(ioutil.WriteFile(path, []byte("roles:\n  x:\n    modes: [\"(\"]\n"), 0600), ShouldBeNil)
+		So(p.Reload(), ShouldNotBeNil)
+		So(p.Authorize(bot, req("sw-01", "login", "show version")), ShouldBeNil)
+		// tokens removed lose their roles
+		So(ioutil.WriteFile(path, []byte("roles:\n  admin:\n    admin: true\nanonymous: [admin]\n"), 0600), ShouldBeNil)
+		So(p.Reload(), ShouldBeNil)
+		So(p.Authorize(bot, req("sw-01", "login", "show version")), ShouldNotBeNil)
+		So(p.Authorize(anonymous, req("sw-01", "login", "show version")), ShouldBeNil)
+	})
+
+	Convey("bad policies rejected", t, func() {
+		dir, err := ioutil.TempDir("", "auth")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "policy.yaml")
+		for _, v := range []string{
+			"clients:\n  portal: [nope]\n",
+			"roles:\n  x:\n    admin: true\ntokens:\n  - {name: t, sha256: abc, roles: [x]}\n",
+			"roles:\n  x:\n    admn: true\n",
+			"anonymous: [x]\n",
+		} {
+			So(ioutil.WriteFile(path, []byte(v), 0600), ShouldBeNil)
+			_, err := Load(path)
+			So(err, ShouldNotBeNil)
+		}
+	})
+
+	Convey("bearer token taken from authorization header", t, func() {
+		So(bearer("Bearer abc"), ShouldEqual, "abc")
+		So(bearer("bearer  abc "), ShouldEqual, "abc")
+		So(bearer("Basic abc"), ShouldBeEmpty)
+		So(bearer(""), ShouldBeEmpty)
+	})
+}
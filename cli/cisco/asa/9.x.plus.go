@@ -43,8 +43,10 @@ func createOp9xPlus() cli.Operator {
 	configTerminalPrompt := regexp.MustCompile(`[[:alnum:]]{1,}(-[[:alnum:]]+){0,}\(config\)# $`)
 	return &op9xPlus{
 		// mode transition
-		// login_enable -> configure_terminal
+		// login -> login_enable -> configure_terminal
 		transitions: map[string][]string{
+			"login->login_enable":              {"enable\r" + cli.EnablePwdPlaceholder},
+			"login_enable->login":              {"disable"},
 			"login_enable->configure_terminal": {"configure terminal"},
 			"configure_terminal->login_enable": {"exit"},
 		},
@@ -68,6 +70,11 @@ func (s *op9xPlus) GetPrompts(k string) []*regexp.Regexp {
 	return nil
 }
 
+// GetModes return modes which have prompts registered, paths go through login_enable
+func (s *op9xPlus) GetModes() []string {
+	return cli.PromptModes(s.prompts)
+}
+
 func (s *op9xPlus) GetExcludes() []*regexp.Regexp {
 	return nil
 }
@@ -100,6 +107,28 @@ func (s *op9xPlus) GetStartMode() string {
 	return "login_or_login_enable"
 }
 
+// GetPlatform return ntc-templates platform name
+func (s *op9xPlus) GetPlatform() string {
+	return "cisco_asa"
+}
+
+// GetResyncs end leaves config modes
+func (s *op9xPlus) GetResyncs() []string {
+	return []string{"", cli.CtrlC, "end"}
+}
+
+// GetSecretPatterns cisco key strings and snmpv3 user keys
+func (s *op9xPlus) GetSecretPatterns() []*regexp.Regexp {
+	return cli.CiscoSecretPatterns
+}
+
+// GetBackups asa config backups
+func (s *op9xPlus) GetBackups() []*cli.Backup {
+	return []*cli.Backup{
+		{Format: "text", Mode: "login_enable", Commands: []string{"show running-config"}},
+	}
+}
+
 func (s *op9xPlus) RegisterMode(req *protocol.CliRequest) error {
 	return nil
 }
@@ -133,3 +162,51 @@ func (s *op9xPlus) GetSSHInitializer() cli.SSHInitializer {
 		return r, w, session, nil
 	}
 }
+
+// PostLogin figure out whether device is in privileged mode or not,
+// entering privileged mode is done by login -> login_enable transition
+func (s *op9xPlus) PostLogin(sess cli.Session, prompt string) error {
+	if sess.Mode() == "login_or_login_enable" {
+		// not sure what mode it is
+		// check prompt
+		if cli.AnyMatch(s.GetPrompts("login"), prompt) {
+			// in login, not enabled, no close page here
+			sess.SetMode("login")
+			return nil
+		}
+		sess.SetMode("login_enable")
+	}
+	return s.DisablePager(sess)
+}
+
+// PreExec close page once privileged mode entered
+func (s *op9xPlus) PreExec(sess cli.Session) error {
+	if _, ok := sess.Get("pager"); ok {
+		return nil
+	}
+	return s.DisablePager(sess)
+}
+
+// DisablePager set terminal pager, login mode no close page
+func (s *op9xPlus) DisablePager(sess cli.Session) error {
+	if sess.Mode() == "login" {
+		return nil
+	}
+	// ===config or normal both ok===
+	// set terminal pager
+	if _, err := sess.WriteBuff("terminal pager 0"); err != nil {
+		return err
+	}
+	if _, _, err := sess.ReadBuff(); err != nil {
+		return err
+	}
+	// set page lines
+	if _, err := sess.WriteBuff("terminal pager lines 0"); err != nil {
+		return err
+	}
+	if _, _, err := sess.ReadBuff(); err != nil {
+		return err
+	}
+	sess.Set("pager", true)
+	return nil
+}
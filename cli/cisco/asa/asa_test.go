@@ -48,3 +48,31 @@ func TestAsaOp(t *testing.T) {
 		)
 	})
 }
+
+func TestAsaTransitions(t *testing.T) {
+
+	Convey("asa transitions", t, func() {
+		op := createOp9xPlus()
+		So(
+			cli.ShortestPath(op, "login", "configure_terminal"),
+			ShouldResemble,
+			[]string{"login", "login_enable", "configure_terminal"},
+		)
+		So(
+			cli.ShortestPath(op, "configure_terminal", "login"),
+			ShouldResemble,
+			[]string{"configure_terminal", "login_enable", "login"},
+		)
+	})
+}
+
+func TestAsaDetectMode(t *testing.T) {
+
+	Convey("asa detect mode", t, func() {
+		op := createOp9xPlus()
+		So(cli.DetectMode(op, "asaNAT# ", "login"), ShouldEqual, "login_enable")
+		So(cli.DetectMode(op, "asaNAT> ", "login_enable"), ShouldEqual, "login")
+		So(cli.DetectMode(op, "asaNAT(config)# ", "login"), ShouldEqual, "configure_terminal")
+		So(cli.DetectMode(op, "% Invalid input", "login"), ShouldEqual, "")
+	})
+}
@@ -44,8 +44,10 @@ func createSwitchIos() cli.Operator {
 	configTerminalPrompt := regexp.MustCompile(`[[:alnum:]]{1,}(-[[:alnum:]]+){0,}\(config\)#$`)
 	return &SwitchIos{
 		// mode transition
-		// login_enable -> configure_terminal
+		// login -> login_enable -> configure_terminal
 		transitions: map[string][]string{
+			"login->login_enable":              {"enable\r" + cli.EnablePwdPlaceholder},
+			"login_enable->login":              {"disable"},
 			"login_enable->configure_terminal": {"config terminal"},
 			"configure_terminal->login_enable": {"exit"},
 		},
@@ -72,6 +74,11 @@ func (s *SwitchIos) GetPrompts(k string) []*regexp.Regexp {
 	return nil
 }
 
+// GetModes return modes which have prompts registered, paths go through login_enable
+func (s *SwitchIos) GetModes() []string {
+	return cli.PromptModes(s.prompts)
+}
+
 func (s *SwitchIos) SetPrompts(k string, regs []*regexp.Regexp) {
 	s.prompts[k] = regs
 }
@@ -114,6 +121,28 @@ func (s *SwitchIos) GetStartMode() string {
 	return "login_or_login_enable"
 }
 
+// GetPlatform return ntc-templates platform name
+func (s *SwitchIos) GetPlatform() string {
+	return "cisco_ios"
+}
+
+// GetResyncs end leaves config modes
+func (s *SwitchIos) GetResyncs() []string {
+	return []string{"", cli.CtrlC, "end"}
+}
+
+// GetSecretPatterns cisco key strings and snmpv3 user keys
+func (s *SwitchIos) GetSecretPatterns() []*regexp.Regexp {
+	return cli.CiscoSecretPatterns
+}
+
+// GetBackups ios config backups
+func (s *SwitchIos) GetBackups() []*cli.Backup {
+	return []*cli.Backup{
+		{Format: "text", Mode: "login_enable", Commands: []string{"show running-config"}},
+	}
+}
+
 // RegisterMode ...
 func (s *SwitchIos) RegisterMode(req *protocol.CliRequest) error {
 	return nil
@@ -145,3 +174,42 @@ func (s *SwitchIos) GetSSHInitializer() cli.SSHInitializer {
 		return r, w, session, nil
 	}
 }
+
+// PostLogin figure out whether device is in privileged mode or not,
+// entering privileged mode is done by login -> login_enable transition
+func (s *SwitchIos) PostLogin(sess cli.Session, prompt string) error {
+	if sess.Mode() == "login_or_login_enable" {
+		// not sure what mode it is
+		// check prompt
+		if cli.AnyMatch(s.GetPrompts("login"), prompt) {
+			// in login, not enabled, no close page here
+			sess.SetMode("login")
+			return nil
+		}
+		sess.SetMode("login_enable")
+	}
+	return s.DisablePager(sess)
+}
+
+// PreExec close page once privileged mode entered
+func (s *SwitchIos) PreExec(sess cli.Session) error {
+	if _, ok := sess.Get("pager"); ok {
+		return nil
+	}
+	return s.DisablePager(sess)
+}
+
+// DisablePager set terminal length, login mode no close page
+func (s *SwitchIos) DisablePager(sess cli.Session) error {
+	if sess.Mode() == "login" {
+		return nil
+	}
+	if _, err := sess.WriteBuff("terminal length 0"); err != nil {
+		return err
+	}
+	if _, _, err := sess.ReadBuff(); err != nil {
+		return err
+	}
+	sess.Set("pager", true)
+	return nil
+}
@@ -69,6 +69,11 @@ func (s *SwitchNxos) GetPrompts(k string) []*regexp.Regexp {
 	return nil
 }
 
+// GetModes return modes which have prompts registered
+func (s *SwitchNxos) GetModes() []string {
+	return cli.PromptModes(s.prompts)
+}
+
 func (s *SwitchNxos) SetPrompts(k string, regs []*regexp.Regexp) {
 	s.prompts[k] = regs
 }
@@ -101,6 +106,28 @@ func (s *SwitchNxos) GetStartMode() string {
 	return "login"
 }
 
+// GetPlatform return ntc-templates platform name
+func (s *SwitchNxos) GetPlatform() string {
+	return "cisco_nxos"
+}
+
+// GetResyncs end leaves config modes
+func (s *SwitchNxos) GetResyncs() []string {
+	return []string{"", cli.CtrlC, "end"}
+}
+
+// GetSecretPatterns cisco key strings and snmpv3 user keys
+func (s *SwitchNxos) GetSecretPatterns() []*regexp.Regexp {
+	return cli.CiscoSecretPatterns
+}
+
+// GetBackups nxos config backups
+func (s *SwitchNxos) GetBackups() []*cli.Backup {
+	return []*cli.Backup{
+		{Format: "text", Mode: "login", Commands: []string{"show running-config"}},
+	}
+}
+
 // RegisterMode ...
 func (s *SwitchNxos) RegisterMode(req *protocol.CliRequest) error {
 	return nil
@@ -21,6 +21,7 @@ import (
 	"github.com/songtianyi/rrframework/logs"
 	"io"
 	"regexp"
+	"sort"
 )
 
 // AnyMatch return true if any regex in patterns matches the input string
@@ -49,6 +50,37 @@ func Match(p *regexp.Regexp, s string) bool {
 	return len(matches) > 0
 }
 
+// DetectMode return the mode whose prompts match the prompt, current mode is preferred
+// when several modes share the same prompts. "" returned if no mode matches
+func DetectMode(op Operator, prompt, current string) string {
+	if AnyMatch(op.GetExcludes(), prompt) {
+		return ""
+	}
+	if AnyMatch(op.GetPrompts(current), prompt) {
+		return current
+	}
+	modes := GetModes(op)
+	sort.Strings(modes)
+	for _, m := range modes {
+		if AnyMatch(op.GetPrompts(m), prompt) {
+			return m
+		}
+	}
+	return ""
+}
+
+// AllPrompts return prompts of current mode and all modes
+func AllPrompts(op Operator, current string) []*regexp.Regexp {
+	all := append([]*regexp.Regexp{}, op.GetPrompts(current)...)
+	for _, m := range GetModes(op) {
+		if m == current {
+			continue
+		}
+		all = append(all, op.GetPrompts(m)...)
+	}
+	return all
+}
+
 // ReadStringUntil read string from reader until specified regex pattern matched
 func ReadStringUntil(r io.Reader, p *regexp.Regexp) (string, error) {
 	if p == nil || r == nil {
@@ -38,3 +38,27 @@ func TestMoreOK(t *testing.T) {
 	})
 
 }
+
+func TestCleanConfig(t *testing.T) {
+	Convey("clean config", t, func() {
+		out := "show running-config\r\n: Saved\r\n<--- More --->\b\b\b\b\b\b\b\b\b\b\b\b\b\b              \b\b\b\b\b\b\b\b\b\b\b\b\b\bhostname asa\r\n\r\n"
+		So(CleanConfig(Normalize("show running-config", out)), ShouldEqual, ": Saved\nhostname asa\n")
+		So(Checksum("x\n"), ShouldHaveLength, 64)
+	})
+}
+
+func TestNormalize(t *testing.T) {
+	Convey("normalize output", t, func() {
+		So(Normalize("show clock", "show clock\r\n10:00:00\r\n"), ShouldEqual, "10:00:00\n")
+		So(Normalize("show clock", "admin@pa> show clock\n10:00:00\n"), ShouldEqual, "10:00:00\n")
+		// vt100 colors and erase
+		So(Normalize("ls", "ls\r\n\x1b[0m\x1b[01;34mbin\x1b[0m  etc\x1b[K\r\n"), ShouldEqual, "bin  etc\n")
+		// overstrike
+		So(Normalize("", "abc\bd\r\n"), ShouldEqual, "abd\n")
+		// huawei pager erased by cursor back
+		So(Normalize("", "a\n  ---- More ----\x1b[16D                \x1b[16Db\n"), ShouldEqual, "a\nb\n")
+		// pager residue not erased
+		So(Normalize("", "a\n---(more 45%)---\nb\n"), ShouldEqual, "a\nb\n")
+		So(Normalize("", "a --More-- b\n"), ShouldEqual, "a b\n")
+	})
+}
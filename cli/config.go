@@ -0,0 +1,63 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Backup tells how to dump config in one format
+type Backup struct {
+	Format   string   // config format, eg. set, text, xml
+	Mode     string   // mode where commands executed, empty for request mode, eg. fortigate vdom
+	Commands []string // commands dumping config, outputs concatenated
+}
+
+// ConfigFetcher is implemented by operators which can dump config, the first backup is the default one
+type ConfigFetcher interface {
+	GetBackups() []*Backup
+}
+
+// GetBackup return backup of format, the default one if format is empty, nil if not found
+func GetBackup(op Operator, format string) *Backup {
+	h, ok := op.(ConfigFetcher)
+	if !ok {
+		return nil
+	}
+	for _, v := range h.GetBackups() {
+		if format == "" || strings.EqualFold(v.Format, format) {
+			return v
+		}
+	}
+	return nil
+}
+
+// CleanConfig remove blank lines around normalized config
+func CleanConfig(x string) string {
+	x = strings.Trim(x, "\n")
+	if x == "" {
+		return ""
+	}
+	return x + "\n"
+}
+
+// Checksum return sha256 hex of config
+func Checksum(config string) string {
+	sum := sha256.Sum256([]byte(config))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,49 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package conn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sky-cloud-tec/netd/cli"
+)
+
+// FetchConfig dump config with operator backup of format, the default one used if format is empty.
+// config is returned without echo, pager and prompt noise
+func (s *CliConn) FetchConfig(ctx context.Context, format string) (string, *cli.Backup, error) {
+	b := cli.GetBackup(s.op, format)
+	if b == nil {
+		return "", nil, fmt.Errorf("no backup of format %q", format)
+	}
+	if b.Mode != "" {
+		s.req.Mode = b.Mode
+	}
+	s.req.Format = b.Format
+	// config is always normalized
+	s.req.Raw = false
+	s.req.Commands = b.Commands
+	results, err := s.Exec(ctx)
+	if err != nil {
+		return "", b, err
+	}
+	configs := make([]string, 0, len(results))
+	for _, v := range results {
+		configs = append(configs, cli.CleanConfig(v.Output))
+	}
+	return strings.Join(configs, ""), b, nil
+}
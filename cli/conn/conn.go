@@ -18,16 +18,19 @@ package conn
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"os"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/saintfish/chardet"
 	"github.com/sky-cloud-tec/netd/cli"
 	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/record"
 	"github.com/songtianyi/rrframework/logs"
 
 	"github.com/sky-cloud-tec/netd/common"
@@ -38,29 +41,112 @@ import (
 )
 
 var (
-	conns map[string]*CliConn
-	semas map[string]chan struct{}
+	mu      sync.Mutex // guards conns, semas and holders
+	conns   map[string]*CliConn
+	semas   map[string]chan struct{}
+	holders map[string]*protocol.CliRequest // request holding sema of address
+)
+
+var (
+	// resyncTimeout is how long to wait for a prompt after each resync sequence
+	resyncTimeout = 10 * time.Second
+	resyncQuiet   = 500 * time.Millisecond
+	// interruptTimeout is how long to wait for a prompt after each interrupt sequence
+	interruptTimeout = 5 * time.Second
+	// breakLength is ssh break length in milliseconds
+	breakLength uint32 = 500
 )
 
 func init() {
 	conns = make(map[string]*CliConn, 0)
 	semas = make(map[string]chan struct{}, 0)
+	holders = make(map[string]*protocol.CliRequest, 0)
 	go func() {
 		dick := time.Tick(15 * time.Hour)
 		for {
 			select {
 			case <-dick:
 				ms := make([]string, 0)
+				mu.Lock()
 				for k, v := range semas {
 					ms = append(ms, fmt.Sprintf("%s:%d", k, len(v)))
 				}
+				mu.Unlock()
 				logs.Debug("semas", strings.Join(ms, ","))
-				logs.Debug("conns", conns)
+				logs.Debug("conns", List())
 			}
 		}
 	}()
 }
 
+// sema return semaphore of address, created if not exists
+func sema(addr string) chan struct{} {
+	mu.Lock()
+	defer mu.Unlock()
+	if semas[addr] == nil {
+		semas[addr] = make(chan struct{}, 1)
+	}
+	return semas[addr]
+}
+
+// take sema of req address, it waits until sema is free or ctx done
+func take(ctx context.Context, req *protocol.CliRequest) error {
+	select {
+	case sema(req.Address) <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	mu.Lock()
+	holders[req.Address] = req
+	mu.Unlock()
+	return nil
+}
+
+func getConn(addr string) (*CliConn, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	v, ok := conns[addr]
+	return v, ok
+}
+
+func putConn(addr string, c *CliConn) {
+	mu.Lock()
+	defer mu.Unlock()
+	conns[addr] = c
+}
+
+// deleteConn remove c from cache, a newer conn of address is kept
+func deleteConn(addr string, c *CliConn) {
+	mu.Lock()
+	defer mu.Unlock()
+	if conns[addr] == c {
+		delete(conns, addr)
+	}
+}
+
+// List return cached connections ordered by address
+func List() []*protocol.ConnInfo {
+	mu.Lock()
+	defer mu.Unlock()
+	infos := make([]*protocol.ConnInfo, 0, len(conns))
+	for k, v := range conns {
+		req := v.req
+		infos = append(infos, &protocol.ConnInfo{
+			Address:    k,
+			Device:     req.Device,
+			Protocol:   req.Protocol,
+			Username:   req.Auth.Username,
+			Credential: req.Auth.Credential,
+			Mode:       v.mode,
+			Busy:       len(semas[k]) > 0,
+			Recording:  v.Recording(),
+			Created:    v.created,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Address < infos[j].Address })
+	return infos
+}
+
 // CliConn cli connection
 type CliConn struct {
 	t    int                  // connection type 0 = ssh, 1 = telnet
@@ -75,35 +161,247 @@ type CliConn struct {
 	r       io.Reader      // ssh session stdout
 	w       io.WriteCloser // ssh session stdin
 
-	formatSet bool
-	closed    bool // to indicate cli conn closed or not
+	out      chan []byte   // device output pumped from session/conn
+	done     chan struct{} // closed by Close, pump stops sending then
+	doneOnce sync.Once
+	errMu    sync.Mutex // guards readErr, it's set by pump
+	readErr  error      // error which stopped the pump
+
+	values    map[string]interface{} // session scoped values set by operator hooks
+	closed    bool                   // to indicate cli conn closed or not
+	timedOut  bool                   // read timed out during current exec
+	recovered bool                   // session recovered after current exec failed
+	failed    bool                   // last exec failed, mode is resynced before conn reused
+	prompt    string                 // prompt matched by last read
+	encoding  string                 // encoding detected by last read
+	// encodings last read converted from and last write transcoded to
+	outEncoding, inEncoding string
+
+	recMu    sync.Mutex   // guards rec and recLines, output is recorded by pump
+	rec      *record.Cast // recording of current request, nil if not recorded
+	recLines lines        // output recorded once line completes or prompt matched
+	logLines lines        // output logged once line completes or prompt matched, used by reader only
+
+	each    func(*protocol.CmdResult) // called with every command result, see ExecEach
+	created time.Time                 // when connection established
+	ctx     context.Context           // context of current call, reads and writes stop once it's done
+}
+
+// canceledError tells exec stopped at command boundary, session is at a known prompt
+type canceledError struct {
+	err error
+}
+
+func (e *canceledError) Error() string {
+	return "canceled before command: " + e.err.Error()
+}
+
+// Request return the cli request currently served
+func (s *CliConn) Request() *protocol.CliRequest {
+	return s.req
+}
+
+// Mode return current cli mode
+func (s *CliConn) Mode() string {
+	return s.mode
+}
+
+// SetMode set current cli mode
+func (s *CliConn) SetMode(m string) {
+	s.mode = m
+}
+
+// Set store session scoped value
+func (s *CliConn) Set(key string, v interface{}) {
+	if s.values == nil {
+		s.values = make(map[string]interface{})
+	}
+	s.values[key] = v
+}
+
+// Get return session scoped value
+func (s *CliConn) Get(key string) (interface{}, bool) {
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Recording return id of current recording, empty if not recorded
+func (s *CliConn) Recording() string {
+	if c := s.recorder(); c != nil {
+		return c.ID
+	}
+	return ""
+}
+
+func (s *CliConn) recorder() *record.Cast {
+	s.recMu.Lock()
+	defer s.recMu.Unlock()
+	return s.rec
+}
+
+// startRecording start recording current request if asked, recording of previous request is stopped
+func (s *CliConn) startRecording() {
+	s.stopRecording()
+	if record.Instance == nil || !record.Instance.Enabled(s.req) {
+		return
+	}
+	c, err := record.Instance.Start(s.req)
+	if err != nil {
+		logs.Error(s.req.LogPrefix, "start recording error:", err)
+		return
+	}
+	logs.Info(s.req.LogPrefix, "recording session to", c.ID)
+	s.recMu.Lock()
+	s.rec = c
+	s.recMu.Unlock()
+}
+
+func (s *CliConn) stopRecording() {
+	s.flushRecording()
+	s.recMu.Lock()
+	c := s.rec
+	s.rec = nil
+	s.recMu.Unlock()
+	if err := c.Close(); err != nil {
+		logs.Error(s.req.LogPrefix, "close recording error:", err)
+	}
+}
+
+// recordOutput record device output line by line, a secret split across reads is redacted as a whole
+func (s *CliConn) recordOutput(b []byte) {
+	s.recMu.Lock()
+	defer s.recMu.Unlock()
+	if s.rec == nil {
+		return
+	}
+	if b = s.recLines.complete(b); b != nil {
+		s.rec.Output(s.recordable(b, s.outputCharset()))
+	}
+}
+
+// flushRecording record partial line held by recordOutput, eg. prompt
+func (s *CliConn) flushRecording() {
+	s.recMu.Lock()
+	defer s.recMu.Unlock()
+	if b := s.recLines.flush(); s.rec != nil && b != nil {
+		s.rec.Output(s.recordable(b, s.outputCharset()))
+	}
+}
+
+// logOutput log output read once lines complete, so secrets split across reads are masked as a whole
+func (s *CliConn) logOutput(b []byte) {
+	if b = s.logLines.complete(b); b != nil {
+		logs.Debug(s.req.LogPrefix, "(", len(b), ")", s.redact(string(b)))
+	}
+}
+
+// flushLog log partial line held by logOutput, eg. prompt
+func (s *CliConn) flushLog() {
+	if b := s.logLines.flush(); b != nil {
+		logs.Debug(s.req.LogPrefix, "(", len(b), ")", s.redact(string(b)))
+	}
+}
+
+// lines holds device output until lines complete
+type lines struct {
+	pending []byte // output after last newline
+}
+
+// complete append b and return lines completed by it, nil if none
+func (l *lines) complete(b []byte) []byte {
+	l.pending = append(l.pending, b...)
+	i := bytes.LastIndexByte(l.pending, '\n')
+	if i < 0 {
+		return nil
+	}
+	out := append([]byte(nil), l.pending[:i+1]...)
+	l.pending = append(l.pending[:0], l.pending[i+1:]...)
+	return out
+}
+
+// flush return partial line held, nil if none
+func (l *lines) flush() []byte {
+	if len(l.pending) == 0 {
+		return nil
+	}
+	out := append([]byte(nil), l.pending...)
+	l.pending = l.pending[:0]
+	return out
+}
+
+// outputCharset return charset output recorded from, request output encoding or operator encoding,
+// lines are too short to detect it
+func (s *CliConn) outputCharset() string {
+	if s.req.OutputEncoding != "" {
+		return s.req.OutputEncoding
+	}
+	return s.op.GetEncoding()
+}
+
+// recordable convert b of charset enc to utf-8 and mask secrets, recordings are json
+func (s *CliConn) recordable(b []byte, enc string) []byte {
+	if u, err := common.ConvToUTF8(enc, b); err == nil {
+		b = u
+	}
+	return []byte(s.redact(string(b)))
+}
+
+// redact mask secrets in x before it's logged or recorded, request credentials included
+func (s *CliConn) redact(x string) string {
+	return cli.Redact(s.op, x, s.req.Auth.Password, s.req.EnablePwd)
+}
+
+// Recovered return true if last Exec failed but session was brought back to a known prompt
+func (s *CliConn) Recovered() bool {
+	return s.recovered
 }
 
-// Acquire cli conn
-func Acquire(req *protocol.CliRequest, op cli.Operator) (*CliConn, error) {
+// RecoveryTimeout return the longest time Exec may take to recover session after failure
+func RecoveryTimeout(op cli.Operator) time.Duration {
+	return 2*resyncQuiet +
+		time.Duration(len(cli.GetInterrupts(op)))*interruptTimeout +
+		time.Duration(len(cli.GetResyncs(op)))*resyncTimeout
+}
+
+// Acquire cli conn, it gives up waiting for sema or logging in once ctx done.
+// Release should be called with req no matter Acquire fails or not
+func Acquire(ctx context.Context, req *protocol.CliRequest, op cli.Operator) (*CliConn, error) {
 	// limit concurrency to 1
 	// there only one req for one connection always
 	logs.Info(req.LogPrefix, "Acquiring sema...")
-	if semas[req.Address] == nil {
-		semas[req.Address] = make(chan struct{}, 1)
+	if err := take(ctx, req); err != nil {
+		logs.Error(req.LogPrefix, "acquire sema error:", err)
+		return nil, fmt.Errorf("acquire sema error: %s", err)
 	}
-	// try
-	semas[req.Address] <- struct{}{}
 	logs.Info(req.LogPrefix, "sema acquired")
 	// no matter what going on next, sema should be released once
 	if req.Mode == "" {
 		req.Mode = op.GetStartMode()
 	}
+	cs, err := credentials(req)
+	if err != nil {
+		return nil, err
+	}
 	// if cli conn already created
-	if v, ok := conns[req.Address]; ok {
+	if v, ok := getConn(req.Address); ok {
 		if v.req.Auth.Username == req.Auth.Username &&
+			v.req.Auth.Credential == req.Auth.Credential &&
 			v.req.Protocol == req.Protocol {
 			// same user
 			// use exist conn
 			v.req = req
 			v.op = op
+			v.ctx = ctx
+			v.startRecording()
 			logs.Info(req.LogPrefix, "user", req.Auth.Username, "cli conn exist")
-			return v, nil
+			if !v.failed {
+				return v, nil
+			}
+			// late output of failed exec may have moved device away from cached mode
+			if err := v.Resync(); err == nil {
+				return v, nil
+			}
+			logs.Error(req.LogPrefix, "resync cached conn failed, reconnecting")
 		}
 		// new user
 		if v.req.Auth.Username != req.Auth.Username {
@@ -116,81 +414,111 @@ func Acquire(req *protocol.CliRequest, op cli.Operator) (*CliConn, error) {
 		// close old conn
 		v.Close()
 	}
-	c, err := newCliConn(req, op)
+	c, err := dial(ctx, req, op, cs)
 	if err != nil {
 		// sema will be released in parent func
 		return nil, err
 	}
-	conns[req.Address] = c
+	putConn(req.Address, c)
 	return c, nil
 }
 
-// Release cli conn
+// Release cli conn, sema is released only if req holds it
 func Release(req *protocol.CliRequest) {
-	if len(semas[req.Address]) > 0 {
-		logs.Info(req.LogPrefix, "Releasing sema")
-		<-semas[req.Address]
+	if v, ok := getConn(req.Address); ok && v.req == req {
+		v.stopRecording()
+	}
+	mu.Lock()
+	held := holders[req.Address] == req
+	if held {
+		delete(holders, req.Address)
+	}
+	mu.Unlock()
+	if !held {
+		logs.Info(req.LogPrefix, "sema not held")
+		return
 	}
+	logs.Info(req.LogPrefix, "Releasing sema")
+	<-sema(req.Address)
 	logs.Info(req.LogPrefix, "sema released")
 }
 
-func newCliConn(req *protocol.CliRequest, op cli.Operator) (*CliConn, error) {
+func newCliConn(ctx context.Context, req *protocol.CliRequest, op cli.Operator) (*CliConn, error) {
 	logs.Info(req.LogPrefix, "creating cli conn...")
-	if strings.ToLower(req.Protocol) == "ssh" {
-		sshConfig := &ssh.ClientConfig{
-			User:            req.Auth.Username,
-			Auth:            []ssh.AuthMethod{ssh.Password(req.Auth.Password)},
-			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-			Timeout:         5 * time.Second,
-		}
-		sshConfig.SetDefaults()
-		sshConfig.Ciphers = append(sshConfig.Ciphers, []string{"aes128-cbc", "3des-cbc"}...)
-		sshConfig.KeyExchanges = append(sshConfig.KeyExchanges, []string{"diffie-hellman-group-exchange-sha1", "diffie-hellman-group1-sha1", "diffie-hellman-group-exchange-sha256"}...)
-		client, err := ssh.Dial("tcp", req.Address, sshConfig)
+	var c *CliConn
+	switch strings.ToLower(req.Protocol) {
+	case "ssh":
+		client, err := dialSSH(req)
 		if err != nil {
-			logs.Error(req.LogPrefix, "dial", req.Address, "error:", err)
-			return nil, fmt.Errorf("dial %s error: %s", req.Address, err)
-		}
-		c := &CliConn{t: common.SSHConn, client: client, req: req, op: op, mode: op.GetStartMode()}
-		if err := c.init(); err != nil {
-			c.Close()
 			return nil, err
 		}
-		return c, nil
-	} else if strings.ToLower(req.Protocol) == "telnet" {
-		conn, err := telnet.DialTimeout("tcp", req.Address, 5*time.Second)
+		c = &CliConn{t: common.SSHConn, client: client, req: req, op: op, mode: op.GetStartMode(), created: time.Now(), ctx: ctx}
+	case "telnet":
+		conn, err := dialTelnet(req)
 		if err != nil {
-			return nil, fmt.Errorf("dial %s error: %s", req.Address, err)
+			return nil, err
 		}
+		c = &CliConn{t: common.TELNETConn, conn: conn, req: req, op: op, mode: op.GetStartMode(), created: time.Now(), ctx: ctx}
+	default:
+		return nil, fmt.Errorf("protocol %s not support", req.Protocol)
+	}
+	c.startRecording()
+	if err := c.init(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
 
-		if common.AppConfigInstance.LogCfgFlag == 3 {
-			e := regexp.MustCompile("Error: ")
-			p := regexp.MustCompile("login: $")
-			_, err = cli.ReadStringUntilError(conn, p, e)
-			if err != nil {
-				return nil, fmt.Errorf("telnet ReadStringUntil error: %s", err)
-			}
-			conn.Write([]byte(req.Auth.Username + "\r"))
-			p = regexp.MustCompile("Password: $")
-			_, err = cli.ReadStringUntilError(conn, p, e)
-			if err != nil {
-				return nil, fmt.Errorf("telnet ReadStringUntil error: %s", err)
-			}
-			conn.Write([]byte(req.Auth.Password + "\r"))
-		} else if common.AppConfigInstance.LogCfgFlag == 4 {
-			if _, err := conn.Write([]byte(req.Auth.Username + "\r" + req.Auth.Password + "\r")); err != nil {
-				return nil, fmt.Errorf("auth error %s", err)
-			}
-		}
+// dialSSH login device of req with ssh
+func dialSSH(req *protocol.CliRequest) (*ssh.Client, error) {
+	sshConfig := &ssh.ClientConfig{
+		User:            req.Auth.Username,
+		Auth:            []ssh.AuthMethod{ssh.Password(req.Auth.Password)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	}
+	sshConfig.SetDefaults()
+	sshConfig.Ciphers = append(sshConfig.Ciphers, []string{"aes128-cbc", "3des-cbc"}...)
+	sshConfig.KeyExchanges = append(sshConfig.KeyExchanges, []string{"diffie-hellman-group-exchange-sha1", "diffie-hellman-group1-sha1", "diffie-hellman-group-exchange-sha256"}...)
+	client, err := ssh.Dial("tcp", req.Address, sshConfig)
+	if err != nil {
+		logs.Error(req.LogPrefix, "dial", req.Address, "error:", err)
+		return nil, fmt.Errorf("dial %s error: %s", req.Address, err)
+	}
+	return client, nil
+}
 
-		c := &CliConn{t: common.TELNETConn, conn: conn, req: req, op: op, mode: op.GetStartMode()}
-		if err := c.init(); err != nil {
-			c.Close()
-			return nil, err
+// dialTelnet connect device of req with telnet, credentials sent as log-cfg-flag tells
+func dialTelnet(req *protocol.CliRequest) (*telnet.Conn, error) {
+	conn, err := telnet.DialTimeout("tcp", req.Address, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s error: %s", req.Address, err)
+	}
+
+	if common.AppConfigInstance.LogCfgFlag == 3 {
+		e := regexp.MustCompile("Error: ")
+		p := regexp.MustCompile("login: $")
+		_, err = cli.ReadStringUntilError(conn, p, e)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("telnet ReadStringUntil error: %s", err)
+		}
+		conn.Write([]byte(req.Auth.Username + "\r"))
+		p = regexp.MustCompile("Password: $")
+		_, err = cli.ReadStringUntilError(conn, p, e)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("telnet ReadStringUntil error: %s", err)
+		}
+		conn.Write([]byte(req.Auth.Password + "\r"))
+	} else if common.AppConfigInstance.LogCfgFlag == 4 {
+		if _, err := conn.Write([]byte(req.Auth.Username + "\r" + req.Auth.Password + "\r")); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("auth error %s", err)
 		}
-		return c, nil
 	}
-	return nil, fmt.Errorf("protocol %s not support", req.Protocol)
+	return conn, nil
 }
 
 func (s *CliConn) heartbeat() {
@@ -204,9 +532,11 @@ func (s *CliConn) heartbeat() {
 					break
 				}
 				logs.Info(s.req.LogPrefix, "Acquiring heartbeat sema...")
-				semas[s.req.Address] <- struct{}{}
+				take(context.Background(), s.req)
 				logs.Info(s.req.LogPrefix, "heartbeat sema acquired")
-				if _, err := s.writeBuff(" "); err != nil {
+				// context of last request is done
+				s.ctx = context.Background()
+				if _, err := s.WriteBuff(" "); err != nil {
 					logs.Critical(s.req.LogPrefix, "heartbeat error:", err)
 					if err1 := s.Close(); err1 != nil {
 						logs.Error(s.req.LogPrefix, "close conn err", err1)
@@ -214,7 +544,7 @@ func (s *CliConn) heartbeat() {
 					Release(s.req)
 					return
 				}
-				if _, _, err := s.readBuff(); err != nil {
+				if _, _, err := s.ReadBuff(); err != nil {
 					logs.Critical(s.req.LogPrefix, "heartbeat error:", err)
 					if err1 := s.Close(); err1 != nil {
 						logs.Error(s.req.LogPrefix, "close conn err", err1)
@@ -240,169 +570,172 @@ func (s *CliConn) init() error {
 	} else if s.t == common.TELNETConn {
 		// do nothing
 	}
+	s.pump()
 	// read login prompt
-	_, prompt, err := s.readBuff()
+	_, prompt, err := s.ReadBuff()
 	if err != nil {
 		return fmt.Errorf("read after login failed: %s", err)
 	}
 	logs.Info(s.req.LogPrefix, "first prompt fetched", prompt)
-	// enable cases
-	if s.mode == "login_or_login_enable" {
-		// not sure what mode it is
-		// check prompt
-		loginPrompts := s.op.GetPrompts("login")
-		if cli.AnyMatch(loginPrompts, prompt) {
-			// in login, not enabled
-			// eventually, we'll exec cmds in privileged mode
-			s.mode = "login"
-			if s.mode != s.req.Mode {
-				// login is not the target mode, need transition
-				// enter privileged mode
-				if _, err := s.writeBuff("enable\r" + s.req.EnablePwd); err != nil {
-					return fmt.Errorf("enter privileged mode err, %s", err)
-				}
-				s.mode = "login_enable"
-				if _, _, err := s.readBuff(); err != nil {
-					s.mode = "login"
-					return fmt.Errorf("readBuff after enable err, %s", err)
-				}
-				if err := s.closePage(true); err != nil {
-					return err
-				}
-			} // login is what you want, no close page here
-		} else {
-			// already in privileged mode, close page
-			s.mode = "login_enable"
-			if err := s.closePage(true); err != nil {
-				return err
-			}
-		}
-	} else { //
-		// special devices
-		if strings.EqualFold(s.req.Vendor, "fortinet") && strings.EqualFold(s.req.Type, "fortigate-VM64-KVM") {
-			pts := s.op.GetPrompts(s.req.Mode)
-			if pts == nil {
-				return fmt.Errorf("mode %s not registered", s.req.Mode)
-			}
-			// close page
-			if !strings.Contains(pts[0].String(), s.req.Mode) {
-				//non vdom
-				s.closePage(true)
-			} else {
-				// vdom
-				logs.Debug(s.req.LogPrefix, "entering domain global...")
-				if _, err := s.writeBuff("config global"); err != nil {
-					return err
-				}
-				if err := s.closePage(false); err != nil {
-					return err
-				}
-				logs.Debug(s.req.LogPrefix, "exiting domain global...")
-				if _, err := s.writeBuff("end"); err != nil {
-					return err
-				}
-				if _, _, err := s.readBuff(); err != nil {
-					return err
-				}
-			}
-		} else {
-			// for any other non special devices
-			// include cisco asa non login_or_login_enable mode
-			if err := s.closePage(true); err != nil {
-				return err
-			}
+	if h, ok := s.op.(cli.PostLoginHook); ok {
+		// operator takes over, pager included
+		if err := h.PostLogin(s, prompt); err != nil {
+			return err
 		}
+	} else if err := s.closePage(); err != nil {
+		return err
 	}
 	s.heartbeat()
 	return nil
 }
 
-func (s *CliConn) closePage(drain bool) error {
-	logs.Info(s.req.LogPrefix, "closing page ...")
-	if strings.EqualFold(s.req.Vendor, "cisco") && (strings.EqualFold(s.req.Type, "asa") || strings.EqualFold(s.req.Type, "asav")) {
-		// login mode no close page
-		if s.mode == "login" {
-			return nil
+// pump read device output continuously,
+// so reads can time out without leaving a reader behind
+func (s *CliConn) pump() {
+	s.out = make(chan []byte, 64)
+	s.done = make(chan struct{})
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := s.read(buf)
+			if n > 0 {
+				b := make([]byte, n)
+				copy(b, buf[:n])
+				s.recordOutput(b)
+				select {
+				case s.out <- b:
+				case <-s.done:
+					// nobody reads a closed conn
+					err = fmt.Errorf("conn closed")
+				}
+			}
+			if err != nil {
+				s.errMu.Lock()
+				s.readErr = err
+				s.errMu.Unlock()
+				close(s.out)
+				return
+			}
 		}
-		// ===config or normal both ok===
-		// set terminal pager
-		if _, err := s.writeBuff("terminal pager 0"); err != nil {
-			return err
+	}()
+}
+
+// pumpErr return error which stopped the pump, nil if it's still running
+func (s *CliConn) pumpErr() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.readErr
+}
+
+// drain discard pending output until device keeps quiet for a while
+func (s *CliConn) drain(quiet time.Duration) {
+	for {
+		select {
+		case b, ok := <-s.out:
+			if !ok {
+				return
+			}
+			s.logOutput(b)
+		case <-time.After(quiet):
+			s.flushLog()
+			return
 		}
-		if _, _, err1 := s.readBuff(); err1 != nil {
-			return err1
+	}
+}
+
+// Resync detect current mode by prompt and update mode.
+// linebreak is sent first, then interrupt sequences when device does not answer with a known prompt.
+func (s *CliConn) Resync() error {
+	logs.Info(s.req.LogPrefix, "resyncing mode, current", s.mode)
+	s.drain(resyncQuiet)
+	patterns := cli.AllPrompts(s.op, s.mode)
+	for _, seq := range cli.GetResyncs(s.op) {
+		logs.Info(s.req.LogPrefix, "resync with", strconv.Quote(seq))
+		if _, err := s.WriteBuff(seq); err != nil {
+			return fmt.Errorf("resync write error: %s", err)
 		}
-		// set page lines
-		if _, err := s.writeBuff("terminal pager lines 0"); err != nil {
-			return err
+		res := s.readLines(patterns, resyncTimeout)
+		if res.err != nil {
+			logs.Error(s.req.LogPrefix, "resync read error:", res.err)
+			if s.pumpErr() != nil {
+				// conn broken
+				return fmt.Errorf("resync read error: %s", res.err)
+			}
+			continue
 		}
-	} else if strings.EqualFold(s.req.Vendor, "cisco") && strings.EqualFold(s.req.Type, "ios") {
-		if s.mode == "login" {
+		if m := cli.DetectMode(s.op, res.prompt, s.mode); m != "" {
+			if m != s.mode {
+				logs.Notice(s.req.LogPrefix, "mode resynced", s.mode, "-->", m)
+			}
+			s.mode = m
 			return nil
 		}
-		if _, err := s.writeBuff("terminal length 0"); err != nil {
-			return err
-		}
-	} else if strings.EqualFold(s.req.Vendor, "Paloalto") && strings.EqualFold(s.req.Type, "PAN-OS") {
-		// set pager
-		if _, err := s.writeBuff("set cli pager off"); err != nil {
-			return err
-		}
-	} else if strings.EqualFold(s.req.Vendor, "hillstone") && strings.EqualFold(s.req.Type, "SG-6000-VM01") {
-		// set pager
-		if _, err := s.writeBuff("terminal length 0"); err != nil {
-			return err
-		}
-	} else if strings.EqualFold(s.req.Vendor, "fortinet") && strings.EqualFold(s.req.Type, "fortigate-VM64-KVM") {
-		// set console
-		if _, err := s.writeBuff("config system console\n\tset output standard\nend"); err != nil {
-			return err
-		}
-	} else if strings.EqualFold(s.req.Vendor, "H3C") && strings.EqualFold(s.req.Type, "SecPath") {
-		// set console
-		if _, err := s.writeBuff("screen-length disable"); err != nil {
-			return err
+	}
+	return fmt.Errorf("resync failed, no known prompt found")
+}
+
+// Recover bring session back to a known prompt after read timeout,
+// operator interrupt sequences are tried one by one before resync
+func (s *CliConn) Recover() error {
+	logs.Info(s.req.LogPrefix, "recovering session, current", s.mode)
+	s.drain(resyncQuiet)
+	patterns := cli.AllPrompts(s.op, s.mode)
+	for _, seq := range cli.GetInterrupts(s.op) {
+		logs.Info(s.req.LogPrefix, "interrupt with", strconv.Quote(seq))
+		if err := s.interrupt(seq); err != nil {
+			logs.Error(s.req.LogPrefix, "interrupt error:", err)
+			continue
 		}
-	} else if strings.EqualFold(s.req.Vendor, "huawei") {
-		if strings.HasSuffix(s.req.Version, "200") {
-			if _, err := s.writeBuff("screen-length 0 temporary"); err != nil {
-				return err
+		res := s.readLines(patterns, interruptTimeout)
+		if res.err != nil {
+			logs.Error(s.req.LogPrefix, "interrupt read error:", res.err)
+			if s.pumpErr() != nil {
+				// conn broken
+				return fmt.Errorf("recover read error: %s", res.err)
 			}
-		} else {
-			if s.mode == "login" {
-				// current in login mode
-				// enter system view
-				s.mode = "system_View"
-				if _, err := s.writeBuff("system-view"); err != nil {
-					// rollback
-					s.mode = "login"
-					return err
-				}
-				// drain output
-				if _, _, err := s.readBuff(); err != nil {
-					return err
-				}
-				// after disable more scren, no need to transition back to login mode
-				// it wiil be done automaticaly before execute commands
+		} else if m := cli.DetectMode(s.op, res.prompt, s.mode); m != "" {
+			if m != s.mode {
+				logs.Notice(s.req.LogPrefix, "mode recovered", s.mode, "-->", m)
 			}
-			// now in system view
-			cmd := "user-interface current\nscreen-length 0"
-			// quit from ui config
-			cmd += "\nquit"
-			if _, err := s.writeBuff(cmd); err != nil {
-				return err
+			s.mode = m
+			return nil
+		}
+		if seq == cli.PagerQuit {
+			// no pager, q left in line would run with next linebreak, it's quit on many devices
+			if _, err := s.write([]byte(cli.CtrlU)); err != nil {
+				logs.Error(s.req.LogPrefix, "clear line error:", err)
 			}
 		}
-		// drain output. see following lines
-	} else {
-		// we did not write any commands to these devices
-		// so no need to readBuff
-		return nil
 	}
-	if !drain {
+	return s.Resync()
+}
+
+// interrupt write interrupt sequence as it is, no linebreak appended
+func (s *CliConn) interrupt(seq string) error {
+	if seq != cli.BreakKey {
+		_, err := s.write([]byte(seq))
+		return err
+	}
+	if s.t == common.TELNETConn {
+		// IAC BRK, written to underlying conn, or IAC will be escaped
+		_, err := s.conn.Conn.Write([]byte{255, 243})
+		return err
+	}
+	if s.session == nil {
+		return fmt.Errorf("no ssh session to send break")
+	}
+	_, err := s.session.SendRequest("break", true, ssh.Marshal(struct{ Length uint32 }{breakLength}))
+	return err
+}
+
+func (s *CliConn) closePage() error {
+	h, ok := s.op.(cli.PagerDisabler)
+	if !ok {
+		// no need to disable pager for these devices
 		return nil
 	}
-	if _, _, err := s.readBuff(); err != nil {
+	logs.Info(s.req.LogPrefix, "closing page ...")
+	if err := h.DisablePager(s); err != nil {
 		return err
 	}
 	logs.Info(s.req.LogPrefix, "closing page done")
@@ -412,8 +745,12 @@ func (s *CliConn) closePage(drain bool) error {
 // Close cli conn
 func (s *CliConn) Close() error {
 	logs.Info(s.req.LogPrefix, "closing conn ...")
-	delete(conns, s.req.Address)
+	deleteConn(s.req.Address, s)
 	s.closed = true
+	if s.done != nil {
+		s.doneOnce.Do(func() { close(s.done) })
+	}
+	s.stopRecording()
 	if s.t == common.TELNETConn {
 		if s.conn == nil {
 			logs.Info(s.req.LogPrefix, "telnet conn nil when close")
@@ -451,6 +788,9 @@ func (s *CliConn) readFull(buf []byte) (int, error) {
 }
 
 func (s *CliConn) write(b []byte) (int, error) {
+	if c := s.recorder(); c != nil {
+		c.Input(s.recordable(b, s.inEncoding))
+	}
 	if s.t == common.SSHConn {
 		return s.w.Write(b)
 	}
@@ -474,63 +814,42 @@ func (s *CliConn) anyMatch(t string, patterns []*regexp.Regexp) []string {
 	return nil
 }
 
-func (s *CliConn) writeLogFile(b []byte, path string) error {
-	if len(b) < 1 {
-		return nil
-	}
-	if common.AppConfigInstance.LogCfgFlag > 0 {
-		// openfile for writing session output
-		if _, err := os.Stat(path); err == nil {
-			// exists
-			// DO NOT Write
-
-		} else if os.IsNotExist(err) {
-			// does *not* exist
-			// write
-			f, err := os.Create(path)
-			if err != nil {
-				return err
-			}
-			f.Write(b)
-			defer f.Close()
-
-		} else {
-			logs.Error(s.req.LogPrefix, err)
-		}
-	}
-	return nil
-}
-
-func (s *CliConn) readLines() *readBuffOut {
-	buf := make([]byte, 1000)
+func (s *CliConn) readLines(patterns []*regexp.Regexp, timeout time.Duration) *readBuffOut {
 	var (
 		lastLine string
 		errRes   error
 		wbuf     bytes.Buffer
-		f        *os.File
 		err      error
 	)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
 
 outside:
 	for {
-		n, err := s.read(buf) // read ssh/telnet terminal content from session/conn
-		if err != nil {
-			// something wrong
-			logs.Error(s.req.LogPrefix, "io.Reader read error:", err)
-			errRes = err
-			break
+		var buf []byte
+		select {
+		case b, ok := <-s.out: // ssh/telnet terminal content pumped from session/conn
+			if !ok {
+				// something wrong
+				errRes = s.pumpErr()
+				logs.Error(s.req.LogPrefix, "io.Reader read error:", errRes)
+				break outside
+			}
+			buf = b
+		case <-timer.C:
+			s.timedOut = true
+			errRes = fmt.Errorf("read stdout timeout after %q", timeout)
+			break outside
+		case <-s.ctx.Done():
+			// device may be still outputting, recovered like timeout
+			s.timedOut = true
+			errRes = fmt.Errorf("read stdout canceled: %s", s.ctx.Err())
+			break outside
 		}
+		n := len(buf)
 
 		// print received content
-		logs.Debug(s.req.LogPrefix, "(", n, ")", string(buf[:n]))
-
-		// write binary to file
-		if f != nil {
-			if _, err = f.Write(buf[:n]); err != nil {
-				errRes = err
-				break
-			}
-		}
+		s.logOutput(buf[:n])
 
 		// write received content to whole document buffer
 		wbuf.Write(buf[:n])
@@ -570,7 +889,7 @@ outside:
 			// wbuf.Truncate(lineBeginAt + 1) // lineBeginAt could not be -1
 			// or deal with this when output done
 			// press enter or press space
-			if _, err := s.writeBuff(" "); err != nil {
+			if _, err := s.WriteBuff(" "); err != nil {
 				logs.Error(s.req.LogPrefix, "press enter error:", err)
 				errRes = err
 				break outside
@@ -578,17 +897,13 @@ outside:
 		}
 
 		// check prompt patterns
-		if s.op.GetPrompts(s.mode) == nil {
-			logs.Error(s.req.LogPrefix, "no patterns for mode", s.mode)
-			errRes = fmt.Errorf("no patterns for mode %s", s.mode)
-			break outside
-		}
-		// test
-		matches := s.anyMatch(testee, s.op.GetPrompts(s.mode))
+		matches := s.anyMatch(testee, patterns)
 
 		if len(matches) > 0 && !cli.AnyMatch(s.op.GetExcludes(), testee) {
 			// test pass
 			logs.Info(s.req.LogPrefix, "prompt matched --->", s.mode, ":", matches)
+			s.flushRecording()
+			s.flushLog()
 			// truncate prompt and break
 			if lineBeginAt == -1 {
 				lastLine = testee
@@ -601,36 +916,28 @@ outside:
 			// break the out loop
 			break outside
 		}
-		// not match
-		// check buf size is large enough
-		if cap(buf) == n {
-			// buf full, it proves that maybe there are lots of more content out there
-			// enlarge buf
-			buf = make([]byte, 2*n)
-		}
 	}
 
-	path := common.AppConfigInstance.LogCfgDir + "/" + s.req.Session + ".binary.cfg.raw." + strconv.Itoa(wbuf.Len())
-	if err := s.writeLogFile(wbuf.Bytes(), path); err != nil {
-		return &readBuffOut{
-			err,
-			"",
-			"",
-		}
-	}
 	d := chardet.NewTextDetector()
 	dr, err := d.DetectBest(wbuf.Bytes())
 	if err != nil {
 		logs.Error(s.req.LogPrefix, "detect origin encoding error:", err)
+		s.encoding = ""
+	} else {
+		s.encoding = dr.Charset
 	}
 	// print origin encoding
 	logs.Debug(s.req.LogPrefix, "detected encoding", dr, "predefined encoding", s.op.GetEncoding())
 
 	encoding := s.op.GetEncoding()
-	if dr != nil && dr.Charset != "UTF-8" && dr.Confidence >= common.AppConfigInstance.Confidence {
+	if s.req.OutputEncoding != "" {
+		// caller knows better
+		encoding = s.req.OutputEncoding
+	} else if dr != nil && dr.Charset != "UTF-8" && dr.Confidence >= common.AppConfigInstance.Confidence {
 		// predefined encoding may set wrong
 		encoding = dr.Charset
 	}
+	s.outEncoding = encoding
 	// convert, even if detect error
 	// if not converted, original byte slice will be retured
 	u8buf, err := common.ConvToUTF8(encoding, wbuf.Bytes())
@@ -646,20 +953,10 @@ outside:
 	}
 
 	// replace more
-	y := string(u8buf)
-	x := y
-	if strings.EqualFold(s.req.Vendor, "fortinet") {
-		x = removeMoreBreakedPart(removeWinBlankline1Space(removeWinBlankline5Space(removeStandardMore(string(y)))))
+	x := string(u8buf)
+	if h, ok := s.op.(cli.OutputCleaner); ok && !s.req.Raw {
+		x = h.CleanOutput(x)
 	}
-	path1 := common.AppConfigInstance.LogCfgDir + "/" + s.req.Session + ".binary.cfg.str." + strconv.Itoa(len(x))
-	if err := s.writeLogFile([]byte(x), path1); err != nil {
-		return &readBuffOut{
-			err,
-			"",
-			"",
-		}
-	}
-	// debug code end
 	return &readBuffOut{
 		errRes,
 		x,
@@ -671,178 +968,229 @@ func removeWinLinebreak(x string) string {
 	return regexp.MustCompile(`\r\n`).ReplaceAllString(x, "\n")
 }
 
-func removeStandardMore(x string) string {
-	return regexp.MustCompile(`--More--([ ]+\r){1,2}`).ReplaceAllString(x, "")
+func removeBlankline(x string) string {
+	return regexp.MustCompile(`( )+\n`).ReplaceAllString(x, "    ")
 }
 
-func removeMoreBreakedPart(x string) string {
-	return regexp.MustCompile(`\n\r( )+\r`).ReplaceAllString(x, "")
+// ReadBuff return cmd output, prompt, error
+func (s *CliConn) ReadBuff() (string, string, error) {
+	// check prompt patterns
+	if s.op.GetPrompts(s.mode) == nil {
+		logs.Error(s.req.LogPrefix, "no patterns for mode", s.mode)
+		return "", "", fmt.Errorf("no patterns for mode %s", s.mode)
+	}
+	res := s.readLines(s.op.GetPrompts(s.mode), s.req.Timeout)
+	s.prompt = res.prompt
+	if res.err == nil {
+		scanner := bufio.NewScanner(strings.NewReader(res.ret))
+		for scanner.Scan() {
+			matches := s.anyMatch(scanner.Text(), s.op.GetErrPatterns())
+			if len(matches) > 0 {
+				logs.Info(s.req.LogPrefix, "err pattern matched:", res.ret)
+				return "", res.prompt, fmt.Errorf("err pattern matched: %s", res.ret)
+			}
+		}
+	}
+	return res.ret, res.prompt, res.err
 }
 
-func removeWinBlankline5Space(x string) string {
-	return regexp.MustCompile(`( ){5}\r\n`).ReplaceAllString(x, "    ")
-}
-func removeWinBlankline1Space(x string) string {
-	return regexp.MustCompile(` \r\n`).ReplaceAllString(x, "")
+// WriteBuff write cmd to device, linebreak appended if cmd not linebreaked
+// cmd is transcoded to request input encoding, operator encoding if not set
+func (s *CliConn) WriteBuff(cmd string) (int, error) {
+	if err := s.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("write canceled: %s", err)
+	}
+	if cmd == cli.BreakKey {
+		return 0, s.interrupt(cmd)
+	}
+	if len(cmd) == 0 || cmd[len(cmd)-1] != '\n' {
+		cmd += s.op.GetLinebreak()
+	}
+	s.inEncoding = s.req.InputEncoding
+	if s.inEncoding == "" {
+		s.inEncoding = s.op.GetEncoding()
+	}
+	b, err := common.ConvFromUTF8(s.inEncoding, []byte(cmd))
+	if err != nil {
+		return 0, fmt.Errorf("transcode cmd to %s error: %s", s.inEncoding, err)
+	}
+	return s.write(b)
 }
 
-func removeBlankline(x string) string {
-	return regexp.MustCompile(`( )+\n`).ReplaceAllString(x, "    ")
+// Exec execute cli cmds, session is recovered when it fails,
+// interrupts are tried if it's a read timeout, conn is closed only if recovery fails
+// results are in commands order, the last one carries error if any.
+// Once ctx done, no more command is sent and the read in flight is interrupted
+func (s *CliConn) Exec(ctx context.Context) ([]*protocol.CmdResult, error) {
+	s.ctx = ctx
+	s.timedOut, s.recovered, s.failed = false, false, false
+	out, err := s.exec()
+	if _, ok := err.(*canceledError); err != nil && !ok {
+		s.afterFailure()
+	}
+	return out, err
 }
 
-// return cmd output, prompt, error
-func (s *CliConn) readBuff() (string, string, error) {
-	// buffered chan
-	ch := make(chan *readBuffOut, 1)
-
-	go func() {
-		ch <- s.readLines()
-	}()
-
-	select {
-	case res := <-ch:
-		if res.err == nil {
-			scanner := bufio.NewScanner(strings.NewReader(res.ret))
-			for scanner.Scan() {
-				matches := s.anyMatch(scanner.Text(), s.op.GetErrPatterns())
-				if len(matches) > 0 {
-					logs.Info(s.req.LogPrefix, "err pattern matched:", res.ret)
-					return "", res.prompt, fmt.Errorf("err pattern matched: %s", res.ret)
-				}
-			}
-		}
-		return res.ret, res.prompt, res.err
-	case <-time.After(s.req.Timeout):
-		return "", "", fmt.Errorf("read stdout timeout after %q", s.req.Timeout)
-	}
+// ExecEach execute cli cmds like Exec, fn is called with every result once its command is done
+func (s *CliConn) ExecEach(ctx context.Context, fn func(*protocol.CmdResult)) ([]*protocol.CmdResult, error) {
+	s.each = fn
+	defer func() { s.each = nil }()
+	return s.Exec(ctx)
 }
 
-func (s *CliConn) writeBuff(cmd string) (int, error) {
-	if len(cmd) > 0 && cmd[len(cmd)-1] == '\n' {
-		// linebreaked
-		return s.write([]byte(cmd))
+// afterFailure bring session back to a known prompt, conn is closed if it fails
+func (s *CliConn) afterFailure() {
+	if s.closed {
+		return
+	}
+	s.failed = true
+	// recovery is bounded by its own timeouts, it goes on even if call canceled
+	s.ctx = context.Background()
+	var err error
+	if s.timedOut {
+		err = s.Recover()
+	} else {
+		err = s.Resync()
+	}
+	if err != nil {
+		logs.Error(s.req.LogPrefix, err, ", closing conn")
+		s.Close()
+		return
 	}
-	return s.write([]byte(cmd + s.op.GetLinebreak()))
+	s.recovered = true
 }
 
-// Exec execute cli cmds
-func (s *CliConn) Exec() (map[string]string, error) {
+func (s *CliConn) exec() ([]*protocol.CmdResult, error) {
 	if err := s.beforeExec(); err != nil {
-		logs.Error(s.req.LogPrefix, "beforeExec error:", err)
-		return nil, fmt.Errorf("beforeExec error: %s", err)
+		return nil, err
 	}
-	// transit to target mode
+	// transit to target mode, pre exec hook runs after every hop
 	if s.req.Mode != s.mode {
 		s.op.RegisterMode(s.req)
-		cmds := s.op.GetTransitions(s.mode, s.req.Mode)
-		// use target mode prompt
-		logs.Info(s.req.LogPrefix, s.mode, "-->", s.req.Mode)
-		if cmds == nil {
-			// unexpected case
-			// no transitions found
-			// please note, if no need to do something, use empty slice instead of nil
-			return nil, fmt.Errorf("unexpected case, no transition found for %s --> %s", s.mode, s.req.Mode)
-		}
-		// transition back when it fail
-		mt := s.mode
-		s.mode = s.req.Mode
-		for _, v := range cmds {
-			logs.Info(s.req.LogPrefix, "exec", "<", v, ">")
-			if _, err := s.writeBuff(v); err != nil {
-				logs.Error(s.req.LogPrefix, "write buff failed:", err)
-				s.mode = mt
-				return nil, fmt.Errorf("write buff failed: %s", err)
-			}
-			_, _, err := s.readBuff()
-			if err != nil {
-				s.mode = mt
-				logs.Error(s.req.LogPrefix, "readBuff failed:", err)
-				return nil, fmt.Errorf("readBuff failed: %s", err)
-			}
+		if err := s.transit(s.req.Mode); err != nil {
+			return nil, err
 		}
 	}
-	if err := s.beforeExec(); err != nil {
-		logs.Error(s.req.LogPrefix, "beforeExec error:", err)
-		return nil, fmt.Errorf("beforeExec error: %s", err)
-	}
-	cmdstd := make(map[string]string, 0)
+	results := make([]*protocol.CmdResult, 0, len(s.req.Commands))
 	// do execute cli commands
 	for _, v := range s.req.Commands {
-		logs.Info(s.req.LogPrefix, "exec", "<", v, ">", "in", s.mode, "mode")
-		if _, err := s.writeBuff(v); err != nil {
-			logs.Error(s.req.LogPrefix, "write buff failed:", err)
-			return cmdstd, fmt.Errorf("write buff failed: %s", err)
+		if err := s.ctx.Err(); err != nil {
+			logs.Info(s.req.LogPrefix, "canceled before", "<", s.redact(v), ">")
+			return results, &canceledError{err}
+		}
+		r := &protocol.CmdResult{Command: v, Mode: s.mode, Start: time.Now()}
+		ret, err := s.run(v)
+		r.End = time.Now()
+		r.Duration = r.End.Sub(r.Start)
+		r.Prompt, r.Encoding = s.prompt, s.encoding
+		r.InputEncoding, r.OutputEncoding = s.inEncoding, s.outEncoding
+		if !s.req.Raw {
+			ret = cli.Normalize(v, ret)
 		}
-		ret, _, err := s.readBuff()
+		if s.req.Redact {
+			ret = s.redact(ret)
+		}
+		r.Output = ret
+		results = append(results, r)
 		if err != nil {
-			logs.Error(s.req.LogPrefix, "readBuff failed:", err)
-			return cmdstd, fmt.Errorf("readBuff failed: %s", err)
+			r.Error = err.Error()
+		}
+		if s.each != nil {
+			s.each(r)
+		}
+		if err != nil {
+			return results, err
 		}
-		cmdstd[v] = ret
 	}
-	return cmdstd, nil
+	return results, nil
 }
 
-func (s *CliConn) beforeExec() error {
-	if strings.EqualFold(s.req.Vendor, "Paloalto") && strings.EqualFold(s.req.Type, "PAN-OS") {
-		if s.req.Format == "" || s.formatSet {
-			return nil
-		}
-		// set format
-		// only for pa device
-		mode := s.mode
-		if s.mode != "login" {
-			// transition to login first
-			if _, err := s.writeBuff("exit"); err != nil {
-				return err
-			}
-			s.mode = "login"
-			if _, _, err := s.readBuff(); err != nil {
-				s.mode = mode
-				return err
+// run execute cmd in current mode
+func (s *CliConn) run(cmd string) (string, error) {
+	logs.Info(s.req.LogPrefix, "exec", "<", s.redact(cmd), ">", "in", s.mode, "mode")
+	if _, err := s.WriteBuff(cmd); err != nil {
+		logs.Error(s.req.LogPrefix, "write buff failed:", err)
+		return "", fmt.Errorf("write buff failed: %s", err)
+	}
+	ret, _, err := s.ReadBuff()
+	if err != nil {
+		logs.Error(s.req.LogPrefix, "readBuff failed:", err)
+		return ret, fmt.Errorf("readBuff failed: %s", err)
+	}
+	return ret, nil
+}
+
+// transit walk through the shortest mode path to target mode,
+// prompt of every hop is verified, it rolls back to the mode it started from when any hop fails.
+// Pre exec hook runs in every mode entered, eg. ios disables pager in login_enable before config mode
+func (s *CliConn) transit(target string) error {
+	path := cli.ShortestPath(s.op, s.mode, target)
+	if path == nil {
+		// unexpected case
+		// no transitions found
+		// please note, if no need to do something, use empty slice instead of nil
+		return fmt.Errorf("unexpected case, no transition found for %s --> %s", s.mode, target)
+	}
+	logs.Info(s.req.LogPrefix, strings.Join(path, " --> "))
+	for i := 1; i < len(path); i++ {
+		if err := s.hop(path[i-1], path[i]); err != nil {
+			// hop failed, device should be still in last verified mode
+			s.mode = path[i-1]
+			if i > 1 {
+				s.rollback(path[0])
 			}
-		}
-		// set format
-		if _, err := s.writeBuff("set cli config-output-format " + s.req.Format); err != nil {
 			return err
 		}
-		if _, _, err := s.readBuff(); err != nil {
+		if err := s.beforeExec(); err != nil {
 			return err
 		}
-		// login
-		if mode != "login" {
-			// transition back
-			if _, err := s.writeBuff("configure"); err != nil {
-				return err
-			}
-			s.mode = mode
-			if _, _, err := s.readBuff(); err != nil {
-				s.mode = "login"
-				return err
-			}
+	}
+	return nil
+}
+
+// hop execute transition commands of one edge, use target mode prompt
+func (s *CliConn) hop(from, to string) error {
+	s.mode = to
+	for _, v := range s.op.GetTransitions(from, to) {
+		logs.Info(s.req.LogPrefix, "exec", "<", v, ">", from, "-->", to)
+		if _, err := s.WriteBuff(strings.Replace(v, cli.EnablePwdPlaceholder, s.req.EnablePwd, -1)); err != nil {
+			logs.Error(s.req.LogPrefix, "write buff failed:", err)
+			return fmt.Errorf("write buff failed: %s", err)
 		}
-	} else if strings.EqualFold(s.req.Vendor, "cisco") {
-		// enable case
-		if !strings.EqualFold(s.req.Mode, "login") && strings.EqualFold(s.mode, "login") {
-			// target mode is enable or config
-			// and current mode is login
-			// so need enable
+		if _, _, err := s.ReadBuff(); err != nil {
+			logs.Error(s.req.LogPrefix, "readBuff failed:", err)
+			return fmt.Errorf("readBuff failed: %s", err)
+		}
+	}
+	return nil
+}
 
-			// enter privileged mode
-			if _, err := s.writeBuff("enable\r" + s.req.EnablePwd); err != nil {
-				return fmt.Errorf("enter privileged mode err, %s", err)
-			}
-			s.mode = "login_enable"
-			if _, _, err := s.readBuff(); err != nil {
-				// rollback
-				s.mode = "login"
-				return fmt.Errorf("readBuff after enable err, %s", err)
-			}
-			// close page when entering privileged mode
-			if err := s.closePage(true); err != nil {
-				return err
-			}
+// rollback transit back to mode m, current mode stays at the last verified one if it fails
+func (s *CliConn) rollback(m string) {
+	logs.Info(s.req.LogPrefix, "rolling back to", m)
+	path := cli.ShortestPath(s.op, s.mode, m)
+	if path == nil {
+		logs.Error(s.req.LogPrefix, "no transition found for rollback", s.mode, "-->", m)
+		return
+	}
+	for i := 1; i < len(path); i++ {
+		if err := s.hop(path[i-1], path[i]); err != nil {
+			logs.Error(s.req.LogPrefix, "rollback failed:", err)
+			s.mode = path[i-1]
+			return
 		}
 	}
+}
+
+// beforeExec run pre exec hook of operator in current mode
+func (s *CliConn) beforeExec() error {
+	h, ok := s.op.(cli.PreExecHook)
+	if !ok {
+		return nil
+	}
+	if err := h.PreExec(s); err != nil {
+		logs.Error(s.req.LogPrefix, "beforeExec error:", err)
+		return fmt.Errorf("beforeExec error: %s", err)
+	}
 	return nil
 }
@@ -16,11 +16,26 @@
 package conn
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/sky-cloud-tec/netd/cli"
+	_ "github.com/sky-cloud-tec/netd/cli/cisco/ios"   // load cisco ios
+	_ "github.com/sky-cloud-tec/netd/cli/juniper/srx" // load juniper srx
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/record"
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/songtianyi/rrframework/logs"
 	"github.com/ziutek/telnet"
 	"golang.org/x/crypto/ssh"
 )
@@ -90,3 +105,547 @@ func TestBreakline(t *testing.T) {
 		)
 	})
 }
+
+// hungOp is an operator which prompt is back only after ctrl-c
+type hungOp struct{}
+
+func (s *hungOp) GetTransitions(c, t string) []string { return nil }
+func (s *hungOp) GetPrompts(m string) []*regexp.Regexp {
+	if m == "login" {
+		return []*regexp.Regexp{regexp.MustCompile(`[[:alnum:]]+> $`)}
+	}
+	return nil
+}
+func (s *hungOp) SetPrompts(string, []*regexp.Regexp)         {}
+func (s *hungOp) GetErrPatterns() []*regexp.Regexp            { return nil }
+func (s *hungOp) SetErrPatterns([]*regexp.Regexp)             {}
+func (s *hungOp) GetSSHInitializer() cli.SSHInitializer       { return nil }
+func (s *hungOp) GetLinebreak() string                        { return "\n" }
+func (s *hungOp) GetStartMode() string                        { return "login" }
+func (s *hungOp) RegisterMode(req *protocol.CliRequest) error { return nil }
+func (s *hungOp) GetEncoding() string                         { return "" }
+func (s *hungOp) GetExcludes() []*regexp.Regexp               { return nil }
+func (s *hungOp) GetInterrupts() []string                     { return []string{cli.PagerQuit, cli.CtrlC} }
+
+// nopCloser wraps pipe writer
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// chatty is a device which never stops talking
+type chatty struct{}
+
+func (chatty) Read(b []byte) (int, error) { return copy(b, "noise\n"), nil }
+
+func TestPumpClose(t *testing.T) {
+	Convey("pump stops once conn closed though nobody reads", t, func() {
+		s := &CliConn{t: common.SSHConn, req: &protocol.CliRequest{Address: "127.0.0.1:22"}, op: &hungOp{}, r: chatty{}, w: nopCloser{ioutil.Discard}}
+		s.pump()
+		for len(s.out) < cap(s.out) {
+			time.Sleep(time.Millisecond)
+		}
+		So(s.pumpErr(), ShouldBeNil)
+		s.Close()
+		deadline := time.Now().Add(time.Second)
+		for s.pumpErr() == nil && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		So(s.pumpErr(), ShouldNotBeNil)
+	})
+}
+
+func TestRecover(t *testing.T) {
+	Convey("recover session after read timeout", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		interruptTimeout, resyncQuiet = 200*time.Millisecond, 50*time.Millisecond
+		// device side
+		dr, cw := io.Pipe()
+		cr, dw := io.Pipe()
+		writes := make(chan string, 16)
+		go func() {
+			buf := make([]byte, 64)
+			for {
+				n, err := dr.Read(buf)
+				if err != nil {
+					return
+				}
+				writes <- string(buf[:n])
+				if strings.Contains(string(buf[:n]), cli.CtrlC) {
+					dw.Write([]byte("^C\nadmin> "))
+				}
+			}
+		}()
+		req := &protocol.CliRequest{
+			Address:  "127.0.0.1:22",
+			Mode:     "login",
+			Commands: []string{"ping 1.1.1.1"},
+			Timeout:  200 * time.Millisecond,
+		}
+		s := &CliConn{t: common.SSHConn, req: req, op: &hungOp{}, mode: "login", r: cr, w: nopCloser{cw}}
+		s.pump()
+		_, err := s.Exec(context.Background())
+		So(err, ShouldNotBeNil)
+		So(s.Recovered(), ShouldBeTrue)
+		So(s.closed, ShouldBeFalse)
+		So(s.Mode(), ShouldEqual, "login")
+		So(<-writes, ShouldEqual, "ping 1.1.1.1\n")
+		So(<-writes, ShouldEqual, cli.PagerQuit)
+		// no pager, q cleared
+		So(<-writes, ShouldEqual, cli.CtrlU)
+		So(<-writes, ShouldEqual, cli.CtrlC)
+
+		// mode resynced before failed conn reused
+		resyncTimeout = 200 * time.Millisecond
+		putConn(req.Address, s)
+		defer deleteConn(req.Address, s)
+		next := &protocol.CliRequest{Address: req.Address, Mode: "login", Timeout: time.Second}
+		c, err := Acquire(context.Background(), next, s.op)
+		So(err, ShouldBeNil)
+		So(c, ShouldEqual, s)
+		So(<-writes, ShouldEqual, "\n")
+		So(<-writes, ShouldEqual, cli.CtrlC+"\n")
+		Release(next)
+		So(s.failed, ShouldBeTrue)
+
+		// healthy conn reused as it is
+		s.failed = false
+		c, err = Acquire(context.Background(), next, s.op)
+		So(err, ShouldBeNil)
+		So(c, ShouldEqual, s)
+		Release(next)
+		select {
+		case w := <-writes:
+			So(w, ShouldBeEmpty)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
+
+// fakeJunos answer every line like a junos device, lines received are sent to ch
+func fakeJunos(ch chan<- string) (io.Reader, io.WriteCloser) {
+	dr, cw := io.Pipe()
+	cr, dw := io.Pipe()
+	go func() {
+		prompt := "admin@srx> "
+		scanner := bufio.NewScanner(dr)
+		for scanner.Scan() {
+			line := scanner.Text()
+			ch <- line
+			echo, out := line+"\n", ""
+			switch line {
+			case "configure", "configure private":
+				out, prompt = "Entering configuration mode\n", "\n[edit]\nadmin@srx# "
+			case "exit":
+				out, prompt = "Exiting configuration mode\n", "admin@srx> "
+			case "show | compare":
+				out = "[edit system]\n-  host-name srx;\n+  host-name srx1;\n"
+			case "commit":
+				out = "commit complete\n"
+			case "rollback 0":
+				out = "load complete\n"
+			case "show configuration | display set | no-more":
+				out = "set system host-name srx\nset system services ssh\n\n"
+			case "show snmp":
+				// secret split across reads
+				dw.Write([]byte(echo + "community s3"))
+				echo, out = "", "cret;\n"
+			}
+			dw.Write([]byte(echo + out + prompt))
+		}
+	}()
+	return cr, nopCloser{cw}
+}
+
+// newConn return conn of req to a fakeJunos in login mode, lines it receives are sent to ch
+func newConn(ch chan string, req *protocol.CliRequest) *CliConn {
+	r, w := fakeJunos(ch)
+	op := cli.OperatorManagerInstance.Get("juniper.srx.12")
+	s := &CliConn{t: common.SSHConn, req: req, op: op, mode: "login", r: r, w: w}
+	s.pump()
+	return s
+}
+
+func TestTransact(t *testing.T) {
+	Convey("config transaction", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		req := func() *protocol.CliRequest {
+			return &protocol.CliRequest{
+				Address:  "127.0.0.1:22",
+				Commands: []string{"set system host-name srx1"},
+				Timeout:  time.Second,
+			}
+		}
+		Convey("dry run", func() {
+			ch := make(chan string, 16)
+			s := newConn(ch, req())
+			c, res, err := s.Transact(context.Background(), 0, true)
+			So(err, ShouldBeNil)
+			So(c, ShouldEqual, s)
+			So(res.Diff, ShouldContainSubstring, "+  host-name srx1;")
+			So(res.Committed, ShouldBeFalse)
+			So(s.Mode(), ShouldEqual, "configure_private")
+			So(<-ch, ShouldEqual, "configure private")
+			So(<-ch, ShouldEqual, "set system host-name srx1")
+			So(<-ch, ShouldEqual, "show | compare")
+			So(<-ch, ShouldEqual, "rollback 0")
+		})
+		Convey("commit", func() {
+			ch := make(chan string, 16)
+			s := newConn(ch, req())
+			_, res, err := s.Transact(context.Background(), 0, false)
+			So(err, ShouldBeNil)
+			So(res.Committed, ShouldBeTrue)
+			So(res.Confirmed, ShouldBeFalse)
+			<-ch
+			<-ch
+			<-ch
+			So(<-ch, ShouldEqual, "commit")
+		})
+	})
+}
+
+func TestFetchConfig(t *testing.T) {
+	Convey("fetch config", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		ch := make(chan string, 16)
+		req := &protocol.CliRequest{Address: "127.0.0.1:22", Mode: "login", Timeout: time.Second}
+		s := newConn(ch, req)
+		config, b, err := s.FetchConfig(context.Background(), "")
+		So(err, ShouldBeNil)
+		So(b.Format, ShouldEqual, "set")
+		So(config, ShouldEqual, "set system host-name srx\nset system services ssh\n")
+		_, _, err = s.FetchConfig(context.Background(), "json")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestExecResults(t *testing.T) {
+	Convey("ordered command results", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		ch := make(chan string, 16)
+		req := &protocol.CliRequest{
+			Address:  "127.0.0.1:22",
+			Mode:     "login",
+			Commands: []string{"show version", "show version"},
+			Timeout:  time.Second,
+		}
+		s := newConn(ch, req)
+		results, err := s.Exec(context.Background())
+		So(err, ShouldBeNil)
+		So(results, ShouldHaveLength, 2)
+		for _, v := range results {
+			So(v.Command, ShouldEqual, "show version")
+			So(v.Mode, ShouldEqual, "login")
+			So(v.Prompt, ShouldEqual, "admin@srx> ")
+			So(v.Error, ShouldBeEmpty)
+			So(v.End.Sub(v.Start), ShouldEqual, v.Duration)
+		}
+		So(results[0].End.After(results[1].Start), ShouldBeFalse)
+	})
+}
+
+// fakeIos answer every line like an ios switch which rejects terminal length in config mode
+func fakeIos(ch chan<- string) (io.Reader, io.WriteCloser) {
+	dr, cw := io.Pipe()
+	cr, dw := io.Pipe()
+	go func() {
+		prompt := "sw1>"
+		scanner := bufio.NewScanner(dr)
+		for scanner.Scan() {
+			line := scanner.Text()
+			ch <- line
+			out := ""
+			switch {
+			case strings.HasPrefix(line, "enable"):
+				prompt = "sw1#"
+			case line == "config terminal":
+				out, prompt = "Enter configuration commands, one per line.  End with CNTL/Z.\n", "sw1(config)#"
+			case line == "terminal length 0" && prompt == "sw1(config)#":
+				out = "                   ^\n% Invalid input detected at '^' marker.\n\n"
+			}
+			dw.Write([]byte(line + "\n" + out + prompt))
+		}
+	}()
+	return cr, nopCloser{cw}
+}
+
+func TestPreExecHops(t *testing.T) {
+	Convey("pager disabled once enabled, before config mode", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		ch := make(chan string, 16)
+		req := &protocol.CliRequest{
+			Address:   "127.0.0.1:22",
+			Mode:      "configure_terminal",
+			Commands:  []string{"hostname sw1"},
+			EnablePwd: "secret",
+			Timeout:   time.Second,
+		}
+		r, w := fakeIos(ch)
+		op := cli.OperatorManagerInstance.Get("cisco.ios.15")
+		s := &CliConn{t: common.SSHConn, req: req, op: op, mode: "login", r: r, w: w}
+		s.pump()
+		results, err := s.Exec(context.Background())
+		So(err, ShouldBeNil)
+		So(results[0].Mode, ShouldEqual, "configure_terminal")
+		So(<-ch, ShouldEqual, "enable\rsecret")
+		So(<-ch, ShouldEqual, "terminal length 0")
+		So(<-ch, ShouldEqual, "config terminal")
+		So(<-ch, ShouldEqual, "hostname sw1")
+	})
+}
+
+func TestEncodings(t *testing.T) {
+	Convey("request input and output encodings", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		ch := make(chan string, 16)
+		req := &protocol.CliRequest{
+			Address:        "127.0.0.1:22",
+			Mode:           "login",
+			Commands:       []string{"show interfaces description 北京"},
+			Timeout:        time.Second,
+			Raw:            true,
+			InputEncoding:  "GB18030",
+			OutputEncoding: "GB18030",
+		}
+		s := newConn(ch, req)
+		results, err := s.Exec(context.Background())
+		So(err, ShouldBeNil)
+		So(<-ch, ShouldEqual, "show interfaces description \xb1\xb1\xbe\xa9")
+		So(results[0].InputEncoding, ShouldEqual, "GB18030")
+		So(results[0].OutputEncoding, ShouldEqual, "GB18030")
+		So(results[0].Output, ShouldContainSubstring, "北京")
+	})
+}
+
+func TestRecording(t *testing.T) {
+	Convey("session recorded in both directions", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		dir, err := ioutil.TempDir("", "netd-record")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		record.Instance, err = record.New(dir, nil)
+		So(err, ShouldBeNil)
+		defer func() { record.Instance = nil }()
+
+		ch := make(chan string, 16)
+		req := &protocol.CliRequest{
+			Address:  "127.0.0.1:22",
+			Device:   "srx",
+			Mode:     "login",
+			Commands: []string{"show version"},
+			Timeout:  time.Second,
+			Record:   true,
+		}
+		s := newConn(ch, req)
+		s.startRecording()
+		_, err = s.Exec(context.Background())
+		So(err, ShouldBeNil)
+		id := s.Recording()
+		So(id, ShouldNotBeEmpty)
+		s.stopRecording()
+		So(s.Recording(), ShouldBeEmpty)
+
+		f, err := record.Instance.Open("srx", id)
+		So(err, ShouldBeNil)
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		So(err, ShouldBeNil)
+		So(string(b), ShouldContainSubstring, `"i","show version\n"]`)
+		// recorded line by line, prompt once matched
+		So(string(b), ShouldContainSubstring, `"o","show version\n"]`)
+		So(string(b), ShouldContainSubstring, `"o","admin@srx> "]`)
+	})
+
+	Convey("secrets redacted in recording and output", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		dir, err := ioutil.TempDir("", "netd-record")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		record.Instance, err = record.New(dir, nil)
+		So(err, ShouldBeNil)
+		defer func() { record.Instance = nil }()
+
+		ch := make(chan string, 16)
+		req := &protocol.CliRequest{
+			Address:   "127.0.0.1:22",
+			Device:    "srx",
+			Mode:      "login",
+			Commands:  []string{"set snmp community s3cret", "show enable-pass", "show snmp"},
+			Timeout:   time.Second,
+			Record:    true,
+			Redact:    true,
+			EnablePwd: "enable-pass",
+		}
+		s := newConn(ch, req)
+		s.startRecording()
+		results, err := s.Exec(context.Background())
+		So(err, ShouldBeNil)
+		So(results[0].Output, ShouldNotContainSubstring, "s3cret")
+		So(results[0].Command, ShouldEqual, "set snmp community s3cret")
+		id := s.Recording()
+		s.stopRecording()
+
+		f, err := record.Instance.Open("srx", id)
+		So(err, ShouldBeNil)
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		So(err, ShouldBeNil)
+		So(string(b), ShouldNotContainSubstring, "s3cret")
+		So(string(b), ShouldNotContainSubstring, "cret;")
+		So(string(b), ShouldNotContainSubstring, "enable-pass")
+		So(string(b), ShouldContainSubstring, `"i","set snmp community ******\n"]`)
+	})
+
+	Convey("output recorded in utf-8", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		dir, err := ioutil.TempDir("", "netd-record")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		record.Instance, err = record.New(dir, nil)
+		So(err, ShouldBeNil)
+		defer func() { record.Instance = nil }()
+
+		ch := make(chan string, 16)
+		req := &protocol.CliRequest{
+			Address:        "127.0.0.1:22",
+			Device:         "srx",
+			Mode:           "login",
+			Commands:       []string{"show interfaces description 北京"},
+			Timeout:        time.Second,
+			Record:         true,
+			InputEncoding:  "GB18030",
+			OutputEncoding: "GB18030",
+		}
+		s := newConn(ch, req)
+		s.startRecording()
+		_, err = s.Exec(context.Background())
+		So(err, ShouldBeNil)
+		id := s.Recording()
+		s.stopRecording()
+
+		f, err := record.Instance.Open("srx", id)
+		So(err, ShouldBeNil)
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		So(err, ShouldBeNil)
+		So(string(b), ShouldContainSubstring, `"i","show interfaces description 北京\n"]`)
+		So(string(b), ShouldContainSubstring, `"o","show interfaces description 北京\n"]`)
+		So(string(b), ShouldNotContainSubstring, "\ufffd")
+	})
+}
+
+// captured keeps messages logged
+var captured = &captureLog{}
+
+type captureLog struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (c *captureLog) Init(string) error { return nil }
+func (c *captureLog) WriteMsg(when time.Time, msg string, level int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buf.WriteString(msg + "\n")
+	return nil
+}
+func (c *captureLog) Destroy() {}
+func (c *captureLog) Flush()   {}
+func (c *captureLog) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.String()
+}
+
+func init() {
+	logs.Register("capture", func() logs.Logger { return captured })
+}
+
+func TestLogRedaction(t *testing.T) {
+	Convey("secret split across reads masked in debug log", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		So(logs.SetLogger("capture"), ShouldBeNil)
+		defer func() {
+			logs.GetBeeLogger().DelLogger("capture")
+			logs.SetLogger("console")
+		}()
+		ch := make(chan string, 16)
+		req := &protocol.CliRequest{Address: "127.0.0.1:22", Mode: "login", Commands: []string{"show snmp"}, Timeout: time.Second}
+		s := newConn(ch, req)
+		_, err := s.Exec(context.Background())
+		So(err, ShouldBeNil)
+		So(captured.String(), ShouldContainSubstring, "community "+cli.Mask)
+		So(captured.String(), ShouldNotContainSubstring, "cret;")
+	})
+}
+
+func TestCancel(t *testing.T) {
+	Convey("cancel at command boundary", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		ch := make(chan string, 16)
+		req := &protocol.CliRequest{
+			Address:  "127.0.0.1:22",
+			Mode:     "login",
+			Commands: []string{"show version", "show clock", "show route"},
+			Timeout:  time.Second,
+		}
+		s := newConn(ch, req)
+		ctx, cancel := context.WithCancel(context.Background())
+		results, err := s.ExecEach(ctx, func(*protocol.CmdResult) { cancel() })
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "canceled")
+		So(results, ShouldHaveLength, 1)
+		So(s.closed, ShouldBeFalse)
+		So(<-ch, ShouldEqual, "show version")
+		So(ch, ShouldBeEmpty)
+	})
+
+	Convey("read in flight interrupted and session recovered", t, func() {
+		common.AppConfigInstance = &common.AppConfig{}
+		interruptTimeout, resyncQuiet = 200*time.Millisecond, 50*time.Millisecond
+		dr, cw := io.Pipe()
+		cr, dw := io.Pipe()
+		go func() {
+			buf := make([]byte, 64)
+			for {
+				n, err := dr.Read(buf)
+				if err != nil {
+					return
+				}
+				if strings.Contains(string(buf[:n]), cli.CtrlC) {
+					dw.Write([]byte("^C\nadmin> "))
+				}
+			}
+		}()
+		req := &protocol.CliRequest{
+			Address:  "127.0.0.1:22",
+			Mode:     "login",
+			Commands: []string{"ping 1.1.1.1"},
+			Timeout:  time.Minute,
+		}
+		s := &CliConn{t: common.SSHConn, req: req, op: &hungOp{}, mode: "login", r: cr, w: nopCloser{cw}}
+		s.pump()
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := s.Exec(ctx)
+		So(err, ShouldNotBeNil)
+		So(time.Since(start), ShouldBeLessThan, 5*time.Second)
+		So(s.Recovered(), ShouldBeTrue)
+		So(s.Mode(), ShouldEqual, "login")
+	})
+
+	Convey("sema waiting given up, only holder releases it", t, func() {
+		holder := &protocol.CliRequest{Address: "10.0.0.1:22"}
+		So(take(context.Background(), holder), ShouldBeNil)
+		req := &protocol.CliRequest{Address: "10.0.0.1:22"}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := Acquire(ctx, req, &hungOp{})
+		So(err, ShouldNotBeNil)
+		Release(req)
+		So(sema(req.Address), ShouldHaveLength, 1)
+		Release(holder)
+		So(sema(req.Address), ShouldHaveLength, 0)
+	})
+}
@@ -0,0 +1,90 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package conn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sky-cloud-tec/netd/cli"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/vault"
+	"github.com/songtianyi/rrframework/logs"
+)
+
+// credentials resolve credentials of req from vault, the first one applied to req.
+// Credential of cached conn goes first, so the conn is reused.
+// Nil returned if req carries password itself.
+func credentials(req *protocol.CliRequest) ([]*vault.Credential, error) {
+	if vault.Instance == nil {
+		if req.Auth.Credential != "" {
+			return nil, fmt.Errorf("credential %s requested, but vault not configured", req.Auth.Credential)
+		}
+		return nil, nil
+	}
+	cs, err := vault.Instance.Resolve(req)
+	if err != nil {
+		return nil, fmt.Errorf("resolve credential error: %s", err)
+	}
+	if v, ok := getConn(req.Address); ok {
+		for i, c := range cs {
+			if c.ID == v.req.Auth.Credential {
+				copy(cs[1:i+1], cs[:i])
+				cs[0] = c
+				break
+			}
+		}
+	}
+	if len(cs) > 0 {
+		vault.Apply(req, cs[0])
+	}
+	return cs, nil
+}
+
+// isAuthError tells whether login failed for bad credential, so the next one worth trying.
+// ssh reports authentication failure, telnet login failure looks like a missing prompt.
+func isAuthError(req *protocol.CliRequest, err error) bool {
+	if strings.ToLower(req.Protocol) == "ssh" {
+		return strings.Contains(err.Error(), "unable to authenticate")
+	}
+	return true
+}
+
+// dial create cli conn with credentials in order, until one logged in
+func dial(ctx context.Context, req *protocol.CliRequest, op cli.Operator, cs []*vault.Credential) (*CliConn, error) {
+	var c *CliConn
+	err := login(req, cs, func() error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var err error
+		c, err = newCliConn(ctx, req, op)
+		return err
+	})
+	return c, err
+}
+
+// login call f with credentials applied in order, until it does not fail for bad credential
+func login(req *protocol.CliRequest, cs []*vault.Credential, f func() error) error {
+	err := f()
+	for i := 1; err != nil && i < len(cs) && isAuthError(req, err); i++ {
+		logs.Error(req.LogPrefix, "login with credential", cs[i-1].ID, "failed:", err, ", try", cs[i].ID)
+		vault.Apply(req, cs[i])
+		err = f()
+	}
+	return err
+}
@@ -0,0 +1,210 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package conn
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sky-cloud-tec/netd/cli"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/record"
+	"github.com/songtianyi/rrframework/logs"
+	"github.com/ziutek/telnet"
+	"golang.org/x/crypto/ssh"
+)
+
+// TerminalLimit is max concurrent terminals per device
+var TerminalLimit = 1
+
+var (
+	termMu sync.Mutex
+	terms  = map[string]int{} // open terminals by device address
+)
+
+// Terminal is an interactive shell to device, it has its own connection,
+// cli conns and their semas are left alone. Everything read and written is
+// logged and recorded for audit, secrets redacted.
+type Terminal struct {
+	req *protocol.CliRequest
+	op  cli.Operator
+
+	client  *ssh.Client
+	session *ssh.Session
+	conn    *telnet.Conn
+	r       io.Reader
+	w       io.Writer
+
+	rec  *record.Cast // nil if recording disabled
+	once sync.Once
+}
+
+// OpenTerminal login device of req with credentials resolved like Acquire and start shell with pty of cols x rows,
+// it fails if device has TerminalLimit terminals open
+func OpenTerminal(req *protocol.CliRequest, op cli.Operator, cols, rows int) (*Terminal, error) {
+	if err := takeTerminal(req.Address); err != nil {
+		return nil, err
+	}
+	t := &Terminal{req: req, op: op}
+	cs, err := credentials(req)
+	if err == nil {
+		err = login(req, cs, func() error { return t.open(cols, rows) })
+	}
+	if err != nil {
+		releaseTerminal(req.Address)
+		return nil, err
+	}
+	// terminals are always recorded if recording enabled
+	if record.Instance != nil {
+		if t.rec, err = record.Instance.Start(req); err != nil {
+			logs.Error(req.LogPrefix, "start recording error:", err)
+		}
+	}
+	logs.Info(req.LogPrefix, "terminal opened by", req.Auth.Username, "recording", t.Recording())
+	return t, nil
+}
+
+func (t *Terminal) open(cols, rows int) error {
+	switch strings.ToLower(t.req.Protocol) {
+	case "ssh":
+		client, err := dialSSH(t.req)
+		if err != nil {
+			return err
+		}
+		session, err := client.NewSession()
+		if err != nil {
+			client.Close()
+			return fmt.Errorf("new ssh session failed, %s", err)
+		}
+		if t.r, err = session.StdoutPipe(); err == nil {
+			t.w, err = session.StdinPipe()
+		}
+		if err != nil {
+			session.Close()
+			client.Close()
+			return fmt.Errorf("create session pipe failed, %s", err)
+		}
+		if cols <= 0 || rows <= 0 {
+			cols, rows = 80, 24
+		}
+		if err := session.RequestPty("xterm", rows, cols, ssh.TerminalModes{ssh.ECHO: 1}); err != nil {
+			session.Close()
+			client.Close()
+			return fmt.Errorf("request pty failed, %s", err)
+		}
+		if err := session.Shell(); err != nil {
+			session.Close()
+			client.Close()
+			return fmt.Errorf("create shell failed, %s", err)
+		}
+		t.client, t.session = client, session
+		return nil
+	case "telnet":
+		conn, err := dialTelnet(t.req)
+		if err != nil {
+			return err
+		}
+		t.conn, t.r, t.w = conn, conn, conn
+		return nil
+	}
+	return fmt.Errorf("protocol %s not support", t.req.Protocol)
+}
+
+// takeTerminal take a terminal slot of device
+func takeTerminal(addr string) error {
+	termMu.Lock()
+	defer termMu.Unlock()
+	if terms[addr] >= TerminalLimit {
+		return fmt.Errorf("terminal limit %d of %s reached", TerminalLimit, addr)
+	}
+	terms[addr]++
+	return nil
+}
+
+func releaseTerminal(addr string) {
+	termMu.Lock()
+	defer termMu.Unlock()
+	if terms[addr]--; terms[addr] <= 0 {
+		delete(terms, addr)
+	}
+}
+
+// Recording return id of terminal recording, empty if not recorded
+func (t *Terminal) Recording() string {
+	if t.rec != nil {
+		return t.rec.ID
+	}
+	return ""
+}
+
+func (t *Terminal) redact(x string) string {
+	return cli.Redact(t.op, x, t.req.Auth.Password, t.req.EnablePwd)
+}
+
+// Read device output
+func (t *Terminal) Read(b []byte) (int, error) {
+	n, err := t.r.Read(b)
+	if n > 0 {
+		x := t.redact(string(b[:n]))
+		logs.Info(t.req.LogPrefix, "terminal output", strconv.Quote(x))
+		t.rec.Output([]byte(x))
+	}
+	return n, err
+}
+
+// Write keystrokes to device
+func (t *Terminal) Write(b []byte) (int, error) {
+	x := t.redact(string(b))
+	logs.Info(t.req.LogPrefix, "terminal input", strconv.Quote(x))
+	t.rec.Input([]byte(x))
+	return t.w.Write(b)
+}
+
+// Resize set pty size, telnet terminals ignore it
+func (t *Terminal) Resize(cols, rows int) error {
+	if t.session == nil || cols <= 0 || rows <= 0 {
+		return nil
+	}
+	if err := t.session.WindowChange(rows, cols); err != nil {
+		return fmt.Errorf("resize terminal failed, %s", err)
+	}
+	return nil
+}
+
+// Close terminal and release its slot, it's safe to call more than once
+func (t *Terminal) Close() error {
+	var err error
+	t.once.Do(func() {
+		if t.session != nil {
+			t.session.Close()
+		}
+		if t.client != nil {
+			err = t.client.Close()
+		}
+		if t.conn != nil {
+			err = t.conn.Close()
+		}
+		if err1 := t.rec.Close(); err1 != nil {
+			logs.Error(t.req.LogPrefix, "close recording error:", err1)
+		}
+		releaseTerminal(t.req.Address)
+		logs.Info(t.req.LogPrefix, "terminal closed")
+	})
+	return err
+}
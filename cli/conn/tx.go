@@ -0,0 +1,131 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package conn
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sky-cloud-tec/netd/cli"
+	"github.com/songtianyi/rrframework/logs"
+)
+
+// TxResult config transaction result
+type TxResult struct {
+	Diff      string // candidate diff before commit
+	Committed bool   // commit or confirmed commit done
+	Confirmed bool   // confirmed commit confirmed after reconnect
+}
+
+// Transact apply req commands to candidate config then commit it.
+// when confirm > 0, it commits confirmed, reconnects and confirms,
+// device rolls back itself if management access is lost.
+// conn is replaced when reconnected, the one serving the request returned.
+// Candidate changes are discarded if ctx done before commit
+func (s *CliConn) Transact(ctx context.Context, confirm int, dryRun bool) (*CliConn, *TxResult, error) {
+	s.ctx = ctx
+	h, ok := s.op.(cli.Transactor)
+	if !ok || h.GetTxCommands().Mode == "" {
+		return s, nil, fmt.Errorf("config transaction not supported")
+	}
+	tx := h.GetTxCommands()
+	if confirm > 0 && tx.CommitConfirmed == "" {
+		return s, nil, fmt.Errorf("confirmed commit not supported")
+	}
+	res := &TxResult{}
+	s.timedOut, s.recovered, s.failed = false, false, false
+	// apply
+	s.req.Mode = tx.Mode
+	if _, err := s.exec(); err != nil {
+		s.abort(tx)
+		return s, res, err
+	}
+	diff, err := s.run(tx.Diff)
+	if err != nil {
+		s.abort(tx)
+		return s, res, err
+	}
+	res.Diff = diff
+	if dryRun {
+		if _, err := s.run(tx.Discard); err != nil {
+			s.afterFailure()
+			return s, res, err
+		}
+		return s, res, nil
+	}
+	if confirm == 0 {
+		if _, err := s.run(tx.Commit); err != nil {
+			s.abort(tx)
+			return s, res, err
+		}
+		res.Committed = true
+		return s, res, nil
+	}
+	if _, err := s.run(fmt.Sprintf(tx.CommitConfirmed, confirm)); err != nil {
+		s.abort(tx)
+		return s, res, err
+	}
+	res.Committed = true
+	// management access should survive the change
+	c, err := s.reconnect()
+	if err != nil {
+		logs.Error(s.req.LogPrefix, "reconnect after confirmed commit failed:", err)
+		return s, res, fmt.Errorf("reconnect failed, device rolls back in %d minutes: %s", confirm, err)
+	}
+	if _, err := c.runIn(tx.Mode, tx.Confirm); err != nil {
+		c.afterFailure()
+		return c, res, fmt.Errorf("confirm failed, device rolls back in %d minutes: %s", confirm, err)
+	}
+	res.Confirmed = true
+	return c, res, nil
+}
+
+// abort discard candidate changes after failure, conn is closed if session can't be recovered
+func (s *CliConn) abort(tx *cli.TxCommands) {
+	s.afterFailure()
+	if s.closed {
+		return
+	}
+	logs.Info(s.req.LogPrefix, "discarding candidate changes")
+	if _, err := s.runIn(tx.Mode, tx.Discard); err != nil {
+		logs.Error(s.req.LogPrefix, "discard failed:", err)
+		s.afterFailure()
+	}
+}
+
+// runIn execute cmd in mode m
+func (s *CliConn) runIn(m, cmd string) (string, error) {
+	if s.mode != m {
+		s.req.Mode = m
+		s.op.RegisterMode(s.req)
+		if err := s.transit(m); err != nil {
+			return "", err
+		}
+	}
+	return s.run(cmd)
+}
+
+// reconnect close conn and dial again, sema is still held by caller
+func (s *CliConn) reconnect() (*CliConn, error) {
+	logs.Info(s.req.LogPrefix, "reconnecting ...")
+	s.Close()
+	c, err := newCliConn(s.ctx, s.req, s.op)
+	if err != nil {
+		return nil, err
+	}
+	putConn(s.req.Address, c)
+	return c, nil
+}
@@ -64,6 +64,12 @@ func (s *opFW1000) GetPrompts(k string) []*regexp.Regexp {
 	}
 	return nil
 }
+
+// GetModes return modes which have prompts registered
+func (s *opFW1000) GetModes() []string {
+	return cli.PromptModes(s.prompts)
+}
+
 func (s *opFW1000) SetPrompts(k string, regs []*regexp.Regexp) {
 	s.prompts[k] = regs
 }
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"strings"
 
 	"github.com/sky-cloud-tec/netd/cli"
 	"github.com/sky-cloud-tec/netd/protocol"
@@ -26,6 +27,8 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
+const consoleStandard = "config system console\n\tset output standard\nend"
+
 type opFortinet struct {
 	lineBreak   string // /r/n \n
 	transitions map[string][]string
@@ -33,6 +36,11 @@ type opFortinet struct {
 	errs        []*regexp.Regexp
 }
 
+// secretPatterns mask ENC encrypted values
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bENC[ \t]+([^ \t\r\n]+)`),
+}
+
 func init() {
 	cli.OperatorManagerInstance.Register(`(?i)fortinet\.FortiGate-VM64-KVM\..*`, createOpfortinet())
 }
@@ -98,6 +106,28 @@ func (s *opFortinet) GetStartMode() string {
 	return "login"
 }
 
+// GetPlatform return ntc-templates platform name
+func (s *opFortinet) GetPlatform() string {
+	return "fortinet"
+}
+
+// GetSecretPatterns ENC encrypted values
+func (s *opFortinet) GetSecretPatterns() []*regexp.Regexp {
+	return secretPatterns
+}
+
+// GetBackups fortigate, per vdom, request mode used config backups
+func (s *opFortinet) GetBackups() []*cli.Backup {
+	return []*cli.Backup{
+		{Format: "text", Commands: []string{"show full-configuration"}},
+	}
+}
+
+// GetInterrupts fortios cli has no ctrl-z binding
+func (s *opFortinet) GetInterrupts() []string {
+	return []string{cli.CtrlC, cli.PagerQuit}
+}
+
 func (s *opFortinet) GetLinebreak() string {
 	return s.lineBreak
 }
@@ -203,3 +233,55 @@ func (s *opFortinet) GetSSHInitializer() cli.SSHInitializer {
 		return r, w, session, nil
 	}
 }
+
+// DisablePager set console output to standard, it's done in global domain when vdom enabled
+func (s *opFortinet) DisablePager(sess cli.Session) error {
+	req := sess.Request()
+	pts := s.GetPrompts(req.Mode)
+	if pts == nil {
+		return fmt.Errorf("mode %s not registered", req.Mode)
+	}
+	if !strings.Contains(pts[0].String(), req.Mode) {
+		// non vdom
+		if _, err := sess.WriteBuff(consoleStandard); err != nil {
+			return err
+		}
+		_, _, err := sess.ReadBuff()
+		return err
+	}
+	// vdom
+	logs.Debug(req.LogPrefix, "entering domain global...")
+	if _, err := sess.WriteBuff("config global"); err != nil {
+		return err
+	}
+	if _, err := sess.WriteBuff(consoleStandard); err != nil {
+		return err
+	}
+	logs.Debug(req.LogPrefix, "exiting domain global...")
+	if _, err := sess.WriteBuff("end"); err != nil {
+		return err
+	}
+	_, _, err := sess.ReadBuff()
+	return err
+}
+
+// CleanOutput remove --More-- residue and windows blank lines
+func (s *opFortinet) CleanOutput(x string) string {
+	return removeMoreBreakedPart(removeWinBlankline1Space(removeWinBlankline5Space(removeStandardMore(x))))
+}
+
+func removeStandardMore(x string) string {
+	return regexp.MustCompile(`--More--([ ]+\r){1,2}`).ReplaceAllString(x, "")
+}
+
+func removeMoreBreakedPart(x string) string {
+	return regexp.MustCompile(`\n\r( )+\r`).ReplaceAllString(x, "")
+}
+
+func removeWinBlankline5Space(x string) string {
+	return regexp.MustCompile(`( ){5}\r\n`).ReplaceAllString(x, "    ")
+}
+
+func removeWinBlankline1Space(x string) string {
+	return regexp.MustCompile(` \r\n`).ReplaceAllString(x, "")
+}
@@ -58,3 +58,15 @@ func TestFortinetOp(t *testing.T) {
 		)
 	})
 }
+
+func TestFortinetCleanOutput(t *testing.T) {
+
+	Convey("fortinet clean output", t, func() {
+		op := createOpfortinet().(cli.OutputCleaner)
+		So(
+			op.CleanOutput("config firewall policy\n--More-- \r         \redit 1\n"),
+			ShouldEqual,
+			"config firewall policy\nedit 1\n",
+		)
+	})
+}
@@ -0,0 +1,59 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import "sort"
+
+// ShortestPath treat operator transitions as a directed graph,
+// return the shortest mode path from c to t, both included.
+// nil returned if t is unreachable.
+func ShortestPath(op Operator, c, t string) []string {
+	if c == t {
+		return []string{c}
+	}
+	modes := GetModes(op)
+	// stable result for modes sharing the same distance
+	sort.Strings(modes)
+	// t may be registered lazily, see RegisterMode, or not listed
+	candidates := append(modes, t)
+	prev := map[string]string{c: ""}
+	queue := []string{c}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range candidates {
+			if _, ok := prev[next]; ok {
+				continue
+			}
+			if op.GetTransitions(cur, next) == nil {
+				// no edge
+				continue
+			}
+			prev[next] = cur
+			if next != t {
+				queue = append(queue, next)
+				continue
+			}
+			// reached, walk back
+			path := []string{t}
+			for m := cur; m != ""; m = prev[m] {
+				path = append([]string{m}, path...)
+			}
+			return path
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/sky-cloud-tec/netd/protocol"
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/ssh"
+)
+
+// graphOp is an operator with transitions only
+type graphOp struct {
+	transitions map[string][]string
+}
+
+func (s *graphOp) GetTransitions(c, t string) []string {
+	if v, ok := s.transitions[c+"->"+t]; ok {
+		return v
+	}
+	return nil
+}
+func (s *graphOp) GetPrompts(m string) []*regexp.Regexp    { return nil }
+func (s *graphOp) SetPrompts(string, []*regexp.Regexp)     {}
+func (s *graphOp) GetErrPatterns() []*regexp.Regexp        { return nil }
+func (s *graphOp) SetErrPatterns([]*regexp.Regexp)         {}
+func (s *graphOp) GetLinebreak() string                    { return "\n" }
+func (s *graphOp) GetStartMode() string                    { return "a" }
+func (s *graphOp) RegisterMode(*protocol.CliRequest) error { return nil }
+func (s *graphOp) GetEncoding() string                     { return "" }
+func (s *graphOp) GetExcludes() []*regexp.Regexp           { return nil }
+func (s *graphOp) GetSSHInitializer() SSHInitializer {
+	return func(*ssh.Client, *protocol.CliRequest) (io.Reader, io.WriteCloser, *ssh.Session, error) {
+		return nil, nil, nil, nil
+	}
+}
+
+// listedOp is graphOp listing its modes
+type listedOp struct {
+	graphOp
+}
+
+func (s *listedOp) GetModes() []string { return []string{"a", "b", "c", "d", "e"} }
+
+func TestShortestPath(t *testing.T) {
+	Convey("shortest mode path", t, func() {
+		op := &listedOp{graphOp{transitions: map[string][]string{
+			"a->b": {"1"},
+			"b->c": {"2"},
+			"c->d": {"3"},
+			"b->d": {"4"},
+			"d->a": {},
+			"x->a": {"5"},
+		}}}
+		So(ShortestPath(op, "a", "a"), ShouldResemble, []string{"a"})
+		So(ShortestPath(op, "a", "b"), ShouldResemble, []string{"a", "b"})
+		So(ShortestPath(op, "a", "d"), ShouldResemble, []string{"a", "b", "d"})
+		So(ShortestPath(op, "c", "b"), ShouldResemble, []string{"c", "d", "a", "b"})
+		So(ShortestPath(op, "a", "e"), ShouldBeNil)
+		// mode not listed by GetModes
+		So(ShortestPath(op, "x", "b"), ShouldResemble, []string{"x", "a", "b"})
+
+		// modes not listed, through start mode only
+		star := &op.graphOp
+		So(GetModes(star), ShouldResemble, []string{"a"})
+		So(ShortestPath(star, "a", "b"), ShouldResemble, []string{"a", "b"})
+		So(ShortestPath(star, "x", "b"), ShouldResemble, []string{"x", "a", "b"})
+		So(ShortestPath(star, "a", "d"), ShouldBeNil)
+	})
+}
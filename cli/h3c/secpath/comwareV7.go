@@ -24,6 +24,12 @@ import (
 	"regexp"
 )
 
+// secretPatterns mask hashed and ciphered passwords
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bhash[ \t]+([^ \t\r\n]+)`),
+	regexp.MustCompile(`\$c\$[0-9]+\$[^ \t\r\n]+`),
+}
+
 func init() {
 	// register H3C SecPath comwareV7
 	cli.OperatorManagerInstance.Register(`(?i)h3c\.secpath\..*`, createOpH3CV7())
@@ -65,6 +71,11 @@ func (s *opH3CV7) GetPrompts(k string) []*regexp.Regexp {
 	return nil
 }
 
+// GetModes return modes which have prompts registered
+func (s *opH3CV7) GetModes() []string {
+	return cli.PromptModes(s.prompts)
+}
+
 func (s *opH3CV7) SetPrompts(k string, regs []*regexp.Regexp) {
 	s.prompts[k] = regs
 }
@@ -101,6 +112,28 @@ func (s *opH3CV7) GetStartMode() string {
 	return "login"
 }
 
+// GetPlatform return ntc-templates platform name
+func (s *opH3CV7) GetPlatform() string {
+	return "hp_comware"
+}
+
+// GetResyncs return leaves system view, quit would log out of user view
+func (s *opH3CV7) GetResyncs() []string {
+	return []string{"", cli.CtrlC, "return"}
+}
+
+// GetSecretPatterns hashed and ciphered passwords
+func (s *opH3CV7) GetSecretPatterns() []*regexp.Regexp {
+	return secretPatterns
+}
+
+// GetBackups comware config backups
+func (s *opH3CV7) GetBackups() []*cli.Backup {
+	return []*cli.Backup{
+		{Format: "text", Mode: "login", Commands: []string{"display current-configuration"}},
+	}
+}
+
 // RegisterMode ...
 func (s *opH3CV7) RegisterMode(req *protocol.CliRequest) error {
 	return nil
@@ -137,3 +170,12 @@ func (s *opH3CV7) GetSSHInitializer() cli.SSHInitializer {
 		return r, w, session, nil
 	}
 }
+
+// DisablePager disable screen paging
+func (s *opH3CV7) DisablePager(sess cli.Session) error {
+	if _, err := sess.WriteBuff("screen-length disable"); err != nil {
+		return err
+	}
+	_, _, err := sess.ReadBuff()
+	return err
+}
@@ -67,6 +67,11 @@ func (s *opHillstone) GetPrompts(k string) []*regexp.Regexp {
 	return nil
 }
 
+// GetModes return modes which have prompts registered
+func (s *opHillstone) GetModes() []string {
+	return cli.PromptModes(s.prompts)
+}
+
 func (s *opHillstone) SetPrompts(k string, regs []*regexp.Regexp) {
 	s.prompts[k] = regs
 }
@@ -103,6 +108,13 @@ func (s *opHillstone) GetStartMode() string {
 	return "login"
 }
 
+// GetBackups hillstone config backups
+func (s *opHillstone) GetBackups() []*cli.Backup {
+	return []*cli.Backup{
+		{Format: "text", Mode: "login", Commands: []string{"show configuration"}},
+	}
+}
+
 // RegisterMode ...
 func (s *opHillstone) RegisterMode(req *protocol.CliRequest) error {
 	return nil
@@ -140,3 +152,12 @@ func (s *opHillstone) GetSSHInitializer() cli.SSHInitializer {
 		return r, w, session, nil
 	}
 }
+
+// DisablePager disable screen paging
+func (s *opHillstone) DisablePager(sess cli.Session) error {
+	if _, err := sess.WriteBuff("terminal length 0"); err != nil {
+		return err
+	}
+	_, _, err := sess.ReadBuff()
+	return err
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"strings"
 
 	"github.com/sky-cloud-tec/netd/cli"
 	"github.com/sky-cloud-tec/netd/protocol"
@@ -26,6 +27,12 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
+// secretPatterns mask %^%# and %$%$ ciphers
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`%\^%#.*?%\^%#`),
+	regexp.MustCompile(`%\$%\$.*?%\$%\$`),
+}
+
 func init() {
 	// register HUAWEI USG6000V2
 	cli.OperatorManagerInstance.Register(`(?i)huawei\.usg[0-9]{0,}\..*`, createopUsg6000V())
@@ -72,6 +79,11 @@ func (s *opUsg6000V) GetPrompts(k string) []*regexp.Regexp {
 	return nil
 }
 
+// GetModes return modes which have prompts registered
+func (s *opUsg6000V) GetModes() []string {
+	return cli.PromptModes(s.prompts)
+}
+
 func (s *opUsg6000V) SetPrompts(k string, regs []*regexp.Regexp) {
 	s.prompts[k] = regs
 }
@@ -100,6 +112,28 @@ func (s *opUsg6000V) GetStartMode() string {
 	return "login"
 }
 
+// GetPlatform return ntc-templates platform name
+func (s *opUsg6000V) GetPlatform() string {
+	return "huawei"
+}
+
+// GetResyncs return leaves system view, quit would log out of user view
+func (s *opUsg6000V) GetResyncs() []string {
+	return []string{"", cli.CtrlC, "return"}
+}
+
+// GetSecretPatterns %^%# and %$%$ ciphers
+func (s *opUsg6000V) GetSecretPatterns() []*regexp.Regexp {
+	return secretPatterns
+}
+
+// GetBackups usg config backups
+func (s *opUsg6000V) GetBackups() []*cli.Backup {
+	return []*cli.Backup{
+		{Format: "text", Mode: "login", Commands: []string{"display current-configuration"}},
+	}
+}
+
 func (s *opUsg6000V) GetEncoding() string {
 	return ""
 }
@@ -147,3 +181,39 @@ func (s *opUsg6000V) GetTelnetInitializer() cli.TELNETInitializer {
 		return nil
 	}
 }
+
+// DisablePager disable screen paging, it's done in user interface view for non 200 series
+func (s *opUsg6000V) DisablePager(sess cli.Session) error {
+	if strings.HasSuffix(sess.Request().Version, "200") {
+		if _, err := sess.WriteBuff("screen-length 0 temporary"); err != nil {
+			return err
+		}
+		_, _, err := sess.ReadBuff()
+		return err
+	}
+	if sess.Mode() == "login" {
+		// current in login mode
+		// enter system view
+		sess.SetMode("system_View")
+		if _, err := sess.WriteBuff("system-view"); err != nil {
+			// rollback
+			sess.SetMode("login")
+			return err
+		}
+		// drain output
+		if _, _, err := sess.ReadBuff(); err != nil {
+			return err
+		}
+		// after disable more scren, no need to transition back to login mode
+		// it wiil be done automaticaly before execute commands
+	}
+	// now in system view
+	cmd := "user-interface current\nscreen-length 0"
+	// quit from ui config
+	cmd += "\nquit"
+	if _, err := sess.WriteBuff(cmd); err != nil {
+		return err
+	}
+	_, _, err := sess.ReadBuff()
+	return err
+}
@@ -25,6 +25,12 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
+// secretPatterns mask $9$ obfuscated and crypt hashed secrets
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\$9\$[^" \t\r\n;]+`),
+	regexp.MustCompile(`\$[156]\$[^" \t\r\n;]+`),
+}
+
 func init() {
 	// register srx 6.x
 	cli.OperatorManagerInstance.Register(`(?i)juniper\.v?srx\..*`, createOpJunos())
@@ -79,6 +85,11 @@ func (s *opJunos) GetPrompts(k string) []*regexp.Regexp {
 	return nil
 }
 
+// GetModes return modes which have prompts registered, configure modes share one prompt
+func (s *opJunos) GetModes() []string {
+	return cli.PromptModes(s.prompts)
+}
+
 func (s *opJunos) SetPrompts(k string, regs []*regexp.Regexp) {
 	s.prompts[k] = regs
 }
@@ -115,6 +126,43 @@ func (s *opJunos) GetStartMode() string {
 	return "login"
 }
 
+// GetPlatform return ntc-templates platform name
+func (s *opJunos) GetPlatform() string {
+	return "juniper_junos"
+}
+
+// GetSecretPatterns $9$ obfuscated and crypt hashed secrets
+func (s *opJunos) GetSecretPatterns() []*regexp.Regexp {
+	return secretPatterns
+}
+
+// GetBackups junos config backups
+func (s *opJunos) GetBackups() []*cli.Backup {
+	return []*cli.Backup{
+		{Format: "set", Mode: "login", Commands: []string{"show configuration | display set | no-more"}},
+		{Format: "text", Mode: "login", Commands: []string{"show configuration | no-more"}},
+		{Format: "xml", Mode: "login", Commands: []string{"show configuration | display xml | no-more"}},
+	}
+}
+
+// GetTxCommands junos candidate config, commit confirmed rolls back itself.
+// private candidate, so other users' uncommitted changes are neither committed nor rolled back
+func (s *opJunos) GetTxCommands() *cli.TxCommands {
+	return &cli.TxCommands{
+		Mode:            "configure_private",
+		Diff:            "show | compare",
+		Commit:          "commit",
+		CommitConfirmed: "commit confirmed %d",
+		Confirm:         "commit",
+		Discard:         "rollback 0",
+	}
+}
+
+// GetInterrupts junos cli has no ctrl-z binding
+func (s *opJunos) GetInterrupts() []string {
+	return []string{cli.CtrlC, cli.PagerQuit}
+}
+
 // RegisterMode ...
 func (s *opJunos) RegisterMode(req *protocol.CliRequest) error {
 	return nil
@@ -36,3 +36,14 @@ func TestJunosOp(t *testing.T) {
 		)
 	})
 }
+
+func TestJunosDetectMode(t *testing.T) {
+
+	Convey("srx detect mode", t, func() {
+		op := createOpJunos()
+		// modes share the same prompt, keep current
+		So(cli.DetectMode(op, "admin@hostname# ", "configure_private"), ShouldEqual, "configure_private")
+		So(cli.DetectMode(op, "admin@hostname# ", "login"), ShouldEqual, "configure")
+		So(cli.DetectMode(op, "admin@hostname> ", "configure_exclusive"), ShouldEqual, "login")
+	})
+}
@@ -25,6 +25,11 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
+// secretPatterns mask vpn preshare keys
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bpreshare[ \t]+("[^"\r\n]*"|[^ \t\r\n]+)`),
+}
+
 func init() {
 	// register ssg
 	cli.OperatorManagerInstance.Register(`(?i)juniper\.ssg\..*`, createOpScreenOS())
@@ -91,6 +96,23 @@ func (s *opScreenOS) GetStartMode() string {
 	return "login"
 }
 
+// GetPlatform return ntc-templates platform name
+func (s *opScreenOS) GetPlatform() string {
+	return "juniper_screenos"
+}
+
+// GetSecretPatterns vpn preshare keys
+func (s *opScreenOS) GetSecretPatterns() []*regexp.Regexp {
+	return secretPatterns
+}
+
+// GetBackups screenos config backups
+func (s *opScreenOS) GetBackups() []*cli.Backup {
+	return []*cli.Backup{
+		{Format: "text", Mode: "login", Commands: []string{"get config"}},
+	}
+}
+
 func (s *opScreenOS) GetEncoding() string {
 	return ""
 }
@@ -108,6 +108,16 @@ func (s *Centos) GetStartMode() string {
 	return "login"
 }
 
+// GetPlatform return ntc-templates platform name
+func (s *Centos) GetPlatform() string {
+	return "linux"
+}
+
+// GetInterrupts Centos, no pager and ctrl-z suspends job in shell
+func (s *Centos) GetInterrupts() []string {
+	return []string{cli.CtrlC}
+}
+
 // RegisterMode ...
 func (s *Centos) RegisterMode(req *protocol.CliRequest) error {
 	return nil
@@ -0,0 +1,162 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Normalizer is a step of output normalization, cmd is the command output belongs to
+type Normalizer func(cmd, x string) string
+
+// Normalizers is the pipeline every command output goes through unless raw output requested
+var Normalizers = []Normalizer{NormalizeLinebreaks, Overstrike, RemovePagerResidue, RemoveEcho}
+
+// Normalize run x through Normalizers
+func Normalize(cmd, x string) string {
+	for _, f := range Normalizers {
+		x = f(cmd, x)
+	}
+	return x
+}
+
+// NormalizeLinebreaks turn \r\n and \n\r into \n
+func NormalizeLinebreaks(cmd, x string) string {
+	x = strings.Replace(x, "\r\n", "\n", -1)
+	return strings.Replace(x, "\n\r", "\n", -1)
+}
+
+var (
+	// csi is control sequence introducer, params and final byte
+	csi = regexp.MustCompile(`^\x1b\[([0-9;?]*)[ -/]*([@-~])`)
+	// other escapes, osc, charset and two bytes ones
+	otherEscape = regexp.MustCompile(`^\x1b(\][^\x07\x1b]*(\x07|\x1b\\)?|[()][0-9A-Za-z]|[@-Z\\-_]?)`)
+)
+
+// Overstrike emulate carriage return, backspace and cursor moving escapes line by line
+// like a terminal does, other escapes are stripped
+func Overstrike(cmd, x string) string {
+	lines := strings.Split(x, "\n")
+	for i, v := range lines {
+		lines[i] = overstrikeLine(v)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func overstrikeLine(line string) string {
+	if !strings.ContainsAny(line, "\r\b\x1b") {
+		return line
+	}
+	rs := []rune(line)
+	buf := make([]rune, 0, len(rs))
+	cur := 0
+	put := func(r rune) {
+		for len(buf) < cur {
+			buf = append(buf, ' ')
+		}
+		if cur < len(buf) {
+			buf[cur] = r
+		} else {
+			buf = append(buf, r)
+		}
+		cur++
+	}
+	for i := 0; i < len(rs); i++ {
+		switch rs[i] {
+		case '\r':
+			cur = 0
+		case '\b':
+			if cur > 0 {
+				cur--
+			}
+		case '\x1b':
+			rest := string(rs[i:])
+			m := csi.FindStringSubmatch(rest)
+			if m == nil {
+				i += len([]rune(otherEscape.FindString(rest))) - 1
+				continue
+			}
+			i += len([]rune(m[0])) - 1
+			n, err := strconv.Atoi(m[1])
+			if err != nil || n == 0 {
+				n = 1
+			}
+			switch m[2] {
+			case "D": // cursor back
+				if cur -= n; cur < 0 {
+					cur = 0
+				}
+			case "C": // cursor forward
+				cur += n
+			case "K": // erase to end of line
+				if m[1] == "" || m[1] == "0" {
+					if cur < len(buf) {
+						buf = buf[:cur]
+					}
+				}
+			}
+		default:
+			put(rs[i])
+		}
+	}
+	return strings.TrimRight(string(buf), " ")
+}
+
+var pagerResidues = []*regexp.Regexp{
+	// --More--, <--- More --->, ---- More ----
+	regexp.MustCompile(`([<\-]+) ?(M|m)ore ?([>\-]+) *`),
+	// junos ---(more)---, ---(more 45%)---
+	regexp.MustCompile(`-+\(more( [0-9]+%)?\)-+ *`),
+}
+
+// RemovePagerResidue remove pager prompts left in output, lines only have pager prompt are dropped
+func RemovePagerResidue(cmd, x string) string {
+	lines := strings.Split(x, "\n")
+	out := lines[:0]
+	for _, v := range lines {
+		y := v
+		for _, p := range pagerResidues {
+			y = p.ReplaceAllString(y, "")
+		}
+		if y != v && strings.TrimSpace(y) == "" {
+			continue
+		}
+		out = append(out, y)
+	}
+	return strings.Join(out, "\n")
+}
+
+// RemoveEcho remove the first line if it's the echo of cmd, maybe with prompt ahead
+func RemoveEcho(cmd, x string) string {
+	cmd = strings.TrimSpace(cmd)
+	if cmd == "" {
+		return x
+	}
+	i := strings.Index(x, "\n")
+	first := x
+	if i >= 0 {
+		first = x[:i]
+	}
+	if !strings.HasSuffix(strings.TrimSpace(first), cmd) {
+		return x
+	}
+	if i < 0 {
+		return ""
+	}
+	return x[i+1:]
+}
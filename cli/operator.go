@@ -19,6 +19,7 @@ import (
 	"io"
 	"log"
 	"regexp"
+	"strings"
 
 	"github.com/sky-cloud-tec/netd/protocol"
 	"github.com/songtianyi/rrframework/logs"
@@ -41,6 +42,152 @@ type Operator interface {
 	GetExcludes() []*regexp.Regexp
 }
 
+// EnablePwdPlaceholder is replaced with request enable password when transition commands executed
+const EnablePwdPlaceholder = "{{enable_pwd}}"
+
+// Session is the cli connection view exposed to operator hooks
+type Session interface {
+	// Request return the cli request currently served by this session
+	Request() *protocol.CliRequest
+	// Mode return current cli mode
+	Mode() string
+	// SetMode set current cli mode, prompts of this mode will be used by next ReadBuff
+	SetMode(string)
+	// WriteBuff write cmd to device, operator linebreak appended if not linebreaked
+	WriteBuff(cmd string) (int, error)
+	// ReadBuff read until prompt of current mode matched, return output and prompt
+	ReadBuff() (string, string, error)
+	// Set store session scoped value, it lives as long as the connection
+	Set(key string, v interface{})
+	// Get return session scoped value
+	Get(key string) (interface{}, bool)
+}
+
+// PagerDisabler is implemented by operators which need to disable paging after login
+type PagerDisabler interface {
+	DisablePager(Session) error
+}
+
+// PostLoginHook is implemented by operators which need extra steps after first prompt fetched,
+// pager disabling is up to the hook when it's implemented
+type PostLoginHook interface {
+	PostLogin(s Session, prompt string) error
+}
+
+// PreExecHook is implemented by operators which need extra steps before commands executed,
+// it runs in the mode session starts from and again after every hop of mode transitions
+type PreExecHook interface {
+	PreExec(Session) error
+}
+
+// OutputCleaner is implemented by operators which need to clean up command output
+type OutputCleaner interface {
+	CleanOutput(string) string
+}
+
+// ModeLister is implemented by operators which have several modes, so mode paths may go through
+// modes other than start mode, prompts of any mode are recognized and transitions can be enumerated
+type ModeLister interface {
+	GetModes() []string
+}
+
+// PromptModes return modes prompts are registered for, GetModes of operators keeping prompts in a map
+func PromptModes(prompts map[string][]*regexp.Regexp) []string {
+	modes := make([]string, 0, len(prompts))
+	for k := range prompts {
+		modes = append(modes, k)
+	}
+	return modes
+}
+
+// GetModes return modes of operator, start mode only if it's not ModeLister
+func GetModes(op Operator) []string {
+	if h, ok := op.(ModeLister); ok {
+		return h.GetModes()
+	}
+	return []string{op.GetStartMode()}
+}
+
+// Interrupter is implemented by operators whose interrupt sequences differ from DefaultInterrupts,
+// they're sent one by one after read timeout until a known prompt is back
+type Interrupter interface {
+	GetInterrupts() []string
+}
+
+const (
+	// CtrlC abort running command
+	CtrlC = "\x03"
+	// CtrlZ leave config mode on many devices
+	CtrlZ = "\x1a"
+	// CtrlU clear line typed so far
+	CtrlU = "\x15"
+	// PagerQuit quit pager, line is cleared with CtrlU if no pager answers it,
+	// opt in with Interrupter since q left in line is quit on many devices
+	PagerQuit = "q"
+	// BreakKey send break, telnet IAC BRK or ssh break request, for consoles,
+	// as interrupt sequence or written by hooks with WriteBuff
+	BreakKey = "<break>"
+)
+
+// DefaultInterrupts are interrupt sequences for operators which are not Interrupter
+var DefaultInterrupts = []string{CtrlC, CtrlZ}
+
+// Resyncer is implemented by operators whose resync sequences differ from DefaultResyncs,
+// they're sent one by one until a known prompt is back, eg. to leave config modes
+type Resyncer interface {
+	GetResyncs() []string
+}
+
+// DefaultResyncs are resync sequences for operators which are not Resyncer, linebreak then ctrl-c
+var DefaultResyncs = []string{"", CtrlC}
+
+// GetResyncs return resync sequences of operator
+func GetResyncs(op Operator) []string {
+	if h, ok := op.(Resyncer); ok {
+		return h.GetResyncs()
+	}
+	return DefaultResyncs
+}
+
+// GetInterrupts return interrupt sequences of operator
+func GetInterrupts(op Operator) []string {
+	if h, ok := op.(Interrupter); ok {
+		return h.GetInterrupts()
+	}
+	return DefaultInterrupts
+}
+
+// TxCommands are config transaction commands of two-stage devices,
+// which edit candidate config and commit it to take effect
+type TxCommands struct {
+	Mode            string // mode where candidate config edited
+	Diff            string // show candidate diff against running config
+	Commit          string // commit candidate
+	CommitConfirmed string // commit and roll back unless confirmed, minutes formatted with %d, empty if not supported
+	Confirm         string // confirm confirmed commit
+	Discard         string // discard candidate changes
+}
+
+// Transactor is implemented by operators which support config transactions,
+// empty Mode means not supported
+type Transactor interface {
+	GetTxCommands() *TxCommands
+}
+
+// Platformer is implemented by operators which have ntc-templates platform name,
+// it selects output templates from index
+type Platformer interface {
+	GetPlatform() string
+}
+
+// GetPlatform return ntc-templates platform of operator, vendor_type of request if it's not declared
+func GetPlatform(op Operator, req *protocol.CliRequest) string {
+	if h, ok := op.(Platformer); ok && h.GetPlatform() != "" {
+		return h.GetPlatform()
+	}
+	return strings.ToLower(req.Vendor + "_" + req.Type)
+}
+
 var (
 	// OperatorManagerInstance is OperatorManager instance
 	OperatorManagerInstance *OperatorManager
@@ -75,6 +222,12 @@ func (s *OperatorManager) Get(t string) Operator {
 	return nil
 }
 
+// Registered return true if pattern registered
+func (s *OperatorManager) Registered(pattern string) bool {
+	_, ok := s.operatorMap[pattern]
+	return ok
+}
+
 // Register do operator registration
 func (s *OperatorManager) Register(pattern string, o Operator) {
 	logs.Info("Registering op", pattern, o)
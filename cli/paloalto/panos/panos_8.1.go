@@ -25,6 +25,12 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
+// secretPatterns mask password hashes and keys, xml and set formats
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`<(?:phash|key|password|secret|community|authentication-key|pre-shared-key)>([^<]*)</`),
+	regexp.MustCompile(`(?i)\bphash[ \t]+([^ \t\r\n]+)`),
+}
+
 func init() {
 	// register paloalto
 	cli.OperatorManagerInstance.Register(`(?i)paloalto\.(pan-os|Panorama)\..*`, createOpPaloalto())
@@ -71,6 +77,12 @@ func (s *opPaloalto) GetPrompts(k string) []*regexp.Regexp {
 	}
 	return nil
 }
+
+// GetModes return modes which have prompts registered
+func (s *opPaloalto) GetModes() []string {
+	return cli.PromptModes(s.prompts)
+}
+
 func (s *opPaloalto) SetPrompts(k string, regs []*regexp.Regexp) {
 	s.prompts[k] = regs
 }
@@ -107,6 +119,41 @@ func (s *opPaloalto) GetStartMode() string {
 	return "login"
 }
 
+// GetPlatform return ntc-templates platform name
+func (s *opPaloalto) GetPlatform() string {
+	return "paloalto_panos"
+}
+
+// GetSecretPatterns password hashes and keys, xml and set formats
+func (s *opPaloalto) GetSecretPatterns() []*regexp.Regexp {
+	return secretPatterns
+}
+
+// GetBackups panos config backups, output format is set by PreExec.
+// running config, show in configure mode would print candidate with uncommitted changes
+func (s *opPaloalto) GetBackups() []*cli.Backup {
+	return []*cli.Backup{
+		{Format: "set", Mode: "login", Commands: []string{"show config running"}},
+		{Format: "xml", Mode: "login", Commands: []string{"show config running"}},
+		{Format: "json", Mode: "login", Commands: []string{"show config running"}},
+	}
+}
+
+// GetTxCommands panos candidate config, no confirmed commit
+func (s *opPaloalto) GetTxCommands() *cli.TxCommands {
+	return &cli.TxCommands{
+		Mode:    "configure",
+		Diff:    "show config diff",
+		Commit:  "commit",
+		Discard: "revert config",
+	}
+}
+
+// GetInterrupts panos cli has no ctrl-z binding
+func (s *opPaloalto) GetInterrupts() []string {
+	return []string{cli.CtrlC, cli.PagerQuit}
+}
+
 // RegisterMode ...
 func (s *opPaloalto) RegisterMode(req *protocol.CliRequest) error {
 	return nil
@@ -144,3 +191,56 @@ func (s *opPaloalto) GetSSHInitializer() cli.SSHInitializer {
 		return r, w, session, nil
 	}
 }
+
+// DisablePager set cli pager off
+func (s *opPaloalto) DisablePager(sess cli.Session) error {
+	if _, err := sess.WriteBuff("set cli pager off"); err != nil {
+		return err
+	}
+	_, _, err := sess.ReadBuff()
+	return err
+}
+
+// PreExec set config output format, it can only be done in login mode
+func (s *opPaloalto) PreExec(sess cli.Session) error {
+	format := sess.Request().Format
+	if format == "" {
+		return nil
+	}
+	if v, ok := sess.Get("format"); ok && v.(string) == format {
+		// already set
+		return nil
+	}
+	mode := sess.Mode()
+	if mode != "login" {
+		// transition to login first
+		if _, err := sess.WriteBuff("exit"); err != nil {
+			return err
+		}
+		sess.SetMode("login")
+		if _, _, err := sess.ReadBuff(); err != nil {
+			sess.SetMode(mode)
+			return err
+		}
+	}
+	// set format
+	if _, err := sess.WriteBuff("set cli config-output-format " + format); err != nil {
+		return err
+	}
+	if _, _, err := sess.ReadBuff(); err != nil {
+		return err
+	}
+	sess.Set("format", format)
+	if mode != "login" {
+		// transition back
+		if _, err := sess.WriteBuff("configure"); err != nil {
+			return err
+		}
+		sess.SetMode(mode)
+		if _, _, err := sess.ReadBuff(); err != nil {
+			sess.SetMode("login")
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,557 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sky-cloud-tec/netd/cli"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/protocol/pb"
+	"github.com/songtianyi/rrframework/logs"
+	"golang.org/x/crypto/ssh"
+	"google.golang.org/grpc"
+)
+
+const (
+	callTimeout = 10 * time.Second
+	// valuesKey is the session key which plugin values stored with
+	valuesKey = "plugin.values"
+)
+
+// remoteOperator is the netd side cli.Operator of a plugin.
+// Patterns and transitions are cached until plugin restarted or hotfixed, linebreak, start mode
+// and encoding are taken from descriptor once plugin started since they are used on every write,
+// all calls fail when plugin is down, so only requests served by this plugin fail.
+type remoteOperator struct {
+	name  string
+	mu    sync.RWMutex
+	cc    *grpc.ClientConn // nil when plugin down
+	c     OperatorClient
+	hooks map[string]bool
+
+	linebreak string
+	startMode string
+	encoding  string
+
+	prompts     map[string][]*regexp.Regexp
+	transitions map[string][]string
+	modes       []string
+	errs        []*regexp.Regexp
+	excludes    []*regexp.Regexp
+	secrets     []*regexp.Regexp
+}
+
+func newRemoteOperator(name string) *remoteOperator {
+	s := &remoteOperator{name: name}
+	s.reset()
+	return s
+}
+
+// setConn attach grpc conn of a (re)started plugin, nil means plugin down
+func (s *remoteOperator) setConn(cc *grpc.ClientConn, desc *Descriptor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cc != nil {
+		s.cc.Close()
+	}
+	s.cc, s.c = cc, nil
+	if cc != nil {
+		s.c = NewOperatorClient(cc)
+	}
+	if desc != nil {
+		// keep hooks and descriptor when plugin down, so hook calls fail instead of being skipped
+		s.hooks = make(map[string]bool)
+		for _, v := range desc.Hooks {
+			s.hooks[v] = true
+		}
+		s.linebreak, s.startMode, s.encoding = desc.Linebreak, desc.StartMode, desc.Encoding
+	}
+	s.resetLocked()
+}
+
+func (s *remoteOperator) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resetLocked()
+}
+
+func (s *remoteOperator) resetLocked() {
+	s.prompts = make(map[string][]*regexp.Regexp)
+	s.transitions = make(map[string][]string)
+	s.modes = nil
+	s.errs = nil
+	s.excludes = nil
+	s.secrets = nil
+}
+
+func (s *remoteOperator) client() (OperatorClient, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.c == nil {
+		return nil, fmt.Errorf("plugin %s not running", s.name)
+	}
+	return s.c, nil
+}
+
+func (s *remoteOperator) hasHook(h string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hooks[h]
+}
+
+// call run rpc name of plugin by fn, error logged
+func (s *remoteOperator) call(name string, fn func(context.Context, OperatorClient) error) error {
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	if err := fn(ctx, c); err != nil {
+		logs.Error("[ plugin", s.name, "]", name, "error:", err)
+		return err
+	}
+	return nil
+}
+
+// strings call rpc name which returns strings
+func (s *remoteOperator) strings(name string, fn func(context.Context, OperatorClient) (*Strings, error)) ([]string, error) {
+	var out *Strings
+	err := s.call(name, func(ctx context.Context, c OperatorClient) (err error) {
+		out, err = fn(ctx, c)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.list(), nil
+}
+
+// text call rpc name which returns text, empty if failed
+func (s *remoteOperator) text(name string, fn func(context.Context, OperatorClient) (*Text, error)) (string, error) {
+	var out *Text
+	err := s.call(name, func(ctx context.Context, c OperatorClient) (err error) {
+		out, err = fn(ctx, c)
+		return
+	})
+	if err != nil {
+		return "", err
+	}
+	return out.Value, nil
+}
+
+func (s *remoteOperator) GetTransitions(c, t string) []string {
+	k := c + "->" + t
+	s.mu.RLock()
+	v, ok := s.transitions[k]
+	s.mu.RUnlock()
+	if ok {
+		return v
+	}
+	v, err := s.strings("GetTransitions", func(ctx context.Context, oc OperatorClient) (*Strings, error) {
+		return oc.GetTransitions(ctx, &TransitionRequest{From: c, To: t})
+	})
+	if err != nil {
+		return nil
+	}
+	s.mu.Lock()
+	s.transitions[k] = v
+	s.mu.Unlock()
+	return v
+}
+
+func (s *remoteOperator) GetPrompts(m string) []*regexp.Regexp {
+	s.mu.RLock()
+	v, ok := s.prompts[m]
+	s.mu.RUnlock()
+	if ok {
+		return v
+	}
+	ss, err := s.strings("GetPrompts", func(ctx context.Context, c OperatorClient) (*Strings, error) {
+		return c.GetPrompts(ctx, &ModeRequest{Mode: m})
+	})
+	if err != nil {
+		return nil
+	}
+	regs, err := toRegexps(ss)
+	if err != nil {
+		logs.Error("[ plugin", s.name, "]", "bad prompt of mode", m, err)
+		return nil
+	}
+	s.mu.Lock()
+	s.prompts[m] = regs
+	s.mu.Unlock()
+	return regs
+}
+
+func (s *remoteOperator) GetModes() []string {
+	s.mu.RLock()
+	v := s.modes
+	s.mu.RUnlock()
+	if v != nil {
+		// copy, callers may sort it
+		return append([]string{}, v...)
+	}
+	v, err := s.strings("GetModes", func(ctx context.Context, c OperatorClient) (*Strings, error) {
+		return c.GetModes(ctx, &Empty{})
+	})
+	if err != nil {
+		return nil
+	}
+	if v == nil {
+		v = []string{}
+	}
+	s.mu.Lock()
+	s.modes = v
+	s.mu.Unlock()
+	return append([]string{}, v...)
+}
+
+func (s *remoteOperator) SetPrompts(m string, regs []*regexp.Regexp) {
+	s.call("SetPrompts", func(ctx context.Context, c OperatorClient) error {
+		_, err := c.SetPrompts(ctx, &PatternsRequest{Mode: m, Patterns: fromRegexps(regs)})
+		return err
+	})
+	s.reset()
+}
+
+func (s *remoteOperator) GetErrPatterns() []*regexp.Regexp {
+	s.mu.RLock()
+	v := s.errs
+	s.mu.RUnlock()
+	if v != nil {
+		return v
+	}
+	ss, err := s.strings("GetErrPatterns", func(ctx context.Context, c OperatorClient) (*Strings, error) {
+		return c.GetErrPatterns(ctx, &Empty{})
+	})
+	if err != nil {
+		return nil
+	}
+	regs, err := toRegexps(ss)
+	if err != nil {
+		logs.Error("[ plugin", s.name, "]", "bad err pattern", err)
+		return nil
+	}
+	if regs == nil {
+		regs = []*regexp.Regexp{}
+	}
+	s.mu.Lock()
+	s.errs = regs
+	s.mu.Unlock()
+	return regs
+}
+
+func (s *remoteOperator) SetErrPatterns(regs []*regexp.Regexp) {
+	s.call("SetErrPatterns", func(ctx context.Context, c OperatorClient) error {
+		_, err := c.SetErrPatterns(ctx, &PatternsRequest{Patterns: fromRegexps(regs)})
+		return err
+	})
+	s.reset()
+}
+
+func (s *remoteOperator) GetLinebreak() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.linebreak
+}
+
+func (s *remoteOperator) GetStartMode() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.startMode
+}
+
+func (s *remoteOperator) RegisterMode(req *protocol.CliRequest) error {
+	err := s.call("RegisterMode", func(ctx context.Context, c OperatorClient) error {
+		_, err := c.RegisterMode(ctx, pb.FromCliRequest(req))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// modes and transitions may have been changed
+	s.reset()
+	return nil
+}
+
+func (s *remoteOperator) GetEncoding() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.encoding
+}
+
+func (s *remoteOperator) GetExcludes() []*regexp.Regexp {
+	s.mu.RLock()
+	v := s.excludes
+	s.mu.RUnlock()
+	if v != nil {
+		return v
+	}
+	ss, err := s.strings("GetExcludes", func(ctx context.Context, c OperatorClient) (*Strings, error) {
+		return c.GetExcludes(ctx, &Empty{})
+	})
+	if err != nil {
+		return nil
+	}
+	regs, err := toRegexps(ss)
+	if err != nil {
+		logs.Error("[ plugin", s.name, "]", "bad exclude pattern", err)
+		return nil
+	}
+	if regs == nil {
+		// cache no excludes as well
+		regs = []*regexp.Regexp{}
+	}
+	s.mu.Lock()
+	s.excludes = regs
+	s.mu.Unlock()
+	return regs
+}
+
+func (s *remoteOperator) GetSSHInitializer() cli.SSHInitializer {
+	return func(c *ssh.Client, req *protocol.CliRequest) (io.Reader, io.WriteCloser, *ssh.Session, error) {
+		var opts *SSHOptions
+		err := s.call("GetSSHOptions", func(ctx context.Context, oc OperatorClient) (err error) {
+			opts, err = oc.GetSSHOptions(ctx, &Empty{})
+			return
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		session, err := c.NewSession()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("new ssh session failed, %s", err)
+		}
+		// get stdout and stdin channel
+		r, err := session.StdoutPipe()
+		if err != nil {
+			session.Close()
+			return nil, nil, nil, fmt.Errorf("create stdout pipe failed, %s", err)
+		}
+		w, err := session.StdinPipe()
+		if err != nil {
+			session.Close()
+			return nil, nil, nil, fmt.Errorf("create stdin pipe failed, %s", err)
+		}
+		if opts.Pty {
+			term := opts.Term
+			if term == "" {
+				term = "vt100"
+			}
+			echo := uint32(0)
+			if opts.Echo {
+				echo = 1
+			}
+			if err := session.RequestPty(term, int(opts.Height), int(opts.Width), ssh.TerminalModes{ssh.ECHO: echo}); err != nil {
+				session.Close()
+				return nil, nil, nil, fmt.Errorf("request pty failed, %s", err)
+			}
+		}
+		if err := session.Shell(); err != nil {
+			session.Close()
+			return nil, nil, nil, fmt.Errorf("create shell failed, %s", err)
+		}
+		return r, w, session, nil
+	}
+}
+
+// PostLogin run plugin post login hook, disable pager as conn does if plugin has no such hook
+func (s *remoteOperator) PostLogin(sess cli.Session, prompt string) error {
+	if !s.hasHook(hookPostLogin) {
+		return s.DisablePager(sess)
+	}
+	return s.hook(hookPostLogin, sess, prompt)
+}
+
+// DisablePager run plugin pager hook
+func (s *remoteOperator) DisablePager(sess cli.Session) error {
+	if !s.hasHook(hookDisablePager) {
+		return nil
+	}
+	return s.hook(hookDisablePager, sess, "")
+}
+
+// PreExec run plugin pre exec hook
+func (s *remoteOperator) PreExec(sess cli.Session) error {
+	if !s.hasHook(hookPreExec) {
+		return nil
+	}
+	return s.hook(hookPreExec, sess, "")
+}
+
+// CleanOutput run plugin output cleaner, output returned as it is when plugin fails
+func (s *remoteOperator) CleanOutput(x string) string {
+	if !s.hasHook(hookCleanOutput) {
+		return x
+	}
+	out, err := s.text("CleanOutput", func(ctx context.Context, c OperatorClient) (*Text, error) {
+		return c.CleanOutput(ctx, &Text{Value: x})
+	})
+	if err != nil {
+		return x
+	}
+	return out
+}
+
+// GetInterrupts return plugin interrupt sequences, defaults used when plugin fails
+func (s *remoteOperator) GetInterrupts() []string {
+	if !s.hasHook(hookInterrupts) {
+		return cli.DefaultInterrupts
+	}
+	v, err := s.strings("GetInterrupts", func(ctx context.Context, c OperatorClient) (*Strings, error) {
+		return c.GetInterrupts(ctx, &Empty{})
+	})
+	if err != nil {
+		return cli.DefaultInterrupts
+	}
+	return v
+}
+
+// GetResyncs return plugin resync sequences, defaults used when plugin fails
+func (s *remoteOperator) GetResyncs() []string {
+	if !s.hasHook(hookResyncs) {
+		return cli.DefaultResyncs
+	}
+	v, err := s.strings("GetResyncs", func(ctx context.Context, c OperatorClient) (*Strings, error) {
+		return c.GetResyncs(ctx, &Empty{})
+	})
+	if err != nil {
+		return cli.DefaultResyncs
+	}
+	return v
+}
+
+// GetTxCommands return plugin transaction commands, empty mode means not supported
+func (s *remoteOperator) GetTxCommands() *cli.TxCommands {
+	if !s.hasHook(hookTx) {
+		return new(cli.TxCommands)
+	}
+	var out *TxCommands
+	s.call("GetTxCommands", func(ctx context.Context, c OperatorClient) (err error) {
+		out, err = c.GetTxCommands(ctx, &Empty{})
+		return
+	})
+	return toTxCommands(out)
+}
+
+// GetBackups return plugin config backups, none if plugin fails
+func (s *remoteOperator) GetBackups() []*cli.Backup {
+	if !s.hasHook(hookBackups) {
+		return nil
+	}
+	var out *Backups
+	s.call("GetBackups", func(ctx context.Context, c OperatorClient) (err error) {
+		out, err = c.GetBackups(ctx, &Empty{})
+		return
+	})
+	return toBackups(out)
+}
+
+// GetPlatform return plugin ntc-templates platform, empty if not declared
+func (s *remoteOperator) GetPlatform() string {
+	if !s.hasHook(hookPlatform) {
+		return ""
+	}
+	v, _ := s.text("GetPlatform", func(ctx context.Context, c OperatorClient) (*Text, error) {
+		return c.GetPlatform(ctx, &Empty{})
+	})
+	return v
+}
+
+// GetSecretPatterns return plugin secret patterns, cached since every output chunk is redacted
+func (s *remoteOperator) GetSecretPatterns() []*regexp.Regexp {
+	if !s.hasHook(hookSecrets) {
+		return nil
+	}
+	s.mu.RLock()
+	v := s.secrets
+	s.mu.RUnlock()
+	if v != nil {
+		return v
+	}
+	ss, err := s.strings("GetSecretPatterns", func(ctx context.Context, c OperatorClient) (*Strings, error) {
+		return c.GetSecretPatterns(ctx, &Empty{})
+	})
+	if err != nil {
+		return nil
+	}
+	regs, err := toRegexps(ss)
+	if err != nil {
+		logs.Error("[ plugin", s.name, "]", "bad secret pattern", err)
+		return nil
+	}
+	if regs == nil {
+		regs = []*regexp.Regexp{}
+	}
+	s.mu.Lock()
+	s.secrets = regs
+	s.mu.Unlock()
+	return regs
+}
+
+// hook run plugin hook, serve session ops plugin sent until it's done
+func (s *remoteOperator) hook(name string, sess cli.Session, prompt string) error {
+	c, err := s.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := c.Hook(ctx)
+	if err != nil {
+		return fmt.Errorf("plugin %s hook %s error: %s", s.name, name, err)
+	}
+	values, _ := sess.Get(valuesKey)
+	vs, _ := values.(map[string]string)
+	if err := stream.Send(&Frame{Hook: name, Request: pb.FromCliRequest(sess.Request()), Mode: sess.Mode(), Prompt: prompt, Values: vs}); err != nil {
+		return fmt.Errorf("plugin %s hook %s error: %s", s.name, name, err)
+	}
+	for {
+		f, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("plugin %s hook %s error: %s", s.name, name, err)
+		}
+		if f.Mode != "" {
+			sess.SetMode(f.Mode)
+		}
+		res := &Frame{}
+		switch f.Op {
+		case opWrite:
+			n, err := sess.WriteBuff(f.Cmd)
+			res.N, res.Err = int32(n), errString(err)
+		case opRead:
+			out, p, err := sess.ReadBuff()
+			res.Output, res.Prompt, res.Err = out, p, errString(err)
+		case opDone:
+			if f.Values != nil {
+				sess.Set(valuesKey, f.Values)
+			}
+			return toError(f.Err)
+		default:
+			return fmt.Errorf("plugin %s hook %s sent unknown op %s", s.name, name, f.Op)
+		}
+		if err := stream.Send(res); err != nil {
+			return fmt.Errorf("plugin %s hook %s error: %s", s.name, name, err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Command example is an operator plugin for generic linux hosts.
+// Build it into netd plugin dir, then run netd with --plugin-dir.
+package main
+
+import (
+	"log"
+	"regexp"
+
+	"github.com/sky-cloud-tec/netd/cli/plugin"
+	"github.com/sky-cloud-tec/netd/protocol"
+)
+
+type opShell struct {
+	prompts map[string][]*regexp.Regexp
+	errs    []*regexp.Regexp
+}
+
+func (s *opShell) GetTransitions(c, t string) []string {
+	return nil
+}
+
+func (s *opShell) GetPrompts(k string) []*regexp.Regexp {
+	if v, ok := s.prompts[k]; ok {
+		return v
+	}
+	return nil
+}
+
+func (s *opShell) SetPrompts(k string, regs []*regexp.Regexp) {
+	s.prompts[k] = regs
+}
+
+func (s *opShell) GetErrPatterns() []*regexp.Regexp {
+	return s.errs
+}
+
+func (s *opShell) SetErrPatterns(regs []*regexp.Regexp) {
+	s.errs = regs
+}
+
+func (s *opShell) GetSSHOptions() *plugin.SSHOptions {
+	return &plugin.SSHOptions{}
+}
+
+func (s *opShell) GetLinebreak() string {
+	return "\n"
+}
+
+func (s *opShell) GetStartMode() string {
+	return "login"
+}
+
+func (s *opShell) RegisterMode(req *protocol.CliRequest) error {
+	return nil
+}
+
+func (s *opShell) GetEncoding() string {
+	return ""
+}
+
+func (s *opShell) GetExcludes() []*regexp.Regexp {
+	return nil
+}
+
+func main() {
+	op := &opShell{
+		prompts: map[string][]*regexp.Regexp{
+			"login": {regexp.MustCompile(`[[:alnum:]_-]+@[[:alnum:]._-]+:.*[#$] $`)},
+		},
+		errs: []*regexp.Regexp{
+			regexp.MustCompile("command not found"),
+		},
+	}
+	if err := plugin.Serve("example", `(?i)example\.shell\..*`, op); err != nil {
+		log.Fatal(err)
+	}
+}
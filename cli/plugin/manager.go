@@ -0,0 +1,158 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/sky-cloud-tec/netd/cli"
+	"github.com/songtianyi/rrframework/logs"
+	"google.golang.org/grpc"
+)
+
+const (
+	dialTimeout = 10 * time.Second
+	maxBackoff  = time.Minute
+)
+
+// Load launch every executable in dir as plugin and register them to cli.OperatorManagerInstance.
+// A plugin which fails to start is skipped, crashed plugin is restarted with backoff.
+func Load(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	sockDir, err := ioutil.TempDir("", "netd-plugins-"+strconv.Itoa(os.Getpid()))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() || f.Mode()&0111 == 0 {
+			continue
+		}
+		p := &process{
+			path: filepath.Join(dir, f.Name()),
+			sock: filepath.Join(sockDir, f.Name()+".sock"),
+			op:   newRemoteOperator(f.Name()),
+		}
+		desc, err := p.start()
+		if err != nil {
+			logs.Error("[ plugin", f.Name(), "]", "start error:", err)
+			continue
+		}
+		if cli.OperatorManagerInstance.Registered(desc.Pattern) {
+			logs.Error("[ plugin", f.Name(), "]", "pattern", desc.Pattern, "registered, skip")
+			p.kill()
+			continue
+		}
+		cli.OperatorManagerInstance.Register(desc.Pattern, p.op)
+		go p.supervise()
+	}
+	return nil
+}
+
+// process is a running plugin
+type process struct {
+	path string
+	sock string
+	op   *remoteOperator
+	cmd  *exec.Cmd
+}
+
+// start launch plugin, connect to it and attach conn to operator
+func (p *process) start() (*Descriptor, error) {
+	os.Remove(p.sock)
+	cmd := exec.Command(p.path)
+	cmd.Env = append(os.Environ(), SocketEnv+"="+p.sock)
+	cmd.Stdout = &logWriter{name: p.op.name}
+	cmd.Stderr = &logWriter{name: p.op.name}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p.cmd = cmd
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	cc, err := grpc.DialContext(ctx, p.sock,
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", addr)
+		}),
+	)
+	if err != nil {
+		p.kill()
+		return nil, fmt.Errorf("connect plugin error: %s", err)
+	}
+	ctx1, cancel1 := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel1()
+	desc, err := NewOperatorClient(cc).Describe(ctx1, &Empty{})
+	if err != nil {
+		cc.Close()
+		p.kill()
+		return nil, fmt.Errorf("describe plugin error: %s", err)
+	}
+	logs.Info("[ plugin", p.op.name, "]", "started", desc.Name, desc.Pattern, desc.Hooks)
+	p.op.setConn(cc, desc)
+	return desc, nil
+}
+
+// supervise wait plugin exit and restart it
+func (p *process) supervise() {
+	backoff := time.Second
+	for {
+		err := p.cmd.Wait()
+		logs.Critical("[ plugin", p.op.name, "]", "exited:", err)
+		// fail requests of this plugin until it's back
+		p.op.setConn(nil, nil)
+		for {
+			time.Sleep(backoff)
+			if _, err := p.start(); err != nil {
+				logs.Error("[ plugin", p.op.name, "]", "restart error:", err)
+				if backoff *= 2; backoff > maxBackoff {
+					backoff = maxBackoff
+				}
+				continue
+			}
+			backoff = time.Second
+			break
+		}
+	}
+}
+
+func (p *process) kill() {
+	if p.cmd != nil && p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+		p.cmd.Wait()
+	}
+}
+
+// logWriter write plugin output to netd log
+type logWriter struct {
+	name string
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	logs.Info("[ plugin", w.name, "]", string(b))
+	return len(b), nil
+}
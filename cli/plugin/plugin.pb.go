@@ -0,0 +1,1530 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: cli/plugin/plugin.proto
+
+package plugin
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	pb "github.com/sky-cloud-tec/netd/protocol/pb"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Empty struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Empty) Reset()         { *m = Empty{} }
+func (m *Empty) String() string { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()    {}
+func (*Empty) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{0}
+}
+
+func (m *Empty) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Empty.Unmarshal(m, b)
+}
+func (m *Empty) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Empty.Marshal(b, m, deterministic)
+}
+func (m *Empty) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Empty.Merge(m, src)
+}
+func (m *Empty) XXX_Size() int {
+	return xxx_messageInfo_Empty.Size(m)
+}
+func (m *Empty) XXX_DiscardUnknown() {
+	xxx_messageInfo_Empty.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Empty proto.InternalMessageInfo
+
+// Descriptor describes plugin operator
+type Descriptor struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Pattern              string   `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Hooks                []string `protobuf:"bytes,3,rep,name=hooks,proto3" json:"hooks,omitempty"`
+	Linebreak            string   `protobuf:"bytes,4,opt,name=linebreak,proto3" json:"linebreak,omitempty"`
+	StartMode            string   `protobuf:"bytes,5,opt,name=start_mode,json=startMode,proto3" json:"start_mode,omitempty"`
+	Encoding             string   `protobuf:"bytes,6,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Descriptor) Reset()         { *m = Descriptor{} }
+func (m *Descriptor) String() string { return proto.CompactTextString(m) }
+func (*Descriptor) ProtoMessage()    {}
+func (*Descriptor) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{1}
+}
+
+func (m *Descriptor) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Descriptor.Unmarshal(m, b)
+}
+func (m *Descriptor) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Descriptor.Marshal(b, m, deterministic)
+}
+func (m *Descriptor) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Descriptor.Merge(m, src)
+}
+func (m *Descriptor) XXX_Size() int {
+	return xxx_messageInfo_Descriptor.Size(m)
+}
+func (m *Descriptor) XXX_DiscardUnknown() {
+	xxx_messageInfo_Descriptor.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Descriptor proto.InternalMessageInfo
+
+func (m *Descriptor) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Descriptor) GetPattern() string {
+	if m != nil {
+		return m.Pattern
+	}
+	return ""
+}
+
+func (m *Descriptor) GetHooks() []string {
+	if m != nil {
+		return m.Hooks
+	}
+	return nil
+}
+
+func (m *Descriptor) GetLinebreak() string {
+	if m != nil {
+		return m.Linebreak
+	}
+	return ""
+}
+
+func (m *Descriptor) GetStartMode() string {
+	if m != nil {
+		return m.StartMode
+	}
+	return ""
+}
+
+func (m *Descriptor) GetEncoding() string {
+	if m != nil {
+		return m.Encoding
+	}
+	return ""
+}
+
+// ModeRequest carries a cli mode
+type ModeRequest struct {
+	Mode                 string   `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ModeRequest) Reset()         { *m = ModeRequest{} }
+func (m *ModeRequest) String() string { return proto.CompactTextString(m) }
+func (*ModeRequest) ProtoMessage()    {}
+func (*ModeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{2}
+}
+
+func (m *ModeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ModeRequest.Unmarshal(m, b)
+}
+func (m *ModeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ModeRequest.Marshal(b, m, deterministic)
+}
+func (m *ModeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ModeRequest.Merge(m, src)
+}
+func (m *ModeRequest) XXX_Size() int {
+	return xxx_messageInfo_ModeRequest.Size(m)
+}
+func (m *ModeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ModeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ModeRequest proto.InternalMessageInfo
+
+func (m *ModeRequest) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+// TransitionRequest carries current and target mode
+type TransitionRequest struct {
+	From                 string   `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To                   string   `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransitionRequest) Reset()         { *m = TransitionRequest{} }
+func (m *TransitionRequest) String() string { return proto.CompactTextString(m) }
+func (*TransitionRequest) ProtoMessage()    {}
+func (*TransitionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{3}
+}
+
+func (m *TransitionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransitionRequest.Unmarshal(m, b)
+}
+func (m *TransitionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransitionRequest.Marshal(b, m, deterministic)
+}
+func (m *TransitionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransitionRequest.Merge(m, src)
+}
+func (m *TransitionRequest) XXX_Size() int {
+	return xxx_messageInfo_TransitionRequest.Size(m)
+}
+func (m *TransitionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransitionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransitionRequest proto.InternalMessageInfo
+
+func (m *TransitionRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *TransitionRequest) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+// Strings is a string list, nil and empty list are different, see cli.Operator GetTransitions
+type Strings struct {
+	Values               []string `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	Nil                  bool     `protobuf:"varint,2,opt,name=nil,proto3" json:"nil,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Strings) Reset()         { *m = Strings{} }
+func (m *Strings) String() string { return proto.CompactTextString(m) }
+func (*Strings) ProtoMessage()    {}
+func (*Strings) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{4}
+}
+
+func (m *Strings) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Strings.Unmarshal(m, b)
+}
+func (m *Strings) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Strings.Marshal(b, m, deterministic)
+}
+func (m *Strings) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Strings.Merge(m, src)
+}
+func (m *Strings) XXX_Size() int {
+	return xxx_messageInfo_Strings.Size(m)
+}
+func (m *Strings) XXX_DiscardUnknown() {
+	xxx_messageInfo_Strings.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Strings proto.InternalMessageInfo
+
+func (m *Strings) GetValues() []string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+func (m *Strings) GetNil() bool {
+	if m != nil {
+		return m.Nil
+	}
+	return false
+}
+
+// Backup is cli.Backup
+type Backup struct {
+	Format               string   `protobuf:"bytes,1,opt,name=format,proto3" json:"format,omitempty"`
+	Mode                 string   `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`
+	Commands             []string `protobuf:"bytes,3,rep,name=commands,proto3" json:"commands,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Backup) Reset()         { *m = Backup{} }
+func (m *Backup) String() string { return proto.CompactTextString(m) }
+func (*Backup) ProtoMessage()    {}
+func (*Backup) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{5}
+}
+
+func (m *Backup) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Backup.Unmarshal(m, b)
+}
+func (m *Backup) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Backup.Marshal(b, m, deterministic)
+}
+func (m *Backup) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Backup.Merge(m, src)
+}
+func (m *Backup) XXX_Size() int {
+	return xxx_messageInfo_Backup.Size(m)
+}
+func (m *Backup) XXX_DiscardUnknown() {
+	xxx_messageInfo_Backup.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Backup proto.InternalMessageInfo
+
+func (m *Backup) GetFormat() string {
+	if m != nil {
+		return m.Format
+	}
+	return ""
+}
+
+func (m *Backup) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+func (m *Backup) GetCommands() []string {
+	if m != nil {
+		return m.Commands
+	}
+	return nil
+}
+
+// Backups carries config backups
+type Backups struct {
+	Values               []*Backup `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *Backups) Reset()         { *m = Backups{} }
+func (m *Backups) String() string { return proto.CompactTextString(m) }
+func (*Backups) ProtoMessage()    {}
+func (*Backups) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{6}
+}
+
+func (m *Backups) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Backups.Unmarshal(m, b)
+}
+func (m *Backups) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Backups.Marshal(b, m, deterministic)
+}
+func (m *Backups) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Backups.Merge(m, src)
+}
+func (m *Backups) XXX_Size() int {
+	return xxx_messageInfo_Backups.Size(m)
+}
+func (m *Backups) XXX_DiscardUnknown() {
+	xxx_messageInfo_Backups.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Backups proto.InternalMessageInfo
+
+func (m *Backups) GetValues() []*Backup {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+// PatternsRequest carries patterns of mode
+type PatternsRequest struct {
+	Mode                 string   `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	Patterns             []string `protobuf:"bytes,2,rep,name=patterns,proto3" json:"patterns,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PatternsRequest) Reset()         { *m = PatternsRequest{} }
+func (m *PatternsRequest) String() string { return proto.CompactTextString(m) }
+func (*PatternsRequest) ProtoMessage()    {}
+func (*PatternsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{7}
+}
+
+func (m *PatternsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PatternsRequest.Unmarshal(m, b)
+}
+func (m *PatternsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PatternsRequest.Marshal(b, m, deterministic)
+}
+func (m *PatternsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PatternsRequest.Merge(m, src)
+}
+func (m *PatternsRequest) XXX_Size() int {
+	return xxx_messageInfo_PatternsRequest.Size(m)
+}
+func (m *PatternsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PatternsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PatternsRequest proto.InternalMessageInfo
+
+func (m *PatternsRequest) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+func (m *PatternsRequest) GetPatterns() []string {
+	if m != nil {
+		return m.Patterns
+	}
+	return nil
+}
+
+// Text message
+type Text struct {
+	Value                string   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Text) Reset()         { *m = Text{} }
+func (m *Text) String() string { return proto.CompactTextString(m) }
+func (*Text) ProtoMessage()    {}
+func (*Text) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{8}
+}
+
+func (m *Text) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Text.Unmarshal(m, b)
+}
+func (m *Text) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Text.Marshal(b, m, deterministic)
+}
+func (m *Text) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Text.Merge(m, src)
+}
+func (m *Text) XXX_Size() int {
+	return xxx_messageInfo_Text.Size(m)
+}
+func (m *Text) XXX_DiscardUnknown() {
+	xxx_messageInfo_Text.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Text proto.InternalMessageInfo
+
+func (m *Text) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+// SSHOptions tells netd how to open ssh session for plugin operator
+type SSHOptions struct {
+	Pty                  bool     `protobuf:"varint,1,opt,name=pty,proto3" json:"pty,omitempty"`
+	Term                 string   `protobuf:"bytes,2,opt,name=term,proto3" json:"term,omitempty"`
+	Height               int32    `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Width                int32    `protobuf:"varint,4,opt,name=width,proto3" json:"width,omitempty"`
+	Echo                 bool     `protobuf:"varint,5,opt,name=echo,proto3" json:"echo,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SSHOptions) Reset()         { *m = SSHOptions{} }
+func (m *SSHOptions) String() string { return proto.CompactTextString(m) }
+func (*SSHOptions) ProtoMessage()    {}
+func (*SSHOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{9}
+}
+
+func (m *SSHOptions) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SSHOptions.Unmarshal(m, b)
+}
+func (m *SSHOptions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SSHOptions.Marshal(b, m, deterministic)
+}
+func (m *SSHOptions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SSHOptions.Merge(m, src)
+}
+func (m *SSHOptions) XXX_Size() int {
+	return xxx_messageInfo_SSHOptions.Size(m)
+}
+func (m *SSHOptions) XXX_DiscardUnknown() {
+	xxx_messageInfo_SSHOptions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SSHOptions proto.InternalMessageInfo
+
+func (m *SSHOptions) GetPty() bool {
+	if m != nil {
+		return m.Pty
+	}
+	return false
+}
+
+func (m *SSHOptions) GetTerm() string {
+	if m != nil {
+		return m.Term
+	}
+	return ""
+}
+
+func (m *SSHOptions) GetHeight() int32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *SSHOptions) GetWidth() int32 {
+	if m != nil {
+		return m.Width
+	}
+	return 0
+}
+
+func (m *SSHOptions) GetEcho() bool {
+	if m != nil {
+		return m.Echo
+	}
+	return false
+}
+
+// TxCommands is cli.TxCommands
+type TxCommands struct {
+	Mode                 string   `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	Diff                 string   `protobuf:"bytes,2,opt,name=diff,proto3" json:"diff,omitempty"`
+	Commit               string   `protobuf:"bytes,3,opt,name=commit,proto3" json:"commit,omitempty"`
+	CommitConfirmed      string   `protobuf:"bytes,4,opt,name=commit_confirmed,json=commitConfirmed,proto3" json:"commit_confirmed,omitempty"`
+	Confirm              string   `protobuf:"bytes,5,opt,name=confirm,proto3" json:"confirm,omitempty"`
+	Discard              string   `protobuf:"bytes,6,opt,name=discard,proto3" json:"discard,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TxCommands) Reset()         { *m = TxCommands{} }
+func (m *TxCommands) String() string { return proto.CompactTextString(m) }
+func (*TxCommands) ProtoMessage()    {}
+func (*TxCommands) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{10}
+}
+
+func (m *TxCommands) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TxCommands.Unmarshal(m, b)
+}
+func (m *TxCommands) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TxCommands.Marshal(b, m, deterministic)
+}
+func (m *TxCommands) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TxCommands.Merge(m, src)
+}
+func (m *TxCommands) XXX_Size() int {
+	return xxx_messageInfo_TxCommands.Size(m)
+}
+func (m *TxCommands) XXX_DiscardUnknown() {
+	xxx_messageInfo_TxCommands.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TxCommands proto.InternalMessageInfo
+
+func (m *TxCommands) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+func (m *TxCommands) GetDiff() string {
+	if m != nil {
+		return m.Diff
+	}
+	return ""
+}
+
+func (m *TxCommands) GetCommit() string {
+	if m != nil {
+		return m.Commit
+	}
+	return ""
+}
+
+func (m *TxCommands) GetCommitConfirmed() string {
+	if m != nil {
+		return m.CommitConfirmed
+	}
+	return ""
+}
+
+func (m *TxCommands) GetConfirm() string {
+	if m != nil {
+		return m.Confirm
+	}
+	return ""
+}
+
+func (m *TxCommands) GetDiscard() string {
+	if m != nil {
+		return m.Discard
+	}
+	return ""
+}
+
+// Frame is exchanged on hook stream.
+// netd sends the first frame with hook set, plugin sends session ops back,
+// netd replies every op with result, until plugin sends op done.
+type Frame struct {
+	Hook                 string            `protobuf:"bytes,1,opt,name=hook,proto3" json:"hook,omitempty"`
+	Request              *pb.CliRequest    `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
+	Op                   string            `protobuf:"bytes,3,opt,name=op,proto3" json:"op,omitempty"`
+	Cmd                  string            `protobuf:"bytes,4,opt,name=cmd,proto3" json:"cmd,omitempty"`
+	Mode                 string            `protobuf:"bytes,5,opt,name=mode,proto3" json:"mode,omitempty"`
+	Prompt               string            `protobuf:"bytes,6,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	Output               string            `protobuf:"bytes,7,opt,name=output,proto3" json:"output,omitempty"`
+	N                    int32             `protobuf:"varint,8,opt,name=n,proto3" json:"n,omitempty"`
+	Values               map[string]string `protobuf:"bytes,9,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Err                  string            `protobuf:"bytes,10,opt,name=err,proto3" json:"err,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *Frame) Reset()         { *m = Frame{} }
+func (m *Frame) String() string { return proto.CompactTextString(m) }
+func (*Frame) ProtoMessage()    {}
+func (*Frame) Descriptor() ([]byte, []int) {
+	return fileDescriptor_06abeba3ccec0c4a, []int{11}
+}
+
+func (m *Frame) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Frame.Unmarshal(m, b)
+}
+func (m *Frame) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Frame.Marshal(b, m, deterministic)
+}
+func (m *Frame) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Frame.Merge(m, src)
+}
+func (m *Frame) XXX_Size() int {
+	return xxx_messageInfo_Frame.Size(m)
+}
+func (m *Frame) XXX_DiscardUnknown() {
+	xxx_messageInfo_Frame.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Frame proto.InternalMessageInfo
+
+func (m *Frame) GetHook() string {
+	if m != nil {
+		return m.Hook
+	}
+	return ""
+}
+
+func (m *Frame) GetRequest() *pb.CliRequest {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (m *Frame) GetOp() string {
+	if m != nil {
+		return m.Op
+	}
+	return ""
+}
+
+func (m *Frame) GetCmd() string {
+	if m != nil {
+		return m.Cmd
+	}
+	return ""
+}
+
+func (m *Frame) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+func (m *Frame) GetPrompt() string {
+	if m != nil {
+		return m.Prompt
+	}
+	return ""
+}
+
+func (m *Frame) GetOutput() string {
+	if m != nil {
+		return m.Output
+	}
+	return ""
+}
+
+func (m *Frame) GetN() int32 {
+	if m != nil {
+		return m.N
+	}
+	return 0
+}
+
+func (m *Frame) GetValues() map[string]string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+func (m *Frame) GetErr() string {
+	if m != nil {
+		return m.Err
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*Empty)(nil), "netd.plugin.Empty")
+	proto.RegisterType((*Descriptor)(nil), "netd.plugin.Descriptor")
+	proto.RegisterType((*ModeRequest)(nil), "netd.plugin.ModeRequest")
+	proto.RegisterType((*TransitionRequest)(nil), "netd.plugin.TransitionRequest")
+	proto.RegisterType((*Strings)(nil), "netd.plugin.Strings")
+	proto.RegisterType((*Backup)(nil), "netd.plugin.Backup")
+	proto.RegisterType((*Backups)(nil), "netd.plugin.Backups")
+	proto.RegisterType((*PatternsRequest)(nil), "netd.plugin.PatternsRequest")
+	proto.RegisterType((*Text)(nil), "netd.plugin.Text")
+	proto.RegisterType((*SSHOptions)(nil), "netd.plugin.SSHOptions")
+	proto.RegisterType((*TxCommands)(nil), "netd.plugin.TxCommands")
+	proto.RegisterType((*Frame)(nil), "netd.plugin.Frame")
+	proto.RegisterMapType((map[string]string)(nil), "netd.plugin.Frame.ValuesEntry")
+}
+
+func init() { proto.RegisterFile("cli/plugin/plugin.proto", fileDescriptor_06abeba3ccec0c4a) }
+
+var fileDescriptor_06abeba3ccec0c4a = []byte{
+	// 927 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0x5f, 0x6f, 0xe3, 0x44,
+	0x10, 0x97, 0xdd, 0xfc, 0x71, 0x26, 0xc7, 0x5d, 0xbb, 0x9c, 0x7a, 0x56, 0x54, 0x50, 0xf1, 0x53,
+	0x01, 0x5d, 0x02, 0x6d, 0xd5, 0x72, 0xc7, 0x81, 0x44, 0x4b, 0xaf, 0xc7, 0x03, 0x6a, 0xe5, 0x54,
+	0x3c, 0xf0, 0x72, 0x72, 0xec, 0x4d, 0xb2, 0x8a, 0xbd, 0x6b, 0xd6, 0x6b, 0x68, 0x3e, 0x12, 0xe2,
+	0x91, 0x2f, 0xc0, 0x47, 0x43, 0xb3, 0xbb, 0x4e, 0x6c, 0x1a, 0xa8, 0xc2, 0x53, 0x66, 0x66, 0x67,
+	0x76, 0x66, 0x7e, 0xf3, 0xdb, 0x89, 0xe1, 0x45, 0x9c, 0xb2, 0x51, 0x9e, 0x96, 0x33, 0xc6, 0xed,
+	0xcf, 0x30, 0x97, 0x42, 0x09, 0xd2, 0xe7, 0x54, 0x25, 0x43, 0x63, 0x1a, 0xec, 0x6b, 0x5b, 0x2c,
+	0xd2, 0x51, 0x3e, 0x19, 0x99, 0x03, 0x34, 0x04, 0x5d, 0x68, 0x5f, 0x65, 0xb9, 0x5a, 0x06, 0xbf,
+	0x3b, 0x00, 0xdf, 0xd3, 0x22, 0x96, 0x2c, 0x57, 0x42, 0x12, 0x02, 0x2d, 0x1e, 0x65, 0xd4, 0x77,
+	0x0e, 0x9d, 0xa3, 0x5e, 0xa8, 0x65, 0xe2, 0x43, 0x37, 0x8f, 0x94, 0xa2, 0x92, 0xfb, 0xae, 0x36,
+	0x57, 0x2a, 0x79, 0x0e, 0xed, 0xb9, 0x10, 0x8b, 0xc2, 0xdf, 0x39, 0xdc, 0x39, 0xea, 0x85, 0x46,
+	0x21, 0x07, 0xd0, 0x4b, 0x19, 0xa7, 0x13, 0x49, 0xa3, 0x85, 0xdf, 0xd2, 0x11, 0x6b, 0x03, 0xf9,
+	0x08, 0xa0, 0x50, 0x91, 0x54, 0xef, 0x33, 0x91, 0x50, 0xbf, 0x6d, 0x8e, 0xb5, 0xe5, 0x47, 0x91,
+	0x50, 0x32, 0x00, 0x8f, 0xf2, 0x58, 0x24, 0x8c, 0xcf, 0xfc, 0x8e, 0x3e, 0x5c, 0xe9, 0xc1, 0x27,
+	0xd0, 0x47, 0x9f, 0x90, 0xfe, 0x52, 0xd2, 0x42, 0x61, 0xad, 0xfa, 0x0e, 0x5b, 0x2b, 0xca, 0xc1,
+	0x39, 0xec, 0xdd, 0xc9, 0x88, 0x17, 0x4c, 0x31, 0xc1, 0x6b, 0x8e, 0x53, 0x29, 0xb2, 0xca, 0x11,
+	0x65, 0xf2, 0x14, 0x5c, 0x25, 0x6c, 0x3f, 0xae, 0x12, 0xc1, 0x09, 0x74, 0xc7, 0x4a, 0x32, 0x3e,
+	0x2b, 0xc8, 0x3e, 0x74, 0x7e, 0x8d, 0xd2, 0x92, 0x16, 0xbe, 0xa3, 0xdb, 0xb2, 0x1a, 0xd9, 0x85,
+	0x1d, 0xce, 0x52, 0x1d, 0xe3, 0x85, 0x28, 0x06, 0xb7, 0xd0, 0xb9, 0x88, 0xe2, 0x45, 0x99, 0x63,
+	0xcc, 0x54, 0xc8, 0x2c, 0x52, 0x36, 0x89, 0xd5, 0x56, 0x35, 0xba, 0xeb, 0x1a, 0xb1, 0xc5, 0x58,
+	0x64, 0x59, 0xc4, 0x93, 0x0a, 0xb8, 0x95, 0x1e, 0x9c, 0x41, 0xd7, 0xdc, 0x58, 0x90, 0xcf, 0x1b,
+	0x65, 0xf4, 0x8f, 0x3f, 0x1c, 0xd6, 0x06, 0x3b, 0x34, 0x5e, 0x55, 0x6d, 0xc1, 0x77, 0xf0, 0xec,
+	0xd6, 0x0c, 0xa5, 0xf8, 0x0f, 0x78, 0x30, 0xb5, 0x9d, 0x5d, 0xe1, 0xbb, 0x26, 0x75, 0xa5, 0x07,
+	0x07, 0xd0, 0xba, 0xa3, 0xf7, 0x0a, 0x87, 0xaa, 0x2f, 0xb5, 0x81, 0x46, 0x09, 0x14, 0xc0, 0x78,
+	0xfc, 0xee, 0x26, 0x47, 0x5c, 0x35, 0x14, 0xb9, 0x5a, 0x6a, 0x0f, 0x2f, 0x44, 0x11, 0xb3, 0x29,
+	0x2a, 0xb3, 0xaa, 0x51, 0x94, 0x11, 0x94, 0x39, 0x65, 0xb3, 0xb9, 0xf2, 0x77, 0x0e, 0x9d, 0xa3,
+	0x76, 0x68, 0x35, 0xcc, 0xf0, 0x1b, 0x4b, 0xd4, 0x5c, 0x93, 0xa3, 0x1d, 0x1a, 0x05, 0x6f, 0xa0,
+	0xf1, 0x5c, 0x68, 0x4a, 0x78, 0xa1, 0x96, 0x83, 0x3f, 0x1c, 0x80, 0xbb, 0xfb, 0x4b, 0x8b, 0xce,
+	0xc6, 0x96, 0x08, 0xb4, 0x12, 0x36, 0x9d, 0x56, 0x89, 0x51, 0xc6, 0xc4, 0x88, 0x28, 0x33, 0x89,
+	0x7b, 0xa1, 0xd5, 0xc8, 0xa7, 0xb0, 0x6b, 0xa4, 0xf7, 0xb1, 0xe0, 0x53, 0x26, 0x33, 0x9a, 0x58,
+	0x82, 0x3e, 0x33, 0xf6, 0xcb, 0xca, 0x8c, 0xa4, 0xb7, 0x3e, 0x96, 0xa3, 0x95, 0x8a, 0x27, 0x09,
+	0x2b, 0xe2, 0x48, 0x26, 0x96, 0xa0, 0x95, 0x1a, 0xfc, 0xe5, 0x42, 0xfb, 0xad, 0xc4, 0x27, 0x43,
+	0xa0, 0x85, 0x6f, 0xa1, 0x2a, 0x14, 0x65, 0xf2, 0x19, 0x74, 0xa5, 0x19, 0x8d, 0xae, 0xb5, 0x7f,
+	0xbc, 0x6b, 0x06, 0x7a, 0x99, 0x32, 0x3b, 0xb2, 0xb0, 0x72, 0x40, 0x76, 0x8a, 0xdc, 0x16, 0xef,
+	0x8a, 0x1c, 0xf1, 0x8e, 0xb3, 0xaa, 0x56, 0x14, 0x57, 0x50, 0xb4, 0x6b, 0x50, 0xec, 0x43, 0x27,
+	0x97, 0x22, 0xcb, 0x95, 0x2d, 0xcc, 0x6a, 0x68, 0x17, 0xa5, 0xca, 0x4b, 0xe5, 0x77, 0x8d, 0xdd,
+	0x68, 0xe4, 0x09, 0x38, 0xdc, 0xf7, 0xf4, 0x0c, 0x1c, 0x4e, 0xce, 0x56, 0x7c, 0xeb, 0x69, 0xbe,
+	0x7d, 0xdc, 0xe0, 0x9b, 0xee, 0x6b, 0xf8, 0x93, 0x76, 0xb8, 0xe2, 0x4a, 0x2e, 0xeb, 0xcf, 0x82,
+	0x4a, 0xe9, 0x83, 0xa9, 0x8d, 0x4a, 0x39, 0x78, 0x05, 0xfd, 0x9a, 0x23, 0x3a, 0x2c, 0xe8, 0xd2,
+	0x62, 0x81, 0xe2, 0x9a, 0x62, 0x6e, 0x8d, 0x62, 0xaf, 0xdd, 0xaf, 0x9c, 0xe3, 0x3f, 0x3d, 0xf0,
+	0x6e, 0x72, 0x2a, 0x23, 0x5c, 0x46, 0xe7, 0xe0, 0x99, 0xd5, 0x34, 0xa1, 0x84, 0x34, 0xaa, 0xd1,
+	0xbb, 0x6b, 0xf0, 0xa2, 0x61, 0xab, 0x6d, 0xb1, 0xb7, 0xf0, 0xf4, 0x9a, 0xaa, 0xf5, 0x22, 0x28,
+	0x48, 0xb3, 0x99, 0x07, 0x2b, 0x62, 0xf0, 0xbc, 0x71, 0x5e, 0x6d, 0x82, 0x37, 0x00, 0xd7, 0x54,
+	0xdd, 0x6a, 0x14, 0x0b, 0xe2, 0x37, 0x7c, 0x6a, 0x9b, 0xe8, 0x5f, 0xa2, 0x4f, 0xc1, 0xbb, 0xa6,
+	0x7a, 0xab, 0x15, 0x1b, 0xcb, 0xdf, 0x1c, 0xf5, 0x2d, 0xc0, 0x78, 0x9d, 0xf3, 0xa0, 0xe1, 0xf3,
+	0x8f, 0x27, 0x3e, 0xd8, 0x70, 0x2b, 0x79, 0xad, 0x7b, 0xbf, 0x92, 0xb2, 0x72, 0xde, 0x22, 0xf7,
+	0x05, 0x3c, 0x1d, 0x37, 0x63, 0xb7, 0xcf, 0xff, 0x06, 0x3e, 0xb8, 0xa6, 0xaa, 0xb6, 0x2b, 0x1e,
+	0x9f, 0x5c, 0xcd, 0xf9, 0x14, 0x9e, 0x84, 0x74, 0xc6, 0x0a, 0x45, 0xa5, 0xfe, 0x3b, 0x78, 0xf0,
+	0x46, 0x36, 0xe6, 0x3c, 0x87, 0x3e, 0xf6, 0x7c, 0x1f, 0xa7, 0xe5, 0x76, 0x60, 0x9f, 0x40, 0xff,
+	0x32, 0xa5, 0x11, 0xbf, 0x31, 0x0f, 0x62, 0xaf, 0xc9, 0x12, 0x7a, 0xaf, 0x06, 0x0f, 0x4d, 0xe4,
+	0x95, 0xee, 0xf0, 0x07, 0xae, 0xa8, 0x94, 0x65, 0xae, 0xb6, 0xc9, 0x77, 0xa6, 0x09, 0x15, 0xd2,
+	0x62, 0xc9, 0xe3, 0x6d, 0xe2, 0x0c, 0xa8, 0xf5, 0x4d, 0xf8, 0x28, 0xa8, 0x35, 0x67, 0x93, 0xb5,
+	0xfa, 0x5f, 0x79, 0x3c, 0x6b, 0xe5, 0x79, 0xaa, 0x61, 0xbd, 0x4d, 0x23, 0x85, 0xff, 0x66, 0x1b,
+	0x03, 0x37, 0xc0, 0xf3, 0x0d, 0xec, 0x21, 0x01, 0x68, 0x2c, 0xa9, 0xfa, 0x1f, 0x1c, 0x3c, 0x86,
+	0xd6, 0x3b, 0x5c, 0x97, 0xe4, 0xe1, 0xfa, 0x19, 0x6c, 0xb0, 0x1d, 0x39, 0x5f, 0x38, 0x17, 0x5f,
+	0xfe, 0x3c, 0x9a, 0x31, 0x35, 0x2f, 0x27, 0xc3, 0x58, 0x64, 0xa3, 0x62, 0xb1, 0x7c, 0x19, 0xa7,
+	0xa2, 0x4c, 0x5e, 0x2a, 0x1a, 0xeb, 0x8f, 0x9e, 0xd1, 0xfa, 0x5b, 0xe9, 0x6b, 0xf3, 0x33, 0xe9,
+	0xe8, 0xef, 0xa0, 0x93, 0xbf, 0x07, 0x00, 0x2b, 0xfd, 0x03, 0x8c, 0x47, 0x09, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// OperatorClient is the client API for Operator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type OperatorClient interface {
+	Describe(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Descriptor, error)
+	GetTransitions(ctx context.Context, in *TransitionRequest, opts ...grpc.CallOption) (*Strings, error)
+	GetPrompts(ctx context.Context, in *ModeRequest, opts ...grpc.CallOption) (*Strings, error)
+	GetModes(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error)
+	SetPrompts(ctx context.Context, in *PatternsRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetErrPatterns(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error)
+	SetErrPatterns(ctx context.Context, in *PatternsRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetSSHOptions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SSHOptions, error)
+	RegisterMode(ctx context.Context, in *pb.CliRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetExcludes(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error)
+	CleanOutput(ctx context.Context, in *Text, opts ...grpc.CallOption) (*Text, error)
+	GetInterrupts(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error)
+	GetResyncs(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error)
+	GetTxCommands(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*TxCommands, error)
+	GetBackups(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Backups, error)
+	GetPlatform(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Text, error)
+	GetSecretPatterns(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error)
+	// Hook runs a session hook, see Frame
+	Hook(ctx context.Context, opts ...grpc.CallOption) (Operator_HookClient, error)
+}
+
+type operatorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOperatorClient(cc grpc.ClientConnInterface) OperatorClient {
+	return &operatorClient{cc}
+}
+
+func (c *operatorClient) Describe(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Descriptor, error) {
+	out := new(Descriptor)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/Describe", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetTransitions(ctx context.Context, in *TransitionRequest, opts ...grpc.CallOption) (*Strings, error) {
+	out := new(Strings)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetTransitions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetPrompts(ctx context.Context, in *ModeRequest, opts ...grpc.CallOption) (*Strings, error) {
+	out := new(Strings)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetPrompts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetModes(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error) {
+	out := new(Strings)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetModes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) SetPrompts(ctx context.Context, in *PatternsRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/SetPrompts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetErrPatterns(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error) {
+	out := new(Strings)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetErrPatterns", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) SetErrPatterns(ctx context.Context, in *PatternsRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/SetErrPatterns", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetSSHOptions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SSHOptions, error) {
+	out := new(SSHOptions)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetSSHOptions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) RegisterMode(ctx context.Context, in *pb.CliRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/RegisterMode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetExcludes(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error) {
+	out := new(Strings)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetExcludes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) CleanOutput(ctx context.Context, in *Text, opts ...grpc.CallOption) (*Text, error) {
+	out := new(Text)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/CleanOutput", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetInterrupts(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error) {
+	out := new(Strings)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetInterrupts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetResyncs(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error) {
+	out := new(Strings)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetResyncs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetTxCommands(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*TxCommands, error) {
+	out := new(TxCommands)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetTxCommands", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetBackups(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Backups, error) {
+	out := new(Backups)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetBackups", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetPlatform(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Text, error) {
+	out := new(Text)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetPlatform", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) GetSecretPatterns(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Strings, error) {
+	out := new(Strings)
+	err := c.cc.Invoke(ctx, "/netd.plugin.Operator/GetSecretPatterns", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *operatorClient) Hook(ctx context.Context, opts ...grpc.CallOption) (Operator_HookClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Operator_serviceDesc.Streams[0], "/netd.plugin.Operator/Hook", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &operatorHookClient{stream}
+	return x, nil
+}
+
+type Operator_HookClient interface {
+	Send(*Frame) error
+	Recv() (*Frame, error)
+	grpc.ClientStream
+}
+
+type operatorHookClient struct {
+	grpc.ClientStream
+}
+
+func (x *operatorHookClient) Send(m *Frame) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *operatorHookClient) Recv() (*Frame, error) {
+	m := new(Frame)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// OperatorServer is the server API for Operator service.
+type OperatorServer interface {
+	Describe(context.Context, *Empty) (*Descriptor, error)
+	GetTransitions(context.Context, *TransitionRequest) (*Strings, error)
+	GetPrompts(context.Context, *ModeRequest) (*Strings, error)
+	GetModes(context.Context, *Empty) (*Strings, error)
+	SetPrompts(context.Context, *PatternsRequest) (*Empty, error)
+	GetErrPatterns(context.Context, *Empty) (*Strings, error)
+	SetErrPatterns(context.Context, *PatternsRequest) (*Empty, error)
+	GetSSHOptions(context.Context, *Empty) (*SSHOptions, error)
+	RegisterMode(context.Context, *pb.CliRequest) (*Empty, error)
+	GetExcludes(context.Context, *Empty) (*Strings, error)
+	CleanOutput(context.Context, *Text) (*Text, error)
+	GetInterrupts(context.Context, *Empty) (*Strings, error)
+	GetResyncs(context.Context, *Empty) (*Strings, error)
+	GetTxCommands(context.Context, *Empty) (*TxCommands, error)
+	GetBackups(context.Context, *Empty) (*Backups, error)
+	GetPlatform(context.Context, *Empty) (*Text, error)
+	GetSecretPatterns(context.Context, *Empty) (*Strings, error)
+	// Hook runs a session hook, see Frame
+	Hook(Operator_HookServer) error
+}
+
+// UnimplementedOperatorServer can be embedded to have forward compatible implementations.
+type UnimplementedOperatorServer struct {
+}
+
+func (*UnimplementedOperatorServer) Describe(ctx context.Context, req *Empty) (*Descriptor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Describe not implemented")
+}
+func (*UnimplementedOperatorServer) GetTransitions(ctx context.Context, req *TransitionRequest) (*Strings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransitions not implemented")
+}
+func (*UnimplementedOperatorServer) GetPrompts(ctx context.Context, req *ModeRequest) (*Strings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPrompts not implemented")
+}
+func (*UnimplementedOperatorServer) GetModes(ctx context.Context, req *Empty) (*Strings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetModes not implemented")
+}
+func (*UnimplementedOperatorServer) SetPrompts(ctx context.Context, req *PatternsRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPrompts not implemented")
+}
+func (*UnimplementedOperatorServer) GetErrPatterns(ctx context.Context, req *Empty) (*Strings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetErrPatterns not implemented")
+}
+func (*UnimplementedOperatorServer) SetErrPatterns(ctx context.Context, req *PatternsRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetErrPatterns not implemented")
+}
+func (*UnimplementedOperatorServer) GetSSHOptions(ctx context.Context, req *Empty) (*SSHOptions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSSHOptions not implemented")
+}
+func (*UnimplementedOperatorServer) RegisterMode(ctx context.Context, req *pb.CliRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterMode not implemented")
+}
+func (*UnimplementedOperatorServer) GetExcludes(ctx context.Context, req *Empty) (*Strings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExcludes not implemented")
+}
+func (*UnimplementedOperatorServer) CleanOutput(ctx context.Context, req *Text) (*Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CleanOutput not implemented")
+}
+func (*UnimplementedOperatorServer) GetInterrupts(ctx context.Context, req *Empty) (*Strings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInterrupts not implemented")
+}
+func (*UnimplementedOperatorServer) GetResyncs(ctx context.Context, req *Empty) (*Strings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetResyncs not implemented")
+}
+func (*UnimplementedOperatorServer) GetTxCommands(ctx context.Context, req *Empty) (*TxCommands, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTxCommands not implemented")
+}
+func (*UnimplementedOperatorServer) GetBackups(ctx context.Context, req *Empty) (*Backups, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBackups not implemented")
+}
+func (*UnimplementedOperatorServer) GetPlatform(ctx context.Context, req *Empty) (*Text, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlatform not implemented")
+}
+func (*UnimplementedOperatorServer) GetSecretPatterns(ctx context.Context, req *Empty) (*Strings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSecretPatterns not implemented")
+}
+func (*UnimplementedOperatorServer) Hook(srv Operator_HookServer) error {
+	return status.Errorf(codes.Unimplemented, "method Hook not implemented")
+}
+
+func RegisterOperatorServer(s *grpc.Server, srv OperatorServer) {
+	s.RegisterService(&_Operator_serviceDesc, srv)
+}
+
+func _Operator_Describe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).Describe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/Describe",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).Describe(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetTransitions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransitionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetTransitions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetTransitions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetTransitions(ctx, req.(*TransitionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetPrompts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetPrompts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetPrompts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetPrompts(ctx, req.(*ModeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetModes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetModes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetModes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetModes(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_SetPrompts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PatternsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).SetPrompts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/SetPrompts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).SetPrompts(ctx, req.(*PatternsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetErrPatterns_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetErrPatterns(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetErrPatterns",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetErrPatterns(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_SetErrPatterns_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PatternsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).SetErrPatterns(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/SetErrPatterns",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).SetErrPatterns(ctx, req.(*PatternsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetSSHOptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetSSHOptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetSSHOptions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetSSHOptions(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_RegisterMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(pb.CliRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).RegisterMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/RegisterMode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).RegisterMode(ctx, req.(*pb.CliRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetExcludes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetExcludes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetExcludes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetExcludes(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_CleanOutput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Text)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).CleanOutput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/CleanOutput",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).CleanOutput(ctx, req.(*Text))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetInterrupts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetInterrupts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetInterrupts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetInterrupts(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetResyncs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetResyncs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetResyncs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetResyncs(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetTxCommands_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetTxCommands(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetTxCommands",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetTxCommands(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetBackups_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetBackups(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetBackups",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetBackups(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetPlatform_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetPlatform(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetPlatform",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetPlatform(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_GetSecretPatterns_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OperatorServer).GetSecretPatterns(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.plugin.Operator/GetSecretPatterns",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OperatorServer).GetSecretPatterns(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Operator_Hook_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(OperatorServer).Hook(&operatorHookServer{stream})
+}
+
+type Operator_HookServer interface {
+	Send(*Frame) error
+	Recv() (*Frame, error)
+	grpc.ServerStream
+}
+
+type operatorHookServer struct {
+	grpc.ServerStream
+}
+
+func (x *operatorHookServer) Send(m *Frame) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *operatorHookServer) Recv() (*Frame, error) {
+	m := new(Frame)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _Operator_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "netd.plugin.Operator",
+	HandlerType: (*OperatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Describe",
+			Handler:    _Operator_Describe_Handler,
+		},
+		{
+			MethodName: "GetTransitions",
+			Handler:    _Operator_GetTransitions_Handler,
+		},
+		{
+			MethodName: "GetPrompts",
+			Handler:    _Operator_GetPrompts_Handler,
+		},
+		{
+			MethodName: "GetModes",
+			Handler:    _Operator_GetModes_Handler,
+		},
+		{
+			MethodName: "SetPrompts",
+			Handler:    _Operator_SetPrompts_Handler,
+		},
+		{
+			MethodName: "GetErrPatterns",
+			Handler:    _Operator_GetErrPatterns_Handler,
+		},
+		{
+			MethodName: "SetErrPatterns",
+			Handler:    _Operator_SetErrPatterns_Handler,
+		},
+		{
+			MethodName: "GetSSHOptions",
+			Handler:    _Operator_GetSSHOptions_Handler,
+		},
+		{
+			MethodName: "RegisterMode",
+			Handler:    _Operator_RegisterMode_Handler,
+		},
+		{
+			MethodName: "GetExcludes",
+			Handler:    _Operator_GetExcludes_Handler,
+		},
+		{
+			MethodName: "CleanOutput",
+			Handler:    _Operator_CleanOutput_Handler,
+		},
+		{
+			MethodName: "GetInterrupts",
+			Handler:    _Operator_GetInterrupts_Handler,
+		},
+		{
+			MethodName: "GetResyncs",
+			Handler:    _Operator_GetResyncs_Handler,
+		},
+		{
+			MethodName: "GetTxCommands",
+			Handler:    _Operator_GetTxCommands_Handler,
+		},
+		{
+			MethodName: "GetBackups",
+			Handler:    _Operator_GetBackups_Handler,
+		},
+		{
+			MethodName: "GetPlatform",
+			Handler:    _Operator_GetPlatform_Handler,
+		},
+		{
+			MethodName: "GetSecretPatterns",
+			Handler:    _Operator_GetSecretPatterns_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Hook",
+			Handler:       _Operator_Hook_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "cli/plugin/plugin.proto",
+}
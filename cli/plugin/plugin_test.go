@@ -0,0 +1,162 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sky-cloud-tec/netd/cli"
+	"github.com/sky-cloud-tec/netd/protocol"
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc"
+)
+
+type testOp struct {
+	prompts    map[string][]*regexp.Regexp
+	linebreaks int32 // GetLinebreak calls
+}
+
+func (s *testOp) GetTransitions(c, t string) []string {
+	if c == "login" && t == "configure" {
+		return []string{"configure"}
+	}
+	if c == t {
+		return []string{}
+	}
+	return nil
+}
+func (s *testOp) GetPrompts(k string) []*regexp.Regexp        { return s.prompts[k] }
+func (s *testOp) GetModes() []string                          { return []string{"login", "configure"} }
+func (s *testOp) SetPrompts(k string, regs []*regexp.Regexp)  { s.prompts[k] = regs }
+func (s *testOp) GetErrPatterns() []*regexp.Regexp            { return nil }
+func (s *testOp) SetErrPatterns([]*regexp.Regexp)             {}
+func (s *testOp) GetSSHOptions() *SSHOptions                  { return nil }
+func (s *testOp) GetStartMode() string                        { return "login" }
+func (s *testOp) RegisterMode(req *protocol.CliRequest) error { return nil }
+func (s *testOp) GetEncoding() string                         { return "" }
+func (s *testOp) GetExcludes() []*regexp.Regexp               { return nil }
+func (s *testOp) CleanOutput(x string) string                 { return x + "!" }
+func (s *testOp) GetLinebreak() string {
+	atomic.AddInt32(&s.linebreaks, 1)
+	return "\n"
+}
+func (s *testOp) GetSecretPatterns() []*regexp.Regexp {
+	return []*regexp.Regexp{regexp.MustCompile(`token (\S+)`)}
+}
+func (s *testOp) DisablePager(sess cli.Session) error {
+	sess.SetMode("configure")
+	if _, err := sess.WriteBuff("set cli pager off"); err != nil {
+		return err
+	}
+	out, _, err := sess.ReadBuff()
+	sess.Set("pager", out)
+	return err
+}
+
+// fakeSession records what hooks did
+type fakeSession struct {
+	mode     string
+	writes   []string
+	values   map[string]interface{}
+	readMode string
+}
+
+func (s *fakeSession) Request() *protocol.CliRequest { return &protocol.CliRequest{Mode: "login"} }
+func (s *fakeSession) Mode() string                  { return s.mode }
+func (s *fakeSession) SetMode(m string)              { s.mode = m }
+func (s *fakeSession) WriteBuff(cmd string) (int, error) {
+	s.writes = append(s.writes, cmd)
+	return len(cmd), nil
+}
+func (s *fakeSession) ReadBuff() (string, string, error) {
+	s.readMode = s.mode
+	return "off", "admin# ", nil
+}
+func (s *fakeSession) Set(k string, v interface{}) { s.values[k] = v }
+func (s *fakeSession) Get(k string) (interface{}, bool) {
+	v, ok := s.values[k]
+	return v, ok
+}
+
+func TestPluginRoundTrip(t *testing.T) {
+
+	Convey("plugin operator round trip", t, func() {
+		dir, err := ioutil.TempDir("", "netd-plugin-test")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		sock := filepath.Join(dir, "test.sock")
+		l, err := net.Listen("unix", sock)
+		So(err, ShouldBeNil)
+		op := &testOp{prompts: map[string][]*regexp.Regexp{"login": {regexp.MustCompile("> $")}}}
+		gs := newGrpcServer("test", `test\..*`, op)
+		go gs.Serve(l)
+		defer gs.Stop()
+
+		cc, err := grpc.Dial(sock, grpc.WithInsecure(),
+			grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", addr)
+			}))
+		So(err, ShouldBeNil)
+		desc, err := NewOperatorClient(cc).Describe(context.Background(), &Empty{})
+		So(err, ShouldBeNil)
+		So(desc.Pattern, ShouldEqual, `test\..*`)
+		ro := newRemoteOperator("test")
+		ro.setConn(cc, desc)
+
+		So(cli.AnyMatch(ro.GetPrompts("login"), "admin> "), ShouldBeTrue)
+		So(ro.GetPrompts("configure"), ShouldBeNil)
+		So(ro.GetTransitions("login", "configure"), ShouldResemble, []string{"configure"})
+		So(ro.GetTransitions("login", "login"), ShouldNotBeNil)
+		So(ro.GetTransitions("configure", "login"), ShouldBeNil)
+		So(ro.GetStartMode(), ShouldEqual, "login")
+		// taken from descriptor, not called per write
+		for i := 0; i < 3; i++ {
+			So(ro.GetLinebreak(), ShouldEqual, "\n")
+		}
+		So(atomic.LoadInt32(&op.linebreaks), ShouldEqual, 1)
+		So(cli.ShortestPath(ro, "login", "configure"), ShouldResemble, []string{"login", "configure"})
+		So(ro.CleanOutput("x"), ShouldEqual, "x!")
+		So(ro.GetInterrupts(), ShouldResemble, cli.DefaultInterrupts)
+		So(ro.GetResyncs(), ShouldResemble, cli.DefaultResyncs)
+		So(ro.GetTxCommands().Mode, ShouldBeEmpty)
+		So(ro.GetBackups(), ShouldBeEmpty)
+		So(cli.GetPlatform(ro, &protocol.CliRequest{Vendor: "Test", Type: "os"}), ShouldEqual, "test_os")
+		So(cli.Redact(ro, "set token abc"), ShouldEqual, "set token "+cli.Mask)
+
+		// post login falls back to pager hook
+		sess := &fakeSession{mode: "login", values: make(map[string]interface{})}
+		So(ro.PostLogin(sess, "admin> "), ShouldBeNil)
+		So(sess.writes, ShouldResemble, []string{"set cli pager off"})
+		So(sess.readMode, ShouldEqual, "configure")
+		So(sess.mode, ShouldEqual, "configure")
+		So(sess.values[valuesKey], ShouldResemble, map[string]string{"pager": "off"})
+		So(ro.PreExec(sess), ShouldBeNil)
+
+		// plugin down
+		ro.setConn(nil, nil)
+		So(ro.GetPrompts("login"), ShouldBeNil)
+		So(ro.PostLogin(sess, "admin> "), ShouldNotBeNil)
+		So(ro.RegisterMode(&protocol.CliRequest{}), ShouldNotBeNil)
+		So(ro.GetLinebreak(), ShouldEqual, "\n")
+	})
+}
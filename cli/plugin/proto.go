@@ -0,0 +1,108 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package plugin runs cli operators out of process.
+//
+// A plugin is an executable which calls Serve with its operator, netd launches
+// every plugin found in plugin dir and registers them to cli.OperatorManagerInstance
+// like built-in operators. The protocol is grpc service netd.plugin.Operator of plugin.proto,
+// every method of cli.Operator and its hooks has a counterpart rpc, except linebreak,
+// start mode and encoding which are described once when plugin started.
+package plugin
+
+import (
+	"github.com/sky-cloud-tec/netd/cli"
+)
+
+const (
+	// SocketEnv is the env var which tells plugin where to listen on
+	SocketEnv = "NETD_PLUGIN_SOCKET"
+
+	// hook names
+	hookPostLogin    = "post_login"
+	hookDisablePager = "disable_pager"
+	hookPreExec      = "pre_exec"
+	hookCleanOutput  = "clean_output"
+	hookInterrupts   = "interrupts"
+	hookResyncs      = "resyncs"
+	hookTx           = "tx"
+	hookBackups      = "backups"
+	hookPlatform     = "platform"
+	hookSecrets      = "secrets"
+
+	// session ops
+	opWrite = "write"
+	opRead  = "read"
+	opDone  = "done"
+)
+
+// newStrings return message of ss, keeping nil apart from empty list
+func newStrings(ss []string) *Strings {
+	return &Strings{Values: ss, Nil: ss == nil}
+}
+
+// list return values, nil only if sender sent nil
+func (s *Strings) list() []string {
+	if s.Nil {
+		return nil
+	}
+	if s.Values == nil {
+		return []string{}
+	}
+	return s.Values
+}
+
+func fromTxCommands(v *cli.TxCommands) *TxCommands {
+	if v == nil {
+		return &TxCommands{}
+	}
+	return &TxCommands{
+		Mode:            v.Mode,
+		Diff:            v.Diff,
+		Commit:          v.Commit,
+		CommitConfirmed: v.CommitConfirmed,
+		Confirm:         v.Confirm,
+		Discard:         v.Discard,
+	}
+}
+
+func toTxCommands(v *TxCommands) *cli.TxCommands {
+	return &cli.TxCommands{
+		Mode:            v.GetMode(),
+		Diff:            v.GetDiff(),
+		Commit:          v.GetCommit(),
+		CommitConfirmed: v.GetCommitConfirmed(),
+		Confirm:         v.GetConfirm(),
+		Discard:         v.GetDiscard(),
+	}
+}
+
+func fromBackups(backups []*cli.Backup) *Backups {
+	out := &Backups{}
+	for _, v := range backups {
+		if v != nil {
+			out.Values = append(out.Values, &Backup{Format: v.Format, Mode: v.Mode, Commands: v.Commands})
+		}
+	}
+	return out
+}
+
+func toBackups(v *Backups) []*cli.Backup {
+	var backups []*cli.Backup
+	for _, b := range v.GetValues() {
+		backups = append(backups, &cli.Backup{Format: b.Format, Mode: b.Mode, Commands: b.Commands})
+	}
+	return backups
+}
@@ -0,0 +1,363 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/sky-cloud-tec/netd/cli"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/protocol/pb"
+	"google.golang.org/grpc"
+)
+
+// Operator is the operator implemented by plugins.
+// It's cli.Operator except that ssh session is opened by netd as SSHOptions told.
+// Hooks are optional as built-in operators, see cli.PagerDisabler, cli.PostLoginHook,
+// cli.PreExecHook, cli.OutputCleaner and cli.ModeLister.
+type Operator interface {
+	GetTransitions(c, t string) []string
+	GetPrompts(m string) []*regexp.Regexp
+	SetPrompts(string, []*regexp.Regexp)
+	GetErrPatterns() []*regexp.Regexp
+	SetErrPatterns([]*regexp.Regexp)
+	GetSSHOptions() *SSHOptions
+	GetLinebreak() string
+	GetStartMode() string
+	RegisterMode(*protocol.CliRequest) error
+	GetEncoding() string
+	GetExcludes() []*regexp.Regexp
+}
+
+// Serve serve plugin operator op on the socket netd told, pattern is
+// the vendor.type.version pattern op registered with.
+// It blocks until netd gone.
+func Serve(name, pattern string, op Operator) error {
+	path := os.Getenv(SocketEnv)
+	if path == "" {
+		return fmt.Errorf("%s not set, plugin should be launched by netd", SocketEnv)
+	}
+	os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	// exit when netd gone
+	ppid := os.Getppid()
+	go func() {
+		for range time.Tick(time.Second) {
+			if os.Getppid() != ppid {
+				os.Exit(0)
+			}
+		}
+	}()
+	return newGrpcServer(name, pattern, op).Serve(l)
+}
+
+func newGrpcServer(name, pattern string, op Operator) *grpc.Server {
+	gs := grpc.NewServer()
+	RegisterOperatorServer(gs, &server{
+		desc: &Descriptor{
+			Name:      name,
+			Pattern:   pattern,
+			Hooks:     hooksOf(op),
+			Linebreak: op.GetLinebreak(),
+			StartMode: op.GetStartMode(),
+			Encoding:  op.GetEncoding(),
+		},
+		op: op,
+	})
+	return gs
+}
+
+func hooksOf(op Operator) []string {
+	hooks := make([]string, 0)
+	if _, ok := op.(cli.PostLoginHook); ok {
+		hooks = append(hooks, hookPostLogin)
+	}
+	if _, ok := op.(cli.PagerDisabler); ok {
+		hooks = append(hooks, hookDisablePager)
+	}
+	if _, ok := op.(cli.PreExecHook); ok {
+		hooks = append(hooks, hookPreExec)
+	}
+	if _, ok := op.(cli.OutputCleaner); ok {
+		hooks = append(hooks, hookCleanOutput)
+	}
+	if _, ok := op.(cli.Interrupter); ok {
+		hooks = append(hooks, hookInterrupts)
+	}
+	if _, ok := op.(cli.Resyncer); ok {
+		hooks = append(hooks, hookResyncs)
+	}
+	if _, ok := op.(cli.Transactor); ok {
+		hooks = append(hooks, hookTx)
+	}
+	if _, ok := op.(cli.ConfigFetcher); ok {
+		hooks = append(hooks, hookBackups)
+	}
+	if _, ok := op.(cli.Platformer); ok {
+		hooks = append(hooks, hookPlatform)
+	}
+	if _, ok := op.(cli.SecretPatterner); ok {
+		hooks = append(hooks, hookSecrets)
+	}
+	return hooks
+}
+
+// server is plugin side OperatorServer implementation
+type server struct {
+	desc *Descriptor
+	op   Operator
+}
+
+func (s *server) Describe(ctx context.Context, in *Empty) (*Descriptor, error) {
+	return s.desc, nil
+}
+
+func (s *server) GetTransitions(ctx context.Context, in *TransitionRequest) (*Strings, error) {
+	return newStrings(s.op.GetTransitions(in.From, in.To)), nil
+}
+
+func (s *server) GetPrompts(ctx context.Context, in *ModeRequest) (*Strings, error) {
+	return newStrings(fromRegexps(s.op.GetPrompts(in.Mode))), nil
+}
+
+func (s *server) GetModes(ctx context.Context, in *Empty) (*Strings, error) {
+	if h, ok := s.op.(cli.ModeLister); ok {
+		return newStrings(h.GetModes()), nil
+	}
+	return newStrings([]string{s.op.GetStartMode()}), nil
+}
+
+func (s *server) SetPrompts(ctx context.Context, in *PatternsRequest) (*Empty, error) {
+	regs, err := toRegexps(in.Patterns)
+	if err != nil {
+		return nil, err
+	}
+	s.op.SetPrompts(in.Mode, regs)
+	return &Empty{}, nil
+}
+
+func (s *server) GetErrPatterns(ctx context.Context, in *Empty) (*Strings, error) {
+	return newStrings(fromRegexps(s.op.GetErrPatterns())), nil
+}
+
+func (s *server) SetErrPatterns(ctx context.Context, in *PatternsRequest) (*Empty, error) {
+	regs, err := toRegexps(in.Patterns)
+	if err != nil {
+		return nil, err
+	}
+	s.op.SetErrPatterns(regs)
+	return &Empty{}, nil
+}
+
+func (s *server) GetSSHOptions(ctx context.Context, in *Empty) (*SSHOptions, error) {
+	if o := s.op.GetSSHOptions(); o != nil {
+		return o, nil
+	}
+	return &SSHOptions{}, nil
+}
+
+func (s *server) RegisterMode(ctx context.Context, in *pb.CliRequest) (*Empty, error) {
+	if err := s.op.RegisterMode(pb.ToCliRequest(in)); err != nil {
+		return nil, err
+	}
+	return &Empty{}, nil
+}
+
+func (s *server) GetExcludes(ctx context.Context, in *Empty) (*Strings, error) {
+	return newStrings(fromRegexps(s.op.GetExcludes())), nil
+}
+
+func (s *server) CleanOutput(ctx context.Context, in *Text) (*Text, error) {
+	if h, ok := s.op.(cli.OutputCleaner); ok {
+		return &Text{Value: h.CleanOutput(in.Value)}, nil
+	}
+	return in, nil
+}
+
+func (s *server) GetInterrupts(ctx context.Context, in *Empty) (*Strings, error) {
+	if h, ok := s.op.(cli.Interrupter); ok {
+		return newStrings(h.GetInterrupts()), nil
+	}
+	return newStrings(cli.DefaultInterrupts), nil
+}
+
+func (s *server) GetResyncs(ctx context.Context, in *Empty) (*Strings, error) {
+	if h, ok := s.op.(cli.Resyncer); ok {
+		return newStrings(h.GetResyncs()), nil
+	}
+	return newStrings(cli.DefaultResyncs), nil
+}
+
+func (s *server) GetTxCommands(ctx context.Context, in *Empty) (*TxCommands, error) {
+	if h, ok := s.op.(cli.Transactor); ok {
+		return fromTxCommands(h.GetTxCommands()), nil
+	}
+	return nil, fmt.Errorf("config transaction not supported")
+}
+
+func (s *server) GetBackups(ctx context.Context, in *Empty) (*Backups, error) {
+	if h, ok := s.op.(cli.ConfigFetcher); ok {
+		return fromBackups(h.GetBackups()), nil
+	}
+	return &Backups{}, nil
+}
+
+func (s *server) GetPlatform(ctx context.Context, in *Empty) (*Text, error) {
+	if h, ok := s.op.(cli.Platformer); ok {
+		return &Text{Value: h.GetPlatform()}, nil
+	}
+	return &Text{}, nil
+}
+
+func (s *server) GetSecretPatterns(ctx context.Context, in *Empty) (*Strings, error) {
+	if h, ok := s.op.(cli.SecretPatterner); ok {
+		return newStrings(fromRegexps(h.GetSecretPatterns())), nil
+	}
+	return &Strings{}, nil
+}
+
+func (s *server) Hook(stream Operator_HookServer) error {
+	start, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	sess := &remoteSession{stream: stream, req: pb.ToCliRequest(start.Request), mode: start.Mode, values: start.Values}
+	if sess.values == nil {
+		sess.values = make(map[string]string)
+	}
+	switch start.Hook {
+	case hookPostLogin:
+		if h, ok := s.op.(cli.PostLoginHook); ok {
+			err = h.PostLogin(sess, start.Prompt)
+		}
+	case hookDisablePager:
+		if h, ok := s.op.(cli.PagerDisabler); ok {
+			err = h.DisablePager(sess)
+		}
+	case hookPreExec:
+		if h, ok := s.op.(cli.PreExecHook); ok {
+			err = h.PreExec(sess)
+		}
+	default:
+		err = fmt.Errorf("unknown hook %s", start.Hook)
+	}
+	return stream.Send(&Frame{Op: opDone, Mode: sess.mode, Values: sess.values, Err: errString(err)})
+}
+
+// remoteSession is the cli.Session seen by plugin hooks,
+// reads and writes are done by netd through hook stream
+type remoteSession struct {
+	stream Operator_HookServer
+	req    *protocol.CliRequest
+	mode   string
+	values map[string]string
+}
+
+func (s *remoteSession) Request() *protocol.CliRequest {
+	return s.req
+}
+
+func (s *remoteSession) Mode() string {
+	return s.mode
+}
+
+func (s *remoteSession) SetMode(m string) {
+	s.mode = m
+}
+
+func (s *remoteSession) WriteBuff(cmd string) (int, error) {
+	res, err := s.call(&Frame{Op: opWrite, Cmd: cmd, Mode: s.mode})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.N), toError(res.Err)
+}
+
+func (s *remoteSession) ReadBuff() (string, string, error) {
+	res, err := s.call(&Frame{Op: opRead, Mode: s.mode})
+	if err != nil {
+		return "", "", err
+	}
+	return res.Output, res.Prompt, toError(res.Err)
+}
+
+// Set store value as string, only strings survive across processes
+func (s *remoteSession) Set(key string, v interface{}) {
+	s.values[key] = fmt.Sprint(v)
+}
+
+func (s *remoteSession) Get(key string) (interface{}, bool) {
+	v, ok := s.values[key]
+	return v, ok
+}
+
+func (s *remoteSession) call(f *Frame) (*Frame, error) {
+	if err := s.stream.Send(f); err != nil {
+		return nil, err
+	}
+	return s.stream.Recv()
+}
+
+func fromRegexps(regs []*regexp.Regexp) []string {
+	if regs == nil {
+		return nil
+	}
+	ss := make([]string, 0, len(regs))
+	for _, v := range regs {
+		if v != nil {
+			ss = append(ss, v.String())
+		}
+	}
+	return ss
+}
+
+func toRegexps(ss []string) ([]*regexp.Regexp, error) {
+	if ss == nil {
+		return nil, nil
+	}
+	regs := make([]*regexp.Regexp, 0, len(ss))
+	for _, v := range ss {
+		r, err := regexp.Compile(v)
+		if err != nil {
+			return nil, err
+		}
+		regs = append(regs, r)
+	}
+	return regs, nil
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func toError(s string) error {
+	if s == "" {
+		return nil
+	}
+	return errors.New(s)
+}
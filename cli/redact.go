@@ -0,0 +1,154 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mask replaces secrets
+const Mask = "******"
+
+// minSecretLen is the shortest request secret masked literally,
+// shorter ones would mask ordinary text everywhere
+const minSecretLen = 4
+
+// SecretPatterner is implemented by operators which know how secrets look in their configs,
+// the first group of a pattern is masked, the whole match if it has no group
+type SecretPatterner interface {
+	GetSecretPatterns() []*regexp.Regexp
+}
+
+// CommonSecretPatterns apply to every operator, keyword followed by an optional type then the secret,
+// eg. `secret 5 $1$xx`, `set password ENC xx`, `pre-shared-key xx`, `snmp-server community xx`
+var CommonSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:password|passwd|secret|pre-shared-key|psksecret|community|key-string|authentication-key)` +
+		`(?:[ \t]+(?:[0-9]|ENC|cipher|simple|ascii-text|hexadecimal-text))?[ \t]+("[^"\r\n]*"|[^ \t\r\n]+)`),
+	regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// CiscoSecretPatterns are secret patterns of cisco style configs, eg. `key 7 xx`, snmpv3 user keys
+var CiscoSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bkey[ \t]+[0-7][ \t]+([^ \t\r\n]+)`),
+	regexp.MustCompile(`(?i)\b(?:auth[ \t]+(?:md5|sha)|priv(?:[ \t]+(?:3des|des|aes[ \t]+[0-9]+|aes-[0-9]+))?)[ \t]+([^ \t\r\n]+)`),
+}
+
+var (
+	extraMu      sync.RWMutex
+	extraSecrets []*regexp.Regexp
+)
+
+// AddSecretPatterns add extra patterns applied to every operator, see netd --redact-patterns
+func AddSecretPatterns(patterns ...string) error {
+	regs := make([]*regexp.Regexp, 0, len(patterns))
+	for _, v := range patterns {
+		r, err := regexp.Compile(v)
+		if err != nil {
+			return fmt.Errorf("invalid secret pattern %q: %s", v, err)
+		}
+		regs = append(regs, r)
+	}
+	extraMu.Lock()
+	extraSecrets = append(extraSecrets, regs...)
+	extraMu.Unlock()
+	return nil
+}
+
+// LoadSecretPatterns add extra patterns from file, one regexp per line, # for comments
+func LoadSecretPatterns(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open secret patterns error: %s", err)
+	}
+	defer f.Close()
+	var patterns []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("read secret patterns error: %s", err)
+	}
+	return AddSecretPatterns(patterns...)
+}
+
+// GetSecretPatterns return patterns of operator, common and extra ones, op may be nil
+func GetSecretPatterns(op Operator) []*regexp.Regexp {
+	extraMu.RLock()
+	defer extraMu.RUnlock()
+	var regs []*regexp.Regexp
+	if h, ok := op.(SecretPatterner); ok {
+		regs = append(regs, h.GetSecretPatterns()...)
+	}
+	regs = append(regs, CommonSecretPatterns...)
+	return append(regs, extraSecrets...)
+}
+
+// Redact mask secrets in x matched by patterns of operator, secrets given are masked literally,
+// eg. request password echoed
+func Redact(op Operator, x string, secrets ...string) string {
+	for _, v := range secrets {
+		if len(v) >= minSecretLen {
+			x = strings.Replace(x, v, Mask, -1)
+		}
+	}
+	for _, r := range GetSecretPatterns(op) {
+		x = mask(r, x)
+	}
+	return x
+}
+
+// RedactCommands mask secrets of commands
+func RedactCommands(op Operator, commands []string) []string {
+	out := make([]string, len(commands))
+	for i, v := range commands {
+		out[i] = Redact(op, v)
+	}
+	return out
+}
+
+// mask replace first group of every match, whole match if no group
+func mask(r *regexp.Regexp, x string) string {
+	idx := r.FindAllStringSubmatchIndex(x, -1)
+	if idx == nil {
+		return x
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range idx {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if start < last || start == end {
+			continue
+		}
+		b.WriteString(x[last:start])
+		b.WriteString(Mask)
+		last = end
+	}
+	b.WriteString(x[last:])
+	return b.String()
+}
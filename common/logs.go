@@ -25,9 +25,8 @@ type LogConfig struct {
 
 // AppConfig contains app config items
 type AppConfig struct {
-	Confidence int    `json:"confidence"`
-	LogCfgFlag int    `json:"log_cfg_flag"`
-	LogCfgDir  string `json:"cfg_dir"`
+	Confidence int `json:"confidence"`
+	LogCfgFlag int `json:"log_cfg_flag"` // telnet login, 3 waits for prompts, 4 sends credentials at once
 }
 
 // AppConfigInstance ...
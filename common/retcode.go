@@ -32,4 +32,30 @@ const (
 	ErrTimeout = 1005
 	// ErrNoMode mode not specified error
 	ErrNoMode = 1006
+	// ErrNoTx operator does not support config transaction
+	ErrNoTx = 1007
+	// ErrTxConfirm confirmed commit not confirmed, device rolls back itself
+	ErrTxConfirm = 1008
+	// ErrNoBackup operator can not dump config in requested format
+	ErrNoBackup = 1009
+	// ErrArchive config archive disabled or version not found
+	ErrArchive = 1010
+	// ErrRecording session recording disabled or not found
+	ErrRecording = 1011
+	// ErrVault credential vault disabled or operation failed
+	ErrVault = 1012
+	// ErrPortClosed port not reachable
+	ErrPortClosed = 1013
+	// ErrBadRequest malformed request
+	ErrBadRequest = 1014
+	// ErrJob job store disabled or operation failed
+	ErrJob = 1015
+	// ErrNoJob job not found
+	ErrNoJob = 1016
+	// ErrJobState job finished already, can not be canceled
+	ErrJobState = 1017
+	// ErrCanceled request canceled by caller
+	ErrCanceled = 1018
+	// ErrDenied client not authenticated or not authorized
+	ErrDenied = 1019
 )
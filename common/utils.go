@@ -17,6 +17,7 @@ package common
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/songtianyi/rrframework/logs"
 	"golang.org/x/text/encoding/ianaindex"
 	"golang.org/x/text/encoding/simplifiedchinese"
@@ -81,3 +82,26 @@ func ConvToUTF8(src string, b []byte) ([]byte, error) {
 	}
 	return d, nil
 }
+
+// ConvFromUTF8 convert utf8 byte to dst encoding type byte, error if any char can't be encoded
+func ConvFromUTF8(dst string, b []byte) ([]byte, error) {
+	if dst == "" || dst == "UTF-8" || dst == "utf-8" {
+		return b, nil
+	}
+	if v, ok := enc[dst]; ok {
+		dst = v
+	}
+	e, err := ianaindex.MIB.Encoding(dst)
+	if err != nil {
+		return b, err
+	}
+	if e == nil {
+		return b, fmt.Errorf("encoding %s not supported", dst)
+	}
+	reader := transform.NewReader(bytes.NewReader(b), e.NewEncoder())
+	d, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return b, err
+	}
+	return d, nil
+}
@@ -19,6 +19,19 @@ func TestEncConvert_map(t *testing.T) {
 		)
 	})
 }
+func TestConvFromUTF8(t *testing.T) {
+	Convey("utf8 to device charset", t, func() {
+		b, err := ConvFromUTF8("GB18030", []byte("description 北京"))
+		So(err, ShouldBeNil)
+		So(b, ShouldResemble, append([]byte("description "), 0xb1, 0xb1, 0xbe, 0xa9))
+		u, err := ConvToUTF8("GB18030", b)
+		So(err, ShouldBeNil)
+		So(string(u), ShouldEqual, "description 北京")
+		_, err = ConvFromUTF8("ISO-8859-1", []byte("北京"))
+		So(err, ShouldNotBeNil)
+	})
+}
+
 func TestEncConvert_convert(t *testing.T) {
 
 	Convey("test topsec encoding convert", t, func() {
@@ -5,15 +5,23 @@ go 1.13
 require (
 	github.com/astaxie/beego v1.12.1
 	github.com/gin-gonic/gin v1.6.3
+	github.com/golang/protobuf v1.3.3
+	github.com/gorilla/websocket v1.4.2
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/rs/xid v1.2.1
 	github.com/saintfish/chardet v0.0.0-20120816061221-3af4cd4741ca
 	github.com/shiena/ansicolor v0.0.0-20151119151921-a422bbe96644 // indirect
+	github.com/sirikothe/gotextfsm v1.2.0
 	github.com/sky-cloud-tec/proto v0.0.0-20201207101645-0a4780d79b9c
 	github.com/smartystreets/goconvey v1.6.4
 	github.com/songtianyi/rrframework v0.0.0-20180901111106-4caefe307b3f
+	github.com/streadway/amqp v1.0.0
 	github.com/urfave/cli v1.22.2
 	github.com/ziutek/telnet v0.0.0-20180329124119-c3b780dc415b
+	go.etcd.io/bbolt v1.3.5
 	golang.org/x/crypto v0.0.0-20191128160524-b544559bb6d1
 	golang.org/x/net v0.0.0-20190620200207-3b0461eec859
 	golang.org/x/text v0.3.2
+	google.golang.org/grpc v1.29.1
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,210 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sky-cloud-tec/netd/auth"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/songtianyi/rrframework/logs"
+	"github.com/streadway/amqp"
+)
+
+const (
+	// amqpRetryDelay is how long to wait before reconnecting to broker
+	amqpRetryDelay = 5 * time.Second
+	// amqpDeliveryCount is the header quorum queues count deliveries with
+	amqpDeliveryCount = "x-delivery-count"
+	// amqpAuthorization is the header of api token, eg. Bearer xxx
+	amqpAuthorization = "authorization"
+)
+
+// Amqp consumes CliRequest messages from queue, responses are published to reply-to queue
+// with correlation id of request. Malformed requests and requests redelivered too many times
+// are dead-lettered to queue suffixed with .dlq
+type Amqp struct {
+	url   string
+	queue string
+	// Reply is the queue responses published to when request has no reply-to, dropped if empty
+	Reply string
+	// Concurrency is how many requests handled at the same time, it's the prefetch count
+	Concurrency int
+	// MaxRedeliveries is how many times a redelivered request is handled again before dead-lettered,
+	// commands may have run when it's redelivered, so they're not run again by default
+	MaxRedeliveries int
+
+	handler func(context.Context, *protocol.CliRequest, *protocol.CliResponse) error
+	publish func(key string, msg amqp.Publishing) error
+}
+
+// NewAmqp return created amqp instance
+func NewAmqp(url, queue string) (*Amqp, error) {
+	if queue == "" {
+		return nil, fmt.Errorf("amqp queue not specified")
+	}
+	return &Amqp{
+		url:         url,
+		queue:       queue,
+		Concurrency: 1,
+		handler: func(ctx context.Context, req *protocol.CliRequest, res *protocol.CliResponse) error {
+			return new(CliHandler).HandleContext(ctx, req, res, nil)
+		},
+	}, nil
+}
+
+// DeadLetterQueue return name of dead letter queue
+func (s *Amqp) DeadLetterQueue() string {
+	return s.queue + ".dlq"
+}
+
+// Serve consume requests, reconnect when connection lost
+func (s *Amqp) Serve() error {
+	for {
+		if err := s.serve(); err != nil {
+			logs.Error("amqp error:", err, ", reconnect in", amqpRetryDelay)
+		}
+		time.Sleep(amqpRetryDelay)
+	}
+}
+
+func (s *Amqp) serve() error {
+	conn, err := amqp.Dial(s.url)
+	if err != nil {
+		return fmt.Errorf("dial amqp error: %s", err)
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("open channel error: %s", err)
+	}
+	if _, err := ch.QueueDeclare(s.DeadLetterQueue(), true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare dead letter queue error: %s", err)
+	}
+	// rejected requests go to dead letter queue through default exchange
+	if _, err := ch.QueueDeclare(s.queue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": s.DeadLetterQueue(),
+	}); err != nil {
+		return fmt.Errorf("declare queue error: %s", err)
+	}
+	if s.Reply != "" {
+		if _, err := ch.QueueDeclare(s.Reply, true, false, false, false, nil); err != nil {
+			return fmt.Errorf("declare reply queue error: %s", err)
+		}
+	}
+	n := s.Concurrency
+	if n < 1 {
+		n = 1
+	}
+	if err := ch.Qos(n, 0, false); err != nil {
+		return fmt.Errorf("set qos error: %s", err)
+	}
+	deliveries, err := ch.Consume(s.queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("consume error: %s", err)
+	}
+	s.publish = func(key string, msg amqp.Publishing) error {
+		return ch.Publish("", key, false, false, msg)
+	}
+	logs.Info("amqp consuming", s.queue)
+	// prefetch bounds deliveries in flight
+	for d := range deliveries {
+		go s.handle(d)
+	}
+	return fmt.Errorf("deliveries closed")
+}
+
+// handle run request of delivery, publish response then ack
+func (s *Amqp) handle(d amqp.Delivery) {
+	id := d.CorrelationId
+	if id == "" {
+		id = d.MessageId
+	}
+	if n := deliveryCount(d); n > s.MaxRedeliveries {
+		logs.Error("[ amqp", id, "]", "redelivered", n, "times, dead-lettered")
+		d.Reject(false)
+		return
+	}
+	req := new(protocol.CliRequest)
+	if err := json.Unmarshal(d.Body, req); err != nil {
+		logs.Error("[ amqp", id, "]", "malformed request, dead-lettered:", err)
+		d.Reject(false)
+		return
+	}
+	if req.Session == "" {
+		// trace request by correlation id
+		req.Session = id
+	}
+	res := new(protocol.CliResponse)
+	// token of request in authorization header, like http
+	authorization, _ := d.Headers[amqpAuthorization].(string)
+	if client, err := auth.Authenticate(nil, authorization, "amqp"); err != nil {
+		logs.Error("[ amqp", id, "]", "denied:", err)
+		*res = protocol.CliResponse{Retcode: common.ErrDenied, Message: err.Error(), Device: req.Device}
+	} else if err := s.handler(auth.NewContext(context.Background(), client), req, res); err != nil {
+		*res = protocol.CliResponse{Retcode: common.ErrCliExec, Message: err.Error(), Device: req.Device}
+	}
+	reply := d.ReplyTo
+	if reply == "" {
+		reply = s.Reply
+	}
+	if reply == "" {
+		logs.Info(req.LogPrefix, "no reply queue, response dropped")
+		d.Ack(false)
+		return
+	}
+	body, err := json.Marshal(res)
+	if err != nil {
+		logs.Error(req.LogPrefix, "marshal response error:", err)
+		d.Reject(false)
+		return
+	}
+	if err := s.publish(reply, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: id,
+		DeliveryMode:  amqp.Persistent,
+		Timestamp:     time.Now(),
+		Body:          body,
+	}); err != nil {
+		// channel is likely gone, broker redelivers it, see MaxRedeliveries
+		logs.Error(req.LogPrefix, "publish response error:", err)
+		d.Nack(false, true)
+		return
+	}
+	d.Ack(false)
+}
+
+// deliveryCount return how many times delivery delivered before,
+// quorum queues count it in header, classic ones only tell redelivered or not
+func deliveryCount(d amqp.Delivery) int {
+	switch v := d.Headers[amqpDeliveryCount].(type) {
+	case int64:
+		return int(v)
+	case int32:
+		return int(v)
+	case int:
+		return v
+	}
+	if d.Redelivered {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+
+	"github.com/sky-cloud-tec/netd/auth"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/songtianyi/rrframework/logs"
+)
+
+// AuthHandler authenticates jrpc connections with api tokens, clients with certificates need not login
+type AuthHandler struct {
+	jrpcConn
+}
+
+// Login authenticate jrpc connection with api token, calls after it run on behalf of token
+func (s *AuthHandler) Login(req *protocol.LoginRequest, res *protocol.LoginResponse) error {
+	if auth.Instance == nil {
+		*res = protocol.LoginResponse{Retcode: common.ErrBadRequest, Message: "authorization disabled"}
+		return nil
+	}
+	cur := auth.FromContext(s.connContext())
+	id, err := auth.Instance.Token(req.Token, cur.Address)
+	if err == nil {
+		err = auth.Instance.Authenticated(id)
+	}
+	if err != nil {
+		logs.Error("jrpc login from", auth.Describe(cur), "denied:", err)
+		*res = protocol.LoginResponse{Retcode: common.ErrDenied, Message: err.Error()}
+		return nil
+	}
+	s.login(id)
+	logs.Info("jrpc connection from", auth.Describe(cur), "logged in as", id.Name)
+	*res = protocol.LoginResponse{Retcode: common.OK, Message: "OK", Identity: id}
+	return nil
+}
+
+// authorize check client of ctx may run req, it's called before device acquired
+func authorize(ctx context.Context, req *protocol.CliRequest) error {
+	if auth.Instance == nil {
+		return nil
+	}
+	if err := auth.Instance.Authorize(auth.FromContext(ctx), req); err != nil {
+		logs.Error(req.LogPrefix, err)
+		return err
+	}
+	return nil
+}
+
+// admit check client of ctx is admin, archives and recordings are keyed by untrusted device names
+func admit(ctx context.Context) error {
+	if auth.Instance == nil {
+		return nil
+	}
+	id := auth.FromContext(ctx)
+	if err := auth.Instance.Admin(id); err != nil {
+		logs.Error("client", auth.Describe(id), "denied:", err)
+		return err
+	}
+	return nil
+}
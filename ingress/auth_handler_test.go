@@ -0,0 +1,130 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sky-cloud-tec/netd/auth"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/job"
+	"github.com/sky-cloud-tec/netd/protocol"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testPolicy = `roles:
+  readonly:
+    commands: ["^show "]
+tokens:
+  - name: compliance-bot
+    sha256: ed55d950c5f4d763e35c62f0f3149e005947542ddf7c4a24a5e1db08372cfde3 # s3cret-bot
+    roles: [readonly]
+`
+
+func TestAuthorize(t *testing.T) {
+	initAppConfig()
+	Convey("requests authorized before device acquired", t, func() {
+		dir, err := ioutil.TempDir("", "auth")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "policy.yaml")
+		So(ioutil.WriteFile(path, []byte(testPolicy), 0600), ShouldBeNil)
+		p, err := auth.Load(path)
+		So(err, ShouldBeNil)
+		auth.Instance = p
+		defer func() { auth.Instance = nil }()
+
+		// nothing listening, device is never reached when denied
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		addr := l.Addr().String()
+		l.Close()
+		req := func(commands ...string) *protocol.CliRequest {
+			return &protocol.CliRequest{Device: "fw1", Vendor: "juniper", Type: "srx", Version: "6.0", Address: addr,
+				Protocol: "ssh", Mode: "login", Commands: commands, Timeout: 2}
+		}
+		bot, err := p.Token("s3cret-bot", "")
+		So(err, ShouldBeNil)
+		ctx := auth.NewContext(context.Background(), bot)
+		var res protocol.CliResponse
+		So(new(CliHandler).HandleContext(ctx, req("show version", "request system reboot"), &res, nil), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.ErrDenied)
+		So(res.Message, ShouldContainSubstring, "request system reboot")
+		So(new(CliHandler).HandleContext(ctx, req("show version"), &res, nil), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.ErrAcquireConn)
+		So(new(CliHandler).HandleContext(context.Background(), req("show version"), &res, nil), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.ErrDenied)
+		// archives keyed by untrusted device names, admin only
+		var diff protocol.ConfigDiffResponse
+		So(new(ConfigHandler).DiffConfigContext(ctx, &protocol.ConfigDiffRequest{Device: "fw1"}, &diff), ShouldBeNil)
+		So(diff.Retcode, ShouldEqual, common.ErrDenied)
+
+		// jobs seen and canceled by client submitted them only
+		m, err := job.New(filepath.Join(dir, "jobs.db"), 1, func(ctx context.Context, req *protocol.CliRequest, res *protocol.CliResponse, emit func(*protocol.CmdResult)) {
+			*res = protocol.CliResponse{Retcode: common.OK, Message: "OK"}
+		})
+		So(err, ShouldBeNil)
+		defer m.Close()
+		job.Instance = m
+		defer func() { job.Instance = nil }()
+		var jr protocol.JobResponse
+		So(new(JobHandler).SubmitContext(ctx, req("show version"), &jr), ShouldBeNil)
+		So(jr.Retcode, ShouldEqual, common.OK)
+		id := &protocol.JobRequest{ID: jr.Job.ID}
+		So(new(JobHandler).StatusContext(ctx, id, &jr), ShouldBeNil)
+		So(jr.Retcode, ShouldEqual, common.OK)
+		So(new(JobHandler).StatusContext(context.Background(), id, &jr), ShouldBeNil)
+		So(jr.Retcode, ShouldEqual, common.ErrDenied)
+		So(new(JobHandler).CancelContext(context.Background(), id, &jr), ShouldBeNil)
+		So(jr.Retcode, ShouldEqual, common.ErrDenied)
+		var list protocol.JobListResponse
+		So(new(JobHandler).ListContext(ctx, &protocol.JobListRequest{}, &list), ShouldBeNil)
+		So(list.Jobs, ShouldHaveLength, 1)
+		So(new(JobHandler).ListContext(context.Background(), &protocol.JobListRequest{}, &list), ShouldBeNil)
+		So(list.Jobs, ShouldBeEmpty)
+
+		// jrpc clients login with token
+		j, err := NewJrpc("", nil)
+		So(err, ShouldBeNil)
+		So(j.Register(new(AuthHandler)), ShouldBeNil)
+		So(j.Register(new(CliHandler)), ShouldBeNil)
+		jl, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer jl.Close()
+		go j.serve(jl)
+		conn, err := net.Dial("tcp", jl.Addr().String())
+		So(err, ShouldBeNil)
+		c := jsonrpc.NewClient(conn)
+		defer c.Close()
+		So(c.Call("CliHandler.Handle", req("show version"), &res), ShouldNotBeNil)
+		var login protocol.LoginResponse
+		So(c.Call("AuthHandler.Login", &protocol.LoginRequest{Token: "guess"}, &login), ShouldBeNil)
+		So(login.Retcode, ShouldEqual, common.ErrDenied)
+		So(c.Call("AuthHandler.Login", &protocol.LoginRequest{Token: "s3cret-bot"}, &login), ShouldBeNil)
+		So(login.Retcode, ShouldEqual, common.OK)
+		So(login.Identity.Name, ShouldEqual, "compliance-bot")
+		So(c.Call("CliHandler.Handle", req("request system reboot"), &res), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.ErrDenied)
+		So(c.Call("CliHandler.Handle", req("show version"), &res), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.ErrAcquireConn)
+	})
+}
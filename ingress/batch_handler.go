@@ -0,0 +1,210 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/inventory"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/songtianyi/rrframework/logs"
+)
+
+var (
+	// BatchConcurrency is the max devices a batch handles at once
+	BatchConcurrency = 64
+	// BatchVendorConcurrency is the max devices of vendor a batch handles at once, keyed by lower case vendor,
+	// vendors not in it limited by BatchConcurrency only
+	BatchVendorConcurrency = map[string]int{}
+)
+
+// BatchHandler run the same commands on many devices
+type BatchHandler struct {
+	jrpcConn
+}
+
+// Run batch request, results of all devices returned at once
+func (s *BatchHandler) Run(req *protocol.BatchRequest, res *protocol.BatchResponse) error {
+	return s.RunContext(s.connContext(), req, res, nil)
+}
+
+// RunContext run batch request, devices handled like CliHandler with global and vendor concurrency limits,
+// emit is called with every device result once it's done. emit may be nil.
+// Once ctx done devices waiting are not run and running ones stop at next command
+func (s *BatchHandler) RunContext(ctx context.Context, req *protocol.BatchRequest, res *protocol.BatchResponse, emit func(*protocol.BatchResult)) error {
+	if req.Session == "" {
+		req.Session = xid.New().String()
+	}
+	devices, err := s.devices(req)
+	if err != nil {
+		logs.Error("[ batch ] [", req.Session, "] bad request:", err)
+		*res = protocol.BatchResponse{Retcode: common.ErrBadRequest, Message: err.Error()}
+		return nil
+	}
+	logs.Info("[ batch ] [", req.Session, "] running", len(devices), "devices")
+
+	n := s.limit(BatchConcurrency, req.Concurrency)
+	if n <= 0 {
+		n = len(devices)
+	}
+	global := make(chan struct{}, n)
+	vendors := make(map[string]chan struct{})
+	for _, v := range devices {
+		vendor := strings.ToLower(v.Vendor)
+		if _, ok := vendors[vendor]; ok {
+			continue
+		}
+		if n := s.limit(BatchVendorConcurrency[vendor], req.VendorConcurrency[vendor]); n > 0 {
+			vendors[vendor] = make(chan struct{}, n)
+		} else {
+			vendors[vendor] = nil
+		}
+	}
+
+	summary := &protocol.BatchSummary{
+		Total:    len(devices),
+		Retcodes: make(map[int][]string),
+		Start:    time.Now(),
+	}
+	results := make([]*protocol.BatchResult, 0, len(devices))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, v := range devices {
+		wg.Add(1)
+		go func(dr *protocol.CliRequest) {
+			defer wg.Done()
+			r := &protocol.BatchResult{Device: dr.Device, Address: dr.Address, Vendor: dr.Vendor}
+			r.Response = s.run(ctx, dr, global, vendors[strings.ToLower(dr.Vendor)])
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, r)
+			name := r.Device
+			if name == "" {
+				name = r.Address
+			}
+			summary.Retcodes[r.Response.Retcode] = append(summary.Retcodes[r.Response.Retcode], name)
+			if r.Response.Retcode == common.OK {
+				summary.Succeeded++
+			} else {
+				summary.Failed++
+			}
+			if emit != nil {
+				emit(r)
+			}
+		}(v)
+	}
+	wg.Wait()
+
+	for _, v := range summary.Retcodes {
+		sort.Strings(v)
+	}
+	summary.End = time.Now()
+	summary.Duration = summary.End.Sub(summary.Start)
+	logs.Info("[ batch ] [", req.Session, "] done,", summary.Succeeded, "succeeded,", summary.Failed, "failed in", summary.Duration)
+	*res = protocol.BatchResponse{Retcode: common.OK, Message: "OK", Summary: summary, Results: results}
+	return nil
+}
+
+// devices return cli requests of batch, commands, mode and timeout default to batch ones
+func (s *BatchHandler) devices(req *protocol.BatchRequest) ([]*protocol.CliRequest, error) {
+	devices := make([]*protocol.CliRequest, 0, len(req.Devices))
+	for _, v := range req.Devices {
+		if v == nil {
+			continue
+		}
+		dr := *v
+		devices = append(devices, &dr)
+	}
+	if req.Selector != nil {
+		if inventory.Instance == nil {
+			return nil, fmt.Errorf("selector given but inventory not configured")
+		}
+		selected, err := inventory.Instance.Select(req.Selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range selected {
+			devices = append(devices, v.Request())
+		}
+	}
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("no device")
+	}
+	for i, v := range devices {
+		if len(v.Commands) == 0 {
+			v.Commands = req.Commands
+		}
+		if len(v.Commands) == 0 {
+			return nil, fmt.Errorf("no command for device %s", v.Device)
+		}
+		if v.Mode == "" {
+			v.Mode = req.Mode
+		}
+		if v.Timeout == 0 {
+			v.Timeout = req.Timeout
+		}
+		v.Session = req.Session + "-" + strconv.Itoa(i)
+	}
+	return devices, nil
+}
+
+// limit return n lowered to requested one
+func (s *BatchHandler) limit(n, requested int) int {
+	if requested > 0 && (n <= 0 || requested < n) {
+		return requested
+	}
+	return n
+}
+
+// run device request once vendor and global slots taken
+func (s *BatchHandler) run(ctx context.Context, req *protocol.CliRequest, global, vendor chan struct{}) *protocol.CliResponse {
+	if vendor != nil {
+		select {
+		case vendor <- struct{}{}:
+			defer func() { <-vendor }()
+		case <-ctx.Done():
+			return s.makeDoneRes(ctx, req)
+		}
+	}
+	select {
+	case global <- struct{}{}:
+		defer func() { <-global }()
+	case <-ctx.Done():
+		return s.makeDoneRes(ctx, req)
+	}
+	res := new(protocol.CliResponse)
+	new(CliHandler).HandleContext(ctx, req, res, nil)
+	if res.Device == "" {
+		res.Device = req.Device
+	}
+	return res
+}
+
+// makeDoneRes return response of device not run as ctx done
+func (s *BatchHandler) makeDoneRes(ctx context.Context, req *protocol.CliRequest) *protocol.CliResponse {
+	code, msg := ctxErrRes(ctx, fmt.Errorf("device not run"))
+	return &protocol.CliResponse{Retcode: code, Message: msg, Device: req.Device}
+}
@@ -0,0 +1,99 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/simulator"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBatch(t *testing.T) {
+	initAppConfig()
+	Convey("batch runs devices with vendor limit and groups results by retcode", t, func() {
+		sc, err := simulator.LoadScenario("../simulator/scenarios/juniper_srx.yaml")
+		So(err, ShouldBeNil)
+		devices := make([]*protocol.CliRequest, 0)
+		for _, name := range []string{"srx1", "srx2"} {
+			srv, err := simulator.NewServer(sc)
+			So(err, ShouldBeNil)
+			defer srv.Close()
+			addr, err := srv.ListenSSH("127.0.0.1:0")
+			So(err, ShouldBeNil)
+			req := simulatedRequest(sc, addr.String(), "ssh")
+			req.Device = name
+			devices = append(devices, req)
+		}
+		// nothing listening
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		closed := simulatedRequest(sc, l.Addr().String(), "ssh")
+		closed.Device = "down"
+		closed.Vendor = "cisco"
+		l.Close()
+		devices = append(devices, closed)
+
+		req := &protocol.BatchRequest{
+			Devices:           devices,
+			Commands:          []string{"show version"},
+			Mode:              "login",
+			VendorConcurrency: map[string]int{"juniper": 1},
+		}
+		var (
+			mu      sync.Mutex
+			emitted []*protocol.BatchResult
+		)
+		var res protocol.BatchResponse
+		So(new(BatchHandler).RunContext(context.Background(), req, &res, func(r *protocol.BatchResult) {
+			mu.Lock()
+			emitted = append(emitted, r)
+			mu.Unlock()
+		}), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.OK)
+		So(emitted, ShouldHaveLength, 3)
+		So(res.Results, ShouldHaveLength, 3)
+		So(res.Summary.Total, ShouldEqual, 3)
+		So(res.Summary.Succeeded, ShouldEqual, 2)
+		So(res.Summary.Failed, ShouldEqual, 1)
+		So(res.Summary.Retcodes[common.OK], ShouldResemble, []string{"srx1", "srx2"})
+		So(res.Summary.Retcodes[common.OK], ShouldNotContain, "down")
+
+		// juniper devices one at a time
+		var srx []*protocol.CmdResult
+		for _, r := range res.Results {
+			if r.Vendor == "juniper" {
+				So(r.Response.Results, ShouldHaveLength, 1)
+				srx = append(srx, r.Response.Results[0])
+			}
+		}
+		So(srx, ShouldHaveLength, 2)
+		So(srx[1].Start.Before(srx[0].End), ShouldBeFalse)
+	})
+
+	Convey("batch without devices is a bad request", t, func() {
+		var res protocol.BatchResponse
+		So(new(BatchHandler).Run(&protocol.BatchRequest{Commands: []string{"show version"}}, &res), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.ErrBadRequest)
+		So(new(BatchHandler).Run(&protocol.BatchRequest{Selector: &protocol.DeviceSelector{Vendor: "juniper"}}, &res), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.ErrBadRequest)
+	})
+}
@@ -16,6 +16,7 @@
 package ingress
 
 import (
+	"context"
 	"strings"
 	"time"
 
@@ -37,18 +38,21 @@ import (
 	_ "github.com/sky-cloud-tec/netd/cli/topsec/ngfw4000"    // load topsec ngfw4000
 
 	"github.com/rs/xid"
+	"github.com/sky-cloud-tec/netd/auth"
 	"github.com/sky-cloud-tec/netd/common"
 	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/textfsm"
 	"github.com/songtianyi/rrframework/logs"
 )
 
 // CliHandler run cli commands and return result to caller
 type CliHandler struct {
+	jrpcConn
 	req *protocol.CliRequest
 }
 
-// Handle cli request
-func (s *CliHandler) Handle(req *protocol.CliRequest, res *protocol.CliResponse) error {
+// prepare build session, timeout and log prefix of cli request, client of ctx logged with it
+func prepare(ctx context.Context, req *protocol.CliRequest) {
 	if req.Session == "" {
 		req.Session = xid.New().String()
 	}
@@ -59,12 +63,8 @@ func (s *CliHandler) Handle(req *protocol.CliRequest, res *protocol.CliResponse)
 	pr.Auth.Username = strings.Repeat("*", len(pr.Auth.Username))
 	pr.Auth.Password = strings.Repeat("*", len(pr.Auth.Password))
 	pr.EnablePwd = strings.Repeat("*", len(pr.EnablePwd))
-	logs.Info("Received req", pr)
-	if req.Mode == "" {
-		logs.Error("mode not specified")
-		*res = s.makeCliErrRes(common.ErrNoMode, "mode not specified")
-		return nil
-	}
+	pr.Commands = cli.RedactCommands(nil, pr.Commands)
+	logs.Info("Received req from", auth.Describe(auth.FromContext(ctx)), pr)
 	// build timeout
 	if req.Timeout == 0 {
 		req.Timeout = common.DefaultTimeout
@@ -76,29 +76,61 @@ func (s *CliHandler) Handle(req *protocol.CliRequest, res *protocol.CliResponse)
 	if req.LogPrefix == "" {
 		req.LogPrefix = "[ " + req.Device + " ]"
 	}
+	req.LogPrefix = req.LogPrefix + " [ " + req.Session + " ] "
+}
 
+// Handle cli request
+func (s *CliHandler) Handle(req *protocol.CliRequest, res *protocol.CliResponse) error {
+	return s.HandleStream(req, res, nil)
+}
+
+// HandleStream handle cli request like Handle, emit is called with every command result once it's done,
+// before outputs parsed. emit may be nil
+func (s *CliHandler) HandleStream(req *protocol.CliRequest, res *protocol.CliResponse, emit func(*protocol.CmdResult)) error {
+	return s.HandleContext(s.connContext(), req, res, emit)
+}
+
+// HandleContext handle cli request like HandleStream, no more command is sent once ctx done or request timed out,
+// the command in flight is interrupted, session recovered or closed and device sema released then
+func (s *CliHandler) HandleContext(ctx context.Context, req *protocol.CliRequest, res *protocol.CliResponse, emit func(*protocol.CmdResult)) error {
+	prepare(ctx, req)
 	s.req = req
+	if req.Mode == "" {
+		logs.Error("mode not specified")
+		*res = s.makeCliErrRes(common.ErrNoMode, "mode not specified")
+		return nil
+	}
 
-	ch := make(chan error, 1)
+	ctx, cancel := context.WithTimeout(ctx, req.Timeout)
+	defer cancel()
+	ch := make(chan protocol.CliResponse, 1)
 
 	go func() {
-		req.LogPrefix = req.LogPrefix + " [ " + req.Session + " ] "
 		logs.Info(req.LogPrefix, "==========START==========")
-		ch <- s.doHandle(req, res)
+		var r protocol.CliResponse
+		s.doHandle(ctx, req, &r, emit)
+		ch <- r
 		logs.Info(req.LogPrefix, "==========END==========")
 	}()
 
-	// timeout
+	// handler stops itself once ctx done, in case recovery hangs
 	select {
-	case res := <-ch:
-		return res
-	case <-time.After(req.Timeout):
+	case *res = <-ch:
+	case <-time.After(req.Timeout + s.recoveryTimeout(req)):
 		*res = s.makeCliErrRes(common.ErrTimeout, "handle req timeout")
 	}
 	return nil
 }
 
-func (s *CliHandler) doHandle(req *protocol.CliRequest, res *protocol.CliResponse) error {
+// ctxErrRes return retcode and message of done ctx
+func ctxErrRes(ctx context.Context, err error) (int, string) {
+	if ctx.Err() == context.DeadlineExceeded {
+		return common.ErrTimeout, "handle req timeout, " + err.Error()
+	}
+	return common.ErrCanceled, "handle req canceled, " + err.Error()
+}
+
+func (s *CliHandler) doHandle(ctx context.Context, req *protocol.CliRequest, res *protocol.CliResponse, emit func(*protocol.CmdResult)) error {
 	// build device operator type
 	t := strings.Join([]string{req.Vendor, req.Type, req.Version}, ".")
 	// get operator by type
@@ -108,31 +140,91 @@ func (s *CliHandler) doHandle(req *protocol.CliRequest, res *protocol.CliRespons
 		*res = s.makeCliErrRes(common.ErrNoOpFound, "no operator match "+t)
 		return nil
 	}
+	if err := authorize(ctx, req); err != nil {
+		*res = s.makeCliErrRes(common.ErrDenied, err.Error())
+		return nil
+	}
 	// acquire cli connection, it could be blocked here for concurrency
-	c, err := conn.Acquire(req, op)
+	c, err := conn.Acquire(ctx, req, op)
 	defer conn.Release(req)
 	if err != nil {
 		logs.Error(req.LogPrefix, "new operator fail,", err)
 		*res = s.makeCliErrRes(common.ErrAcquireConn, "acquire cli conn fail, "+err.Error())
+		if ctx.Err() != nil {
+			*res = s.makeCliErrRes(ctxErrRes(ctx, err))
+		}
 		return nil
 	}
 	// execute cli commands
-	out, err := c.Exec()
+	results, err := c.ExecEach(ctx, emit)
 	if err != nil {
 		logs.Error(req.LogPrefix, "exec error:", err)
 		*res = s.makeCliErrRes(common.ErrCliExec, "exec cli cmds fail, "+err.Error())
+		if ctx.Err() != nil {
+			*res = s.makeCliErrRes(ctxErrRes(ctx, err))
+		}
+		res.Recovered = c.Recovered()
+		res.Results = results
+		res.Recording = c.Recording()
 		return nil
 	}
+	out := make(map[string]string, len(results))
+	for _, v := range results {
+		out[v.Command] = v.Output
+	}
 	// make reponse
 	*res = protocol.CliResponse{
-		Retcode: common.OK,
-		Message: "OK",
-		Device:  req.Device,
-		CmdsStd: out,
+		Retcode:   common.OK,
+		Message:   "OK",
+		Device:    req.Device,
+		CmdsStd:   out,
+		Results:   results,
+		Recording: c.Recording(),
 	}
+	s.parse(req, op, res)
 	return nil
 }
 
+// parse outputs with templates, explicit ones first, index is used if parse requested
+func (s *CliHandler) parse(req *protocol.CliRequest, op cli.Operator, res *protocol.CliResponse) {
+	if !req.Parse && len(req.Templates) == 0 {
+		return
+	}
+	if textfsm.Instance == nil {
+		res.ParseErrors = map[string]string{"": "templates dir not configured"}
+		return
+	}
+	platform := cli.GetPlatform(op, req)
+	res.Records = make(map[string][]map[string]interface{})
+	res.ParseErrors = make(map[string]string)
+	for _, r := range res.Results {
+		template, ok := req.Templates[r.Command]
+		if !ok && !req.Parse {
+			continue
+		}
+		records, err := textfsm.Instance.ParseCommand(platform, req.Device, r.Command, template, r.Output)
+		if err != nil {
+			logs.Error(req.LogPrefix, "parse", "<", r.Command, ">", "error:", err)
+			res.ParseErrors[r.Command] = err.Error()
+			continue
+		}
+		r.Records = make([]map[string]interface{}, 0, len(records))
+		for _, v := range records {
+			r.Records = append(r.Records, v)
+		}
+		res.Records[r.Command] = r.Records
+	}
+}
+
+// recoveryTimeout leave room for session recovery, so caller knows whether session recovered
+func (s *CliHandler) recoveryTimeout(req *protocol.CliRequest) time.Duration {
+	op := cli.OperatorManagerInstance.Get(strings.Join([]string{req.Vendor, req.Type, req.Version}, "."))
+	if op == nil {
+		return 0
+	}
+	return conn.RecoveryTimeout(op)
+}
+
 func (s *CliHandler) makeCliErrRes(code int, msg string) protocol.CliResponse {
 	return protocol.CliResponse{Retcode: code, Message: msg, Device: s.req.Device, CmdsStd: nil}
 }
@@ -0,0 +1,234 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sky-cloud-tec/netd/archive"
+	"github.com/sky-cloud-tec/netd/auth"
+	"github.com/sky-cloud-tec/netd/cli"
+	"github.com/sky-cloud-tec/netd/cli/conn"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/songtianyi/rrframework/logs"
+)
+
+// ConfigHandler serve device config operations
+type ConfigHandler struct {
+	jrpcConn
+}
+
+// Transact apply config commands to candidate config and commit it,
+// no handler timeout here, every step is bounded by read timeout
+// and a transaction should not be reported before it's done.
+// It stops once client gone, candidate changes are discarded then
+func (s *ConfigHandler) Transact(req *protocol.TxRequest, res *protocol.TxResponse) error {
+	prepare(s.connContext(), &req.CliRequest)
+	logs.Info(req.LogPrefix, "==========START==========")
+	*res = s.doTransact(s.connContext(), req)
+	logs.Info(req.LogPrefix, "==========END==========")
+	return nil
+}
+
+// doTransact run transaction on behalf of client of ctx, it gives up waiting for device and stops once ctx done
+func (s *ConfigHandler) doTransact(ctx context.Context, req *protocol.TxRequest) protocol.TxResponse {
+	t := strings.Join([]string{req.Vendor, req.Type, req.Version}, ".")
+	op := cli.OperatorManagerInstance.Get(t)
+	if op == nil {
+		logs.Error(req.LogPrefix, "no operator match", t)
+		return makeTxErrRes(req, common.ErrNoOpFound, "no operator match "+t)
+	}
+	h, ok := op.(cli.Transactor)
+	if !ok || h.GetTxCommands().Mode == "" {
+		logs.Error(req.LogPrefix, "config transaction not supported by", t)
+		return makeTxErrRes(req, common.ErrNoTx, "config transaction not supported by "+t)
+	}
+	req.Mode = h.GetTxCommands().Mode
+	if err := authorize(ctx, &req.CliRequest); err != nil {
+		return makeTxErrRes(req, common.ErrDenied, err.Error())
+	}
+	c, err := conn.Acquire(ctx, &req.CliRequest, op)
+	defer conn.Release(&req.CliRequest)
+	if err != nil {
+		logs.Error(req.LogPrefix, "new operator fail,", err)
+		if ctx.Err() != nil {
+			code, msg := ctxErrRes(ctx, err)
+			return makeTxErrRes(req, code, msg)
+		}
+		return makeTxErrRes(req, common.ErrAcquireConn, "acquire cli conn fail, "+err.Error())
+	}
+	_, tx, err := c.Transact(ctx, req.Confirm, req.DryRun)
+	res := makeTxErrRes(req, common.OK, "OK")
+	if tx != nil {
+		res.Diff, res.Committed, res.Confirmed = tx.Diff, tx.Committed, tx.Confirmed
+	}
+	if err != nil {
+		logs.Error(req.LogPrefix, "transaction error:", err)
+		res.Retcode, res.Message = common.ErrCliExec, "config transaction fail, "+err.Error()
+		if res.Committed {
+			// confirmed commit done but not confirmed
+			res.Retcode = common.ErrTxConfirm
+		} else if ctx.Err() != nil {
+			res.Retcode, res.Message = ctxErrRes(ctx, err)
+		}
+	}
+	return res
+}
+
+func makeTxErrRes(req *protocol.TxRequest, code int, msg string) protocol.TxResponse {
+	return protocol.TxResponse{Retcode: code, Message: msg, Device: req.Device}
+}
+
+// FetchConfig dump device config with operator backup commands
+func (s *ConfigHandler) FetchConfig(req *protocol.CliRequest, res *protocol.ConfigResponse) error {
+	return s.FetchConfigContext(s.connContext(), req, res)
+}
+
+// FetchConfigContext dump device config like FetchConfig, it stops once ctx done
+func (s *ConfigHandler) FetchConfigContext(ctx context.Context, req *protocol.CliRequest, res *protocol.ConfigResponse) error {
+	prepare(ctx, req)
+	logs.Info(req.LogPrefix, "==========START==========")
+	*res = s.doFetchConfig(ctx, req)
+	logs.Info(req.LogPrefix, "==========END==========")
+	return nil
+}
+
+func (s *ConfigHandler) doFetchConfig(ctx context.Context, req *protocol.CliRequest) protocol.ConfigResponse {
+	t := strings.Join([]string{req.Vendor, req.Type, req.Version}, ".")
+	op := cli.OperatorManagerInstance.Get(t)
+	if op == nil {
+		logs.Error(req.LogPrefix, "no operator match", t)
+		return makeConfigErrRes(req, common.ErrNoOpFound, "no operator match "+t)
+	}
+	b := cli.GetBackup(op, req.Format)
+	if b == nil {
+		logs.Error(req.LogPrefix, "no backup of format", req.Format, "for", t)
+		return makeConfigErrRes(req, common.ErrNoBackup, "no backup of format "+req.Format+" for "+t)
+	}
+	// authorized like commands of backup run in request
+	ar := *req
+	ar.Commands = b.Commands
+	if b.Mode != "" {
+		ar.Mode = b.Mode
+	}
+	if err := authorize(ctx, &ar); err != nil {
+		return makeConfigErrRes(req, common.ErrDenied, err.Error())
+	}
+	c, err := conn.Acquire(ctx, req, op)
+	defer conn.Release(req)
+	if err != nil {
+		logs.Error(req.LogPrefix, "new operator fail,", err)
+		if ctx.Err() != nil {
+			code, msg := ctxErrRes(ctx, err)
+			return makeConfigErrRes(req, code, msg)
+		}
+		return makeConfigErrRes(req, common.ErrAcquireConn, "acquire cli conn fail, "+err.Error())
+	}
+	config, b, err := c.FetchConfig(ctx, req.Format)
+	if err != nil {
+		logs.Error(req.LogPrefix, "fetch config error:", err)
+		if ctx.Err() != nil {
+			code, msg := ctxErrRes(ctx, err)
+			return makeConfigErrRes(req, code, msg)
+		}
+		return makeConfigErrRes(req, common.ErrCliExec, "fetch config fail, "+err.Error())
+	}
+	res := protocol.ConfigResponse{
+		Retcode:  common.OK,
+		Message:  "OK",
+		Device:   req.Device,
+		Format:   b.Format,
+		Config:   config,
+		Checksum: cli.Checksum(config),
+	}
+	if archive.Instance != nil {
+		// config is returned even if archiving fails
+		if v, err := archive.Instance.Store(req.Device, b.Format, config); err != nil {
+			logs.Error(req.LogPrefix, "archive config error:", err)
+			res.Message = "OK, archive config fail, " + err.Error()
+		} else {
+			logs.Info(req.LogPrefix, "config archived, version", v.Version)
+			res.Version = v.Version
+		}
+	}
+	return res
+}
+
+// DiffConfig return unified diff between archived versions, or archived version and device current config, admin only
+func (s *ConfigHandler) DiffConfig(req *protocol.ConfigDiffRequest, res *protocol.ConfigDiffResponse) error {
+	return s.DiffConfigContext(s.connContext(), req, res)
+}
+
+// DiffConfigContext diff config like DiffConfig, fetching current config stops once ctx done
+func (s *ConfigHandler) DiffConfigContext(ctx context.Context, req *protocol.ConfigDiffRequest, res *protocol.ConfigDiffResponse) error {
+	logs.Info("Received req from", auth.Describe(auth.FromContext(ctx)), req.Device, req.From, req.To, req.Current != nil)
+	*res = s.doDiffConfig(ctx, req)
+	return nil
+}
+
+func (s *ConfigHandler) doDiffConfig(ctx context.Context, req *protocol.ConfigDiffRequest) protocol.ConfigDiffResponse {
+	res := protocol.ConfigDiffResponse{Retcode: common.OK, Message: "OK", Device: req.Device}
+	if err := admit(ctx); err != nil {
+		res.Retcode, res.Message = common.ErrDenied, err.Error()
+		return res
+	}
+	if archive.Instance == nil {
+		res.Retcode, res.Message = common.ErrArchive, "config archive disabled"
+		return res
+	}
+	from, fv, err := archive.Instance.Get(req.Device, req.From)
+	if err != nil {
+		res.Retcode, res.Message = common.ErrArchive, err.Error()
+		return res
+	}
+	res.From = fv.Version
+	fromName := fmt.Sprintf("%s@%d", req.Device, fv.Version)
+	var to, toName string
+	if req.Current != nil {
+		if req.Current.Device == "" {
+			req.Current.Device = req.Device
+		}
+		if req.Current.Format == "" {
+			req.Current.Format = fv.Format
+		}
+		prepare(ctx, req.Current)
+		cur := s.doFetchConfig(ctx, req.Current)
+		if cur.Retcode != common.OK {
+			res.Retcode, res.Message = cur.Retcode, cur.Message
+			return res
+		}
+		to, toName = cur.Config, req.Device+"@current"
+	} else {
+		var tv *archive.Version
+		if to, tv, err = archive.Instance.Get(req.Device, req.To); err != nil {
+			res.Retcode, res.Message = common.ErrArchive, err.Error()
+			return res
+		}
+		res.To = tv.Version
+		toName = fmt.Sprintf("%s@%d", req.Device, tv.Version)
+	}
+	if res.Diff, err = archive.Diff(from, to, fromName, toName); err != nil {
+		res.Retcode, res.Message = common.ErrArchive, err.Error()
+	}
+	return res
+}
+
+func makeConfigErrRes(req *protocol.CliRequest, code int, msg string) protocol.ConfigResponse {
+	return protocol.ConfigResponse{Retcode: code, Message: msg, Device: req.Device}
+}
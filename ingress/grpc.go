@@ -0,0 +1,293 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+
+	"github.com/sky-cloud-tec/netd/auth"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/protocol/pb"
+	"github.com/songtianyi/rrframework/logs"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Grpc serves cli, config and port check handlers over grpc, service netd.Netd of protocol/pb/netd.proto
+type Grpc struct {
+	addr   string
+	server *grpc.Server
+}
+
+// NewGrpc return created grpc instance, connections are tls if cfg not nil
+func NewGrpc(addr string, cfg *tls.Config) (*Grpc, error) {
+	var opts []grpc.ServerOption
+	if cfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(cfg)))
+	}
+	s := &Grpc{addr: addr, server: grpc.NewServer(opts...)}
+	pb.RegisterNetdServer(s.server, new(grpcServer))
+	return s, nil
+}
+
+// Serve start listen tcp port and serve grpc calls
+func (s *Grpc) Serve() error {
+	l, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.serve(l)
+}
+
+func (s *Grpc) serve(l net.Listener) error {
+	logs.Info("grpc listening on", l.Addr())
+	return s.server.Serve(l)
+}
+
+// Stop close listener and all connections
+func (s *Grpc) Stop() {
+	s.server.Stop()
+}
+
+// grpcServer adapts handlers to grpc methods
+type grpcServer struct{}
+
+// grpcAuth return ctx carrying client identity of grpc peer, api token taken from authorization metadata,
+// error if client not authenticated
+func grpcAuth(ctx context.Context) (context.Context, error) {
+	var (
+		state         *tls.ConnectionState
+		addr          string
+		authorization string
+	)
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state = &info.State
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+		authorization = md.Get("authorization")[0]
+	}
+	id, err := auth.Authenticate(state, authorization, addr)
+	if err != nil {
+		logs.Error("grpc client", auth.Describe(id), "denied:", err)
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return auth.NewContext(ctx, id), nil
+}
+
+func (s *grpcServer) Exec(ctx context.Context, in *pb.CliRequest) (*pb.CliResponse, error) {
+	ctx, err := grpcAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := new(protocol.CliResponse)
+	if err := new(CliHandler).HandleContext(ctx, pb.ToCliRequest(in), res, nil); err != nil {
+		return nil, err
+	}
+	return pb.FromCliResponse(res), nil
+}
+
+// ExecStream send result of every command once it's done, then the response
+func (s *grpcServer) ExecStream(in *pb.CliRequest, stream pb.Netd_ExecStreamServer) error {
+	var (
+		mu   sync.Mutex
+		done bool // handler may time out while commands still running
+		req  = pb.ToCliRequest(in)
+	)
+	emit := func(r *protocol.CmdResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return
+		}
+		if err := stream.Send(&pb.CliStreamMessage{Result: pb.FromCmdResult(r)}); err != nil {
+			logs.Error(req.LogPrefix, "send result error:", err)
+		}
+	}
+	ctx, err := grpcAuth(stream.Context())
+	if err != nil {
+		return err
+	}
+	res := new(protocol.CliResponse)
+	err = new(CliHandler).HandleContext(ctx, req, res, emit)
+	mu.Lock()
+	done = true
+	mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return stream.Send(&pb.CliStreamMessage{Response: pb.FromCliResponse(res)})
+}
+
+// Batch send result of every device once it's done, then the response without results
+func (s *grpcServer) Batch(in *pb.BatchRequest, stream pb.Netd_BatchServer) error {
+	req := pb.ToBatchRequest(in)
+	emit := func(r *protocol.BatchResult) {
+		if err := stream.Send(&pb.BatchStreamMessage{Result: pb.FromBatchResult(r)}); err != nil {
+			logs.Error("[ batch ] [", req.Session, "] send result error:", err)
+		}
+	}
+	ctx, err := grpcAuth(stream.Context())
+	if err != nil {
+		return err
+	}
+	res := new(protocol.BatchResponse)
+	if err := new(BatchHandler).RunContext(ctx, req, res, emit); err != nil {
+		return err
+	}
+	res.Results = nil
+	return stream.Send(&pb.BatchStreamMessage{Response: pb.FromBatchResponse(res)})
+}
+
+func (s *grpcServer) FetchConfig(ctx context.Context, in *pb.CliRequest) (*pb.ConfigResponse, error) {
+	ctx, err := grpcAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := new(protocol.ConfigResponse)
+	if err := new(ConfigHandler).FetchConfigContext(ctx, pb.ToCliRequest(in), res); err != nil {
+		return nil, err
+	}
+	return pb.FromConfigResponse(res), nil
+}
+
+func (s *grpcServer) CheckPort(ctx context.Context, in *pb.PortCheckRequest) (*pb.PortCheckResponse, error) {
+	if _, err := grpcAuth(ctx); err != nil {
+		return nil, err
+	}
+	res := new(protocol.PortCheckResponse)
+	if err := new(UtilsHandler).CheckPort(pb.ToPortCheckRequest(in), res); err != nil {
+		return nil, err
+	}
+	return pb.FromPortCheckResponse(res), nil
+}
+
+// GrpcClient calls netd grpc ingress
+type GrpcClient struct {
+	cc *grpc.ClientConn
+	c  pb.NetdClient
+}
+
+// DialGrpc connect to netd grpc ingress, over tls if cfg not nil
+func DialGrpc(addr string, cfg *tls.Config, opts ...grpc.DialOption) (*GrpcClient, error) {
+	if cfg != nil {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	cc, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &GrpcClient{cc: cc, c: pb.NewNetdClient(cc)}, nil
+}
+
+// GrpcToken return option authenticating calls with api token, tls required
+func GrpcToken(token string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(grpcToken(token))
+}
+
+type grpcToken string
+
+func (s grpcToken) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(s)}, nil
+}
+
+func (s grpcToken) RequireTransportSecurity() bool {
+	return true
+}
+
+// Close close connection
+func (s *GrpcClient) Close() error {
+	return s.cc.Close()
+}
+
+// Exec run cli commands
+func (s *GrpcClient) Exec(ctx context.Context, req *protocol.CliRequest) (*protocol.CliResponse, error) {
+	res, err := s.c.Exec(ctx, pb.FromCliRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return pb.ToCliResponse(res), nil
+}
+
+// ExecStream run cli commands, fn is called with every command result once it's done
+func (s *GrpcClient) ExecStream(ctx context.Context, req *protocol.CliRequest, fn func(*protocol.CmdResult)) (*protocol.CliResponse, error) {
+	stream, err := s.c.ExecStream(ctx, pb.FromCliRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if msg.Response != nil {
+			return pb.ToCliResponse(msg.Response), nil
+		}
+		if msg.Result != nil && fn != nil {
+			fn(pb.ToCmdResult(msg.Result))
+		}
+	}
+}
+
+// Batch run commands on many devices, fn is called with every device result once it's done,
+// results are not kept in response returned
+func (s *GrpcClient) Batch(ctx context.Context, req *protocol.BatchRequest, fn func(*protocol.BatchResult)) (*protocol.BatchResponse, error) {
+	stream, err := s.c.Batch(ctx, pb.FromBatchRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if msg.Response != nil {
+			return pb.ToBatchResponse(msg.Response), nil
+		}
+		if msg.Result != nil && fn != nil {
+			fn(pb.ToBatchResult(msg.Result))
+		}
+	}
+}
+
+// FetchConfig dump device config
+func (s *GrpcClient) FetchConfig(ctx context.Context, req *protocol.CliRequest) (*protocol.ConfigResponse, error) {
+	res, err := s.c.FetchConfig(ctx, pb.FromCliRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return pb.ToConfigResponse(res), nil
+}
+
+// CheckPort check port is open or not
+func (s *GrpcClient) CheckPort(ctx context.Context, req *protocol.PortCheckRequest) (*protocol.PortCheckResponse, error) {
+	res, err := s.c.CheckPort(ctx, pb.FromPortCheckRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return pb.ToPortCheckResponse(res), nil
+}
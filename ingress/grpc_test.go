@@ -0,0 +1,105 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/simulator"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGrpc(t *testing.T) {
+	initAppConfig()
+	Convey("handlers over grpc", t, func() {
+		sc, err := simulator.LoadScenario("../simulator/scenarios/juniper_srx.yaml")
+		So(err, ShouldBeNil)
+		srv, err := simulator.NewServer(sc)
+		So(err, ShouldBeNil)
+		defer srv.Close()
+		addr, err := srv.ListenSSH("127.0.0.1:0")
+		So(err, ShouldBeNil)
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		g, err := NewGrpc("", nil)
+		So(err, ShouldBeNil)
+		go g.serve(l)
+		defer g.Stop()
+		c, err := DialGrpc(l.Addr().String(), nil)
+		So(err, ShouldBeNil)
+		defer c.Close()
+		ctx := context.Background()
+
+		req := simulatedRequest(sc, addr.String(), "ssh")
+		req.Mode = "login"
+		req.Commands = []string{"show version"}
+		res, err := c.Exec(ctx, req)
+		So(err, ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.OK)
+		So(res.CmdsStd["show version"], ShouldContainSubstring, "JUNOS")
+
+		req = simulatedRequest(sc, addr.String(), "ssh")
+		req.Mode = "login"
+		req.Commands = []string{"show version", "show configuration | display set | no-more"}
+		var streamed []string
+		res, err = c.ExecStream(ctx, req, func(r *protocol.CmdResult) {
+			streamed = append(streamed, r.Command)
+		})
+		So(err, ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.OK)
+		So(streamed, ShouldResemble, req.Commands)
+
+		var devices []string
+		br, err := c.Batch(ctx, &protocol.BatchRequest{
+			Devices:  []*protocol.CliRequest{simulatedRequest(sc, addr.String(), "ssh")},
+			Commands: []string{"show version"},
+			Mode:     "login",
+		}, func(r *protocol.BatchResult) {
+			devices = append(devices, r.Device)
+		})
+		So(err, ShouldBeNil)
+		So(br.Retcode, ShouldEqual, common.OK)
+		So(devices, ShouldResemble, []string{sc.Name})
+		So(br.Summary.Succeeded, ShouldEqual, 1)
+		So(br.Results, ShouldBeEmpty)
+
+		req = simulatedRequest(sc, addr.String(), "ssh")
+		cfg, err := c.FetchConfig(ctx, req)
+		So(err, ShouldBeNil)
+		So(cfg.Retcode, ShouldEqual, common.OK)
+		So(cfg.Format, ShouldEqual, "set")
+		So(cfg.Config, ShouldContainSubstring, "set system host-name")
+
+		host, port, _ := net.SplitHostPort(addr.String())
+		pr, err := c.CheckPort(ctx, &protocol.PortCheckRequest{IP: host, Port: port, Timeout: 1})
+		So(err, ShouldBeNil)
+		So(pr.Retcode, ShouldEqual, common.OK)
+		// a port just released is most likely closed
+		cl, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		closed := strconv.Itoa(cl.Addr().(*net.TCPAddr).Port)
+		cl.Close()
+		pr, err = c.CheckPort(ctx, &protocol.PortCheckRequest{IP: host, Port: closed, Timeout: 1})
+		So(err, ShouldBeNil)
+		So(pr.Retcode, ShouldEqual, common.ErrPortClosed)
+	})
+}
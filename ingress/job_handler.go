@@ -0,0 +1,182 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sky-cloud-tec/netd/auth"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/job"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/songtianyi/rrframework/logs"
+)
+
+// JobHandler serve asynchronous cli requests, see job.Manager
+type JobHandler struct {
+	jrpcConn
+}
+
+// RunJob is the job.Runner handling requests like CliHandler, once job canceled
+// no more command is sent and it returns after session recovered
+func RunJob(ctx context.Context, req *protocol.CliRequest, res *protocol.CliResponse, emit func(*protocol.CmdResult)) {
+	new(CliHandler).HandleContext(ctx, req, res, emit)
+}
+
+// Submit create job of cli request, job id returned at once
+func (s *JobHandler) Submit(req *protocol.CliRequest, res *protocol.JobResponse) error {
+	return s.SubmitContext(s.connContext(), req, res)
+}
+
+// SubmitContext create job like Submit, job is run on behalf of client of ctx
+func (s *JobHandler) SubmitContext(ctx context.Context, req *protocol.CliRequest, res *protocol.JobResponse) error {
+	if job.Instance == nil {
+		*res = makeJobErrRes(common.ErrJob, "jobs disabled")
+		return nil
+	}
+	client := auth.FromContext(ctx)
+	// checked again once job runs, policy may have changed
+	if auth.Instance != nil {
+		if err := auth.Instance.Authorize(client, req); err != nil {
+			logs.Error("job for", req.Device, "denied:", err)
+			*res = makeJobErrRes(common.ErrDenied, err.Error())
+			return nil
+		}
+	}
+	j, err := job.Instance.Submit(req, client)
+	if err != nil {
+		logs.Error("submit job error:", err)
+		*res = makeJobErrRes(common.ErrJob, err.Error())
+		return nil
+	}
+	logs.Info("job", j.ID, "submitted for", req.Device, "by", auth.Describe(client))
+	*res = protocol.JobResponse{Retcode: common.OK, Message: "OK", Job: j}
+	return nil
+}
+
+// Status return job with results so far
+func (s *JobHandler) Status(req *protocol.JobRequest, res *protocol.JobResponse) error {
+	return s.StatusContext(s.connContext(), req, res)
+}
+
+// StatusContext return job like Status, only to client submitted it or admin
+func (s *JobHandler) StatusContext(ctx context.Context, req *protocol.JobRequest, res *protocol.JobResponse) error {
+	if job.Instance == nil {
+		*res = makeJobErrRes(common.ErrJob, "jobs disabled")
+		return nil
+	}
+	j, err := job.Instance.Get(req.ID)
+	if err != nil {
+		*res = makeJobErrRes(jobRetcode(err), err.Error())
+		return nil
+	}
+	if err := jobAllowed(ctx, j); err != nil {
+		logs.Error("job", j.ID, "status denied:", err)
+		*res = makeJobErrRes(common.ErrDenied, err.Error())
+		return nil
+	}
+	*res = protocol.JobResponse{Retcode: common.OK, Message: "OK", Job: j}
+	return nil
+}
+
+// List return jobs matching request
+func (s *JobHandler) List(req *protocol.JobListRequest, res *protocol.JobListResponse) error {
+	return s.ListContext(s.connContext(), req, res)
+}
+
+// ListContext return jobs like List, jobs of other clients are left out unless admin
+func (s *JobHandler) ListContext(ctx context.Context, req *protocol.JobListRequest, res *protocol.JobListResponse) error {
+	if job.Instance == nil {
+		*res = protocol.JobListResponse{Retcode: common.ErrJob, Message: "jobs disabled"}
+		return nil
+	}
+	jobs, err := job.Instance.List(req.Device, req.State)
+	if err != nil {
+		*res = protocol.JobListResponse{Retcode: common.ErrJob, Message: err.Error()}
+		return nil
+	}
+	allowed := jobs[:0]
+	for _, j := range jobs {
+		if jobAllowed(ctx, j) == nil {
+			allowed = append(allowed, j)
+		}
+	}
+	*res = protocol.JobListResponse{Retcode: common.OK, Message: "OK", Jobs: allowed}
+	return nil
+}
+
+// Cancel job, running job stops before its next command
+func (s *JobHandler) Cancel(req *protocol.JobRequest, res *protocol.JobResponse) error {
+	return s.CancelContext(s.connContext(), req, res)
+}
+
+// CancelContext cancel job like Cancel, only by client submitted it or admin
+func (s *JobHandler) CancelContext(ctx context.Context, req *protocol.JobRequest, res *protocol.JobResponse) error {
+	if job.Instance == nil {
+		*res = makeJobErrRes(common.ErrJob, "jobs disabled")
+		return nil
+	}
+	j, err := job.Instance.Get(req.ID)
+	if err == nil {
+		if err = jobAllowed(ctx, j); err != nil {
+			logs.Error("job", j.ID, "cancel denied:", err)
+			*res = makeJobErrRes(common.ErrDenied, err.Error())
+			return nil
+		}
+		j, err = job.Instance.Cancel(req.ID)
+	}
+	if err != nil {
+		*res = makeJobErrRes(jobRetcode(err), err.Error())
+		return nil
+	}
+	logs.Info("job", j.ID, "cancel requested by", auth.Describe(auth.FromContext(ctx)))
+	*res = protocol.JobResponse{Retcode: common.OK, Message: "OK", Job: j}
+	return nil
+}
+
+// jobAllowed check client of ctx is admin, or submitted j and is still allowed its request.
+// anonymous clients share roles, they are one client here
+func jobAllowed(ctx context.Context, j *protocol.Job) error {
+	if auth.Instance == nil {
+		return nil
+	}
+	id := auth.FromContext(ctx)
+	if auth.Instance.Admin(id) == nil {
+		return nil
+	}
+	if j.Client == nil || j.Client.Method != id.Method || j.Client.Name != id.Name {
+		return fmt.Errorf("job %s not submitted by client %s", j.ID, auth.Describe(id))
+	}
+	if j.Request == nil {
+		return fmt.Errorf("job %s has no request", j.ID)
+	}
+	return auth.Instance.Authorize(id, j.Request)
+}
+
+func jobRetcode(err error) int {
+	switch err {
+	case job.ErrNotFound:
+		return common.ErrNoJob
+	case job.ErrFinished:
+		return common.ErrJobState
+	}
+	return common.ErrJob
+}
+
+func makeJobErrRes(code int, msg string) protocol.JobResponse {
+	return protocol.JobResponse{Retcode: code, Message: msg}
+}
@@ -16,26 +16,43 @@
 package ingress
 
 import (
+	"context"
+	"crypto/tls"
 	"net"
 	"net/rpc"
 	"net/rpc/jsonrpc"
+	"reflect"
+	"sync"
+	"time"
 
+	"github.com/sky-cloud-tec/netd/auth"
+	"github.com/sky-cloud-tec/netd/protocol"
 	"github.com/songtianyi/rrframework/logs"
 )
 
+// jrpcHandshakeTimeout bounds tls handshake of jrpc connections
+const jrpcHandshakeTimeout = 10 * time.Second
+
 // Jrpc struct
 type Jrpc struct {
-	addr string
+	addr     string
+	tls      *tls.Config
+	handlers []interface{}
 }
 
-// NewJrpc return created jrpc instance
-func NewJrpc(addr string) (*Jrpc, error) {
-	return &Jrpc{addr: addr}, nil
+// NewJrpc return created jrpc instance, connections are tls if cfg not nil
+func NewJrpc(addr string, cfg *tls.Config) (*Jrpc, error) {
+	return &Jrpc{addr: addr, tls: cfg}, nil
 }
 
-// Register do jrpc logic method registration
+// Register do jrpc logic method registration, handlers embedding jrpcConn
+// are created per connection and know the client of it
 func (s *Jrpc) Register(method interface{}) error {
-	return rpc.Register(method)
+	if err := rpc.NewServer().Register(method); err != nil {
+		return err
+	}
+	s.handlers = append(s.handlers, method)
+	return nil
 }
 
 // Serve start listen tcp port and accept jrpc calls
@@ -44,12 +61,115 @@ func (s *Jrpc) Serve() error {
 	if e != nil {
 		return e
 	}
+	if s.tls != nil {
+		listener = tls.NewListener(listener, s.tls)
+	}
+	return s.serve(listener)
+}
+
+func (s *Jrpc) serve(listener net.Listener) error {
+	logs.Info("jrpc listening on", listener.Addr(), "tls", s.tls != nil)
 	for {
-		if conn, err := listener.Accept(); err != nil {
-			logs.Error("accept error: " + err.Error())
-		} else {
-			logs.Info("new jrpc connection established")
-			go jsonrpc.ServeConn(conn)
+		conn, err := listener.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				logs.Error("accept error: " + err.Error())
+				continue
+			}
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn serve calls of connection with handlers bound to its client
+func (s *Jrpc) serveConn(conn net.Conn) {
+	var state *tls.ConnectionState
+	if tc, ok := conn.(*tls.Conn); ok {
+		tc.SetDeadline(time.Now().Add(jrpcHandshakeTimeout))
+		if err := tc.Handshake(); err != nil {
+			logs.Error("jrpc tls handshake with", conn.RemoteAddr(), "error:", err)
+			conn.Close()
+			return
 		}
+		tc.SetDeadline(time.Time{})
+		cs := tc.ConnectionState()
+		state = &cs
+	}
+	id := auth.FromTLS(state, conn.RemoteAddr().String())
+	logs.Info("new jrpc connection established from", auth.Describe(id))
+	session := &jrpcSession{ctx: auth.NewContext(context.Background(), id)}
+	srv := rpc.NewServer()
+	for _, v := range s.handlers {
+		h := v
+		if _, ok := v.(jrpcBinder); ok {
+			h = reflect.New(reflect.TypeOf(v).Elem()).Interface()
+			h.(jrpcBinder).bind(session)
+		}
+		srv.RegisterName(reflect.Indirect(reflect.ValueOf(v)).Type().Name(), h)
+	}
+	srv.ServeCodec(&jrpcAuthCodec{ServerCodec: jsonrpc.NewServerCodec(conn), session: session})
+}
+
+// jrpcAuthCodec fails calls of clients without role once authorization enabled, login excepted
+type jrpcAuthCodec struct {
+	rpc.ServerCodec
+	session *jrpcSession
+	method  string
+}
+
+func (s *jrpcAuthCodec) ReadRequestHeader(r *rpc.Request) error {
+	err := s.ServerCodec.ReadRequestHeader(r)
+	s.method = r.ServiceMethod
+	return err
+}
+
+func (s *jrpcAuthCodec) ReadRequestBody(x interface{}) error {
+	if err := s.ServerCodec.ReadRequestBody(x); err != nil || x == nil {
+		return err
+	}
+	if auth.Instance == nil || s.method == "AuthHandler.Login" {
+		return nil
 	}
+	return auth.Instance.Authenticated(auth.FromContext(s.session.context()))
+}
+
+// jrpcSession is the client of jrpc connection, shared by handlers of it
+type jrpcSession struct {
+	mu  sync.Mutex
+	ctx context.Context
+}
+
+func (s *jrpcSession) context() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ctx
+}
+
+type jrpcBinder interface {
+	bind(session *jrpcSession)
+}
+
+// jrpcConn is embedded by handlers to know the client of jrpc connection they serve
+type jrpcConn struct {
+	session *jrpcSession
+}
+
+func (s *jrpcConn) bind(session *jrpcSession) {
+	s.session = session
+}
+
+// connContext return ctx carrying client identity of jrpc connection, background if not served by jrpc
+func (s *jrpcConn) connContext() context.Context {
+	if s.session == nil {
+		return context.Background()
+	}
+	return s.session.context()
+}
+
+// login change client identity of jrpc connection, calls after it run on behalf of id
+func (s *jrpcConn) login(id *protocol.Identity) {
+	s.session.mu.Lock()
+	defer s.session.mu.Unlock()
+	s.session.ctx = auth.NewContext(s.session.ctx, id)
 }
@@ -0,0 +1,199 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/inventory"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/simulator"
+	"github.com/sky-cloud-tec/netd/vault"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// TestSimulator run scenario cases of every shipped operator through CliHandler
+func TestSimulator(t *testing.T) {
+	initAppConfig()
+	scs, err := simulator.LoadScenarios("../simulator/scenarios")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// devices live through all cases, so cached conns are exercised too
+	addrs := make([]string, len(scs))
+	for i, sc := range scs {
+		srv, err := simulator.NewServer(sc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer srv.Close()
+		addr, err := srv.ListenSSH("127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs[i] = addr.String()
+	}
+	Convey("operators against simulated devices", t, func() {
+		So(len(scs), ShouldBeGreaterThan, 0)
+		for i, sc := range scs {
+			sc, addr := sc, addrs[i]
+			for _, c := range sc.Cases {
+				c := c
+				Convey(sc.Name+" "+c.Name, func() {
+					req := simulatedRequest(sc, addr, "ssh")
+					req.Mode = c.Mode
+					req.Commands = c.Commands
+					req.OutputEncoding = c.OutputEncoding
+					var res protocol.CliResponse
+					So(new(CliHandler).Handle(req, &res), ShouldBeNil)
+					So(res.Retcode, ShouldEqual, c.Retcode)
+					for cmd, v := range c.Expect {
+						So(res.CmdsStd[cmd], ShouldContainSubstring, v)
+					}
+				})
+			}
+		}
+	})
+}
+
+func TestSimulatorTelnet(t *testing.T) {
+	initAppConfig()
+	Convey("telnet login and exec", t, func() {
+		sc, err := simulator.LoadScenario("../simulator/scenarios/juniper_srx.yaml")
+		So(err, ShouldBeNil)
+		srv, err := simulator.NewServer(sc)
+		So(err, ShouldBeNil)
+		defer srv.Close()
+		addr, err := srv.ListenTelnet("127.0.0.1:0")
+		So(err, ShouldBeNil)
+		flag := common.AppConfigInstance.LogCfgFlag
+		common.AppConfigInstance.LogCfgFlag = 3
+		defer func() { common.AppConfigInstance.LogCfgFlag = flag }()
+
+		req := simulatedRequest(sc, addr.String(), "telnet")
+		req.Mode = "login"
+		req.Commands = []string{"show version"}
+		var res protocol.CliResponse
+		So(new(CliHandler).Handle(req, &res), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.OK)
+		So(res.CmdsStd["show version"], ShouldContainSubstring, "JUNOS Software Release")
+	})
+}
+
+func initAppConfig() {
+	if common.AppConfigInstance == nil {
+		common.AppConfigInstance = &common.AppConfig{Confidence: 30}
+	}
+}
+
+func simulatedRequest(sc *simulator.Scenario, addr, proto string) *protocol.CliRequest {
+	return &protocol.CliRequest{
+		Device:    sc.Name,
+		Vendor:    sc.Vendor,
+		Type:      sc.Type,
+		Version:   sc.Version,
+		Address:   addr,
+		Protocol:  proto,
+		Auth:      protocol.Auth{Username: sc.Username, Password: sc.Password},
+		EnablePwd: sc.EnablePassword,
+		Timeout:   5,
+	}
+}
+
+func TestSimulatorVault(t *testing.T) {
+	initAppConfig()
+	Convey("credentials resolved from vault, fallbacks tried in order", t, func() {
+		sc, err := simulator.LoadScenario("../simulator/scenarios/juniper_srx.yaml")
+		So(err, ShouldBeNil)
+		srv, err := simulator.NewServer(sc)
+		So(err, ShouldBeNil)
+		defer srv.Close()
+		addr, err := srv.ListenSSH("127.0.0.1:0")
+		So(err, ShouldBeNil)
+
+		dir, err := ioutil.TempDir("", "netd-vault")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		b, err := vault.NewFileBackend(filepath.Join(dir, "vault.json"), []byte("master"))
+		So(err, ShouldBeNil)
+		vault.Instance = vault.New(b)
+		defer func() { vault.Instance = nil }()
+		_, err = vault.Instance.Create(&vault.Credential{ID: "stale", Username: sc.Username, Password: "stale"})
+		So(err, ShouldBeNil)
+		_, err = vault.Instance.Create(&vault.Credential{ID: "current", Username: sc.Username, Password: sc.Password})
+		So(err, ShouldBeNil)
+		So(vault.Instance.SetFallbacks(sc.Name, []string{"current"}), ShouldBeNil)
+		inventory.Instance = &inventory.Inventory{Devices: []*inventory.Device{{Name: sc.Name, Address: addr.String(), Credential: "stale"}}}
+		defer func() { inventory.Instance = nil }()
+
+		req := simulatedRequest(sc, addr.String(), "ssh")
+		req.Auth = protocol.Auth{Credential: "stale"}
+		req.Mode = "login"
+		req.Commands = []string{"show version"}
+		var res protocol.CliResponse
+		So(new(CliHandler).Handle(req, &res), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.OK)
+		So(req.Auth.Credential, ShouldEqual, "current")
+
+		req = simulatedRequest(sc, addr.String(), "ssh")
+		req.Auth = protocol.Auth{Credential: "none"}
+		req.Mode = "login"
+		req.Commands = []string{"show version"}
+		So(new(CliHandler).Handle(req, &res), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.ErrAcquireConn)
+	})
+}
+
+func TestSimulatorCancel(t *testing.T) {
+	initAppConfig()
+	Convey("canceled request stops at command boundary and releases device", t, func() {
+		sc, err := simulator.LoadScenario("../simulator/scenarios/juniper_srx.yaml")
+		So(err, ShouldBeNil)
+		srv, err := simulator.NewServer(sc)
+		So(err, ShouldBeNil)
+		defer srv.Close()
+		addr, err := srv.ListenSSH("127.0.0.1:0")
+		So(err, ShouldBeNil)
+
+		req := simulatedRequest(sc, addr.String(), "ssh")
+		req.Mode = "login"
+		req.Commands = []string{"show version", "show version", "show version"}
+		ctx, cancel := context.WithCancel(context.Background())
+		var res protocol.CliResponse
+		So(new(CliHandler).HandleContext(ctx, req, &res, func(*protocol.CmdResult) { cancel() }), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.ErrCanceled)
+		So(res.Results, ShouldHaveLength, 1)
+
+		// device slot released, session reused
+		req = simulatedRequest(sc, addr.String(), "ssh")
+		req.Mode = "login"
+		req.Commands = []string{"show version"}
+		So(new(CliHandler).Handle(req, &res), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.OK)
+
+		// transaction of gone client reports cancel
+		tx := &protocol.TxRequest{CliRequest: *simulatedRequest(sc, addr.String(), "ssh")}
+		tx.Commands = []string{"set system host-name srx1"}
+		ctx, cancel = context.WithCancel(context.Background())
+		cancel()
+		So(new(ConfigHandler).doTransact(ctx, tx).Retcode, ShouldEqual, common.ErrCanceled)
+	})
+}
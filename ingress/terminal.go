@@ -0,0 +1,139 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sky-cloud-tec/netd/auth"
+	"github.com/sky-cloud-tec/netd/cli"
+	"github.com/sky-cloud-tec/netd/cli/conn"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/songtianyi/rrframework/logs"
+)
+
+// TerminalIdleTimeout closes web terminals without keystrokes for the duration
+var TerminalIdleTimeout = 10 * time.Minute
+
+// terminalOpenTimeout is how long to wait for the open message
+const terminalOpenTimeout = 30 * time.Second
+
+// ServeTerminal relay interactive terminal on websocket, see protocol.TerminalMessage.
+// The first message opens terminal to device of its request, then keystrokes and resizes
+// are relayed to device and device output is sent back, until either side closes.
+// ctx carries the client identity, it is logged with the request.
+func ServeTerminal(ctx context.Context, ws *websocket.Conn) {
+	defer ws.Close()
+	var wmu sync.Mutex // one writer at a time
+	send := func(typ int, b []byte) error {
+		wmu.Lock()
+		defer wmu.Unlock()
+		ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return ws.WriteMessage(typ, b)
+	}
+	sendJSON := func(m *protocol.TerminalMessage) error {
+		b, _ := json.Marshal(m)
+		return send(websocket.TextMessage, b)
+	}
+	fail := func(msg string) {
+		sendJSON(&protocol.TerminalMessage{Type: protocol.TerminalError, Data: msg})
+	}
+
+	var m protocol.TerminalMessage
+	ws.SetReadDeadline(time.Now().Add(terminalOpenTimeout))
+	if err := ws.ReadJSON(&m); err != nil || m.Type != protocol.TerminalOpen || m.Request == nil {
+		fail("expect open message with request")
+		return
+	}
+	req := m.Request
+	prepare(ctx, req)
+	t := strings.Join([]string{req.Vendor, req.Type, req.Version}, ".")
+	op := cli.OperatorManagerInstance.Get(t)
+	if op == nil {
+		logs.Error(req.LogPrefix, "no operator match", t)
+		fail("no operator match " + t)
+		return
+	}
+	if auth.Instance != nil {
+		if err := auth.Instance.AuthorizeTerminal(auth.FromContext(ctx), req); err != nil {
+			logs.Error(req.LogPrefix, err)
+			fail(err.Error())
+			return
+		}
+	}
+	term, err := conn.OpenTerminal(req, op, m.Cols, m.Rows)
+	if err != nil {
+		logs.Error(req.LogPrefix, "open terminal fail,", err)
+		fail("open terminal fail, " + err.Error())
+		return
+	}
+	defer term.Close()
+	if err := sendJSON(&protocol.TerminalMessage{Type: protocol.TerminalOpened, Data: term.Recording()}); err != nil {
+		return
+	}
+
+	// device output
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := term.Read(buf)
+			if n > 0 {
+				if err := send(websocket.BinaryMessage, buf[:n]); err != nil {
+					break
+				}
+			}
+			if err != nil {
+				logs.Info(req.LogPrefix, "terminal read stopped:", err)
+				break
+			}
+		}
+		// device closed, stop reading client too
+		send(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "device closed"))
+		ws.Close()
+	}()
+
+	// client input
+	for {
+		ws.SetReadDeadline(time.Now().Add(TerminalIdleTimeout))
+		var m protocol.TerminalMessage
+		if err := ws.ReadJSON(&m); err != nil {
+			if e, ok := err.(interface{ Timeout() bool }); ok && e.Timeout() {
+				logs.Info(req.LogPrefix, "terminal idle timeout")
+				send(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "idle timeout"))
+			}
+			return
+		}
+		switch m.Type {
+		case protocol.TerminalInput:
+			_, err = term.Write([]byte(m.Data))
+		case protocol.TerminalResize:
+			err = term.Resize(m.Cols, m.Rows)
+		default:
+			err = fmt.Errorf("unknown message type %s", m.Type)
+		}
+		if err != nil {
+			logs.Error(req.LogPrefix, "terminal error:", err)
+			fail(err.Error())
+			return
+		}
+	}
+}
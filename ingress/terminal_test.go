@@ -0,0 +1,104 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/simulator"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTerminal(t *testing.T) {
+	initAppConfig()
+	Convey("terminal relayed over websocket", t, func() {
+		sc, err := simulator.LoadScenario("../simulator/scenarios/juniper_srx.yaml")
+		So(err, ShouldBeNil)
+		srv, err := simulator.NewServer(sc)
+		So(err, ShouldBeNil)
+		defer srv.Close()
+		addr, err := srv.ListenSSH("127.0.0.1:0")
+		So(err, ShouldBeNil)
+
+		upgrader := websocket.Upgrader{}
+		hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ws, err := upgrader.Upgrade(w, r, nil)
+			if err == nil {
+				ServeTerminal(r.Context(), ws)
+			}
+		}))
+		defer hs.Close()
+		open := func() (*websocket.Conn, *protocol.TerminalMessage) {
+			ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(hs.URL, "http"), nil)
+			So(err, ShouldBeNil)
+			req := simulatedRequest(sc, addr.String(), "ssh")
+			So(ws.WriteJSON(&protocol.TerminalMessage{Type: protocol.TerminalOpen, Request: req, Cols: 120, Rows: 40}), ShouldBeNil)
+			var m protocol.TerminalMessage
+			So(ws.ReadJSON(&m), ShouldBeNil)
+			return ws, &m
+		}
+		// read device output until it contains x
+		readUntil := func(ws *websocket.Conn, x string) string {
+			var out string
+			ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+			for !strings.Contains(out, x) {
+				typ, b, err := ws.ReadMessage()
+				if err != nil {
+					break
+				}
+				if typ == websocket.BinaryMessage {
+					out += string(b)
+				}
+			}
+			return out
+		}
+
+		ws, m := open()
+		defer ws.Close()
+		So(m.Type, ShouldEqual, protocol.TerminalOpened)
+		So(readUntil(ws, "> "), ShouldContainSubstring, "> ")
+		So(ws.WriteJSON(&protocol.TerminalMessage{Type: protocol.TerminalResize, Cols: 100, Rows: 30}), ShouldBeNil)
+		So(ws.WriteJSON(&protocol.TerminalMessage{Type: protocol.TerminalInput, Data: "show version\n"}), ShouldBeNil)
+		So(readUntil(ws, "JUNOS"), ShouldContainSubstring, "JUNOS")
+
+		// one terminal per device by default
+		ws2, m := open()
+		defer ws2.Close()
+		So(m.Type, ShouldEqual, protocol.TerminalError)
+		So(m.Data, ShouldContainSubstring, "terminal limit")
+
+		// slot released once closed
+		ws.Close()
+		time.Sleep(200 * time.Millisecond)
+		idle := TerminalIdleTimeout
+		TerminalIdleTimeout = 300 * time.Millisecond
+		defer func() { TerminalIdleTimeout = idle }()
+		ws3, m := open()
+		defer ws3.Close()
+		So(m.Type, ShouldEqual, protocol.TerminalOpened)
+		// output read until closed for idle
+		readUntil(ws3, "idle timeout")
+		_, _, err = ws3.ReadMessage()
+		So(websocket.IsCloseError(err, websocket.CloseNormalClosure), ShouldBeTrue)
+		So(err.Error(), ShouldContainSubstring, "idle timeout")
+	})
+}
@@ -0,0 +1,110 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package ingress
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sky-cloud-tec/netd/auth"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/job"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/sky-cloud-tec/netd/tlsconf"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTLS(t *testing.T) {
+	Convey("jrpc and grpc over mutual tls know their clients", t, func() {
+		dir, err := ioutil.TempDir("", "tls")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		// jobs record who submitted them
+		clients := make(chan *protocol.Identity, 2)
+		m, err := job.New(filepath.Join(dir, "jobs.db"), 1, func(ctx context.Context, req *protocol.CliRequest, res *protocol.CliResponse, emit func(*protocol.CmdResult)) {
+			clients <- auth.FromContext(ctx)
+			*res = protocol.CliResponse{Retcode: common.OK, Message: "OK"}
+		})
+		So(err, ShouldBeNil)
+		defer m.Close()
+		job.Instance = m
+		defer func() { job.Instance = nil }()
+
+		cfg, err := tlsconf.New("../tlsconf/testdata/server.pem", "../tlsconf/testdata/server.key", "../tlsconf/testdata/ca.pem", false)
+		So(err, ShouldBeNil)
+		b, err := ioutil.ReadFile("../tlsconf/testdata/ca.pem")
+		So(err, ShouldBeNil)
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM(b)
+		pair, err := tls.LoadX509KeyPair("../tlsconf/testdata/client.pem", "../tlsconf/testdata/client.key")
+		So(err, ShouldBeNil)
+		cc := &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: []tls.Certificate{pair}}
+		req := &protocol.CliRequest{Device: "fw1", Commands: []string{"show version"}}
+
+		j, err := NewJrpc("", cfg.TLS())
+		So(err, ShouldBeNil)
+		So(j.Register(new(JobHandler)), ShouldBeNil)
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer l.Close()
+		go j.serve(tls.NewListener(l, j.tls))
+
+		conn, err := tls.Dial("tcp", l.Addr().String(), cc)
+		So(err, ShouldBeNil)
+		c := jsonrpc.NewClient(conn)
+		defer c.Close()
+		var res protocol.JobResponse
+		So(c.Call("JobHandler.Submit", req, &res), ShouldBeNil)
+		So(res.Retcode, ShouldEqual, common.OK)
+		So(res.Job.Client.Name, ShouldEqual, "ops-portal")
+		So(res.Job.Client.Subject, ShouldContainSubstring, "O=netops")
+		So(res.Job.Client.SANs, ShouldResemble, []string{"ops-portal.example.com"})
+		So((<-clients).Name, ShouldEqual, "ops-portal")
+
+		// client without certificate rejected
+		conn, err = tls.Dial("tcp", l.Addr().String(), &tls.Config{RootCAs: roots, ServerName: "localhost"})
+		if err == nil {
+			_, err = conn.Read(make([]byte, 1))
+			conn.Close()
+		}
+		So(err, ShouldNotBeNil)
+
+		gl, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		g, err := NewGrpc("", cfg.TLS("h2"))
+		So(err, ShouldBeNil)
+		go g.serve(gl)
+		defer g.Stop()
+		gc, err := DialGrpc(gl.Addr().String(), cc)
+		So(err, ShouldBeNil)
+		defer gc.Close()
+		br, err := gc.Batch(context.Background(), &protocol.BatchRequest{Commands: []string{"show version"}}, nil)
+		So(err, ShouldBeNil)
+		So(br.Retcode, ShouldEqual, common.ErrBadRequest)
+		gc, err = DialGrpc(gl.Addr().String(), &tls.Config{RootCAs: roots, ServerName: "localhost"})
+		So(err, ShouldBeNil)
+		defer gc.Close()
+		_, err = gc.Batch(context.Background(), &protocol.BatchRequest{Commands: []string{"show version"}}, nil)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -16,6 +16,8 @@
 package ingress
 
 import (
+	"net"
+	"strings"
 	"time"
 
 	"github.com/sky-cloud-tec/netd/common"
@@ -54,20 +56,42 @@ func (s *UtilsHandler) CheckPort(req *protocol.PortCheckRequest, res *protocol.P
 
 	go func() {
 		logs.Info(req.LogPrefix, "==========START==========")
+		ch <- s.doCheckPort(req, res)
 		logs.Info(req.LogPrefix, "==========END==========")
 	}()
 
-	// timeout
+	// timeout, dial times out itself, leave it some room
 	select {
 	case res := <-ch:
 		return res
-	case <-time.After(req.Timeout):
-		*res = makeUtilsErrRes(common.ErrNoOpFound, "handle req timeout")
+	case <-time.After(req.Timeout + time.Second):
+		*res = makeUtilsErrRes(common.ErrTimeout, "handle req timeout")
 	}
 
 	return nil
 }
 
+func (s *UtilsHandler) doCheckPort(req *protocol.PortCheckRequest, res *protocol.PortCheckResponse) error {
+	proto := strings.ToLower(req.Proto)
+	if proto == "" {
+		proto = "tcp"
+	}
+	if proto != "tcp" && proto != "tcp4" && proto != "tcp6" {
+		// udp has no handshake to tell
+		*res = makeUtilsErrRes(common.ErrPortClosed, "proto "+req.Proto+" not support")
+		return nil
+	}
+	c, err := net.DialTimeout(proto, net.JoinHostPort(req.IP, req.Port), req.Timeout)
+	if err != nil {
+		logs.Info(req.LogPrefix, "port check failed:", err)
+		*res = makeUtilsErrRes(common.ErrPortClosed, err.Error())
+		return nil
+	}
+	c.Close()
+	*res = makeUtilsErrRes(common.OK, "OK")
+	return nil
+}
+
 func makeUtilsErrRes(code int, msg string) protocol.PortCheckResponse {
 	return protocol.PortCheckResponse{Retcode: code, Message: msg}
 }
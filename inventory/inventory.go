@@ -0,0 +1,126 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package inventory loads devices from a yaml file, batch requests select devices from it by name, vendor and labels.
+// Credentials are not stored here, devices refer to vault credentials.
+package inventory
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/sky-cloud-tec/netd/protocol"
+	"gopkg.in/yaml.v2"
+)
+
+// Instance is the inventory batch devices selected from, nil if not configured
+var Instance *Inventory
+
+// Device is an inventory device
+type Device struct {
+	Name       string            `yaml:"name"`
+	Vendor     string            `yaml:"vendor"`
+	Type       string            `yaml:"type"`
+	Version    string            `yaml:"version"`
+	Address    string            `yaml:"address"`
+	Protocol   string            `yaml:"protocol"`   // ssh by default
+	Username   string            `yaml:"username"`   // username, credential one if empty
+	Credential string            `yaml:"credential"` // vault credential id, device fallbacks used if empty
+	Labels     map[string]string `yaml:"labels"`
+}
+
+// Inventory is a list of devices
+type Inventory struct {
+	Devices []*Device `yaml:"devices"`
+}
+
+// Load inventory from yaml file
+func Load(path string) (*Inventory, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read inventory error: %s", err)
+	}
+	var s Inventory
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("parse inventory %s error: %s", path, err)
+	}
+	names := make(map[string]bool, len(s.Devices))
+	for i, v := range s.Devices {
+		if v.Name == "" || v.Address == "" {
+			return nil, fmt.Errorf("inventory device %d: name and address required", i)
+		}
+		if names[v.Name] {
+			return nil, fmt.Errorf("inventory device %s duplicated", v.Name)
+		}
+		names[v.Name] = true
+		if v.Protocol == "" {
+			v.Protocol = "ssh"
+		}
+	}
+	return &s, nil
+}
+
+// Select return devices matching selector, in inventory order
+func (s *Inventory) Select(sel *protocol.DeviceSelector) ([]*Device, error) {
+	var name *regexp.Regexp
+	if sel.Name != "" {
+		var err error
+		if name, err = regexp.Compile(sel.Name); err != nil {
+			return nil, fmt.Errorf("compile name selector error: %s", err)
+		}
+	}
+	devices := make([]*Device, 0)
+next:
+	for _, v := range s.Devices {
+		if name != nil && !name.MatchString(v.Name) {
+			continue
+		}
+		if sel.Vendor != "" && !strings.EqualFold(sel.Vendor, v.Vendor) {
+			continue
+		}
+		for k, l := range sel.Labels {
+			if x, ok := v.Labels[k]; !ok || x != l {
+				continue next
+			}
+		}
+		devices = append(devices, v)
+	}
+	return devices, nil
+}
+
+// ByAddress return device of address, nil if address not in inventory
+func (s *Inventory) ByAddress(addr string) *Device {
+	for _, v := range s.Devices {
+		if v.Address == addr {
+			return v
+		}
+	}
+	return nil
+}
+
+// Request return cli request of device
+func (d *Device) Request() *protocol.CliRequest {
+	return &protocol.CliRequest{
+		Device:   d.Name,
+		Vendor:   d.Vendor,
+		Type:     d.Type,
+		Version:  d.Version,
+		Address:  d.Address,
+		Protocol: d.Protocol,
+		Auth:     protocol.Auth{Username: d.Username, Credential: d.Credential},
+	}
+}
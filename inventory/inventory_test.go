@@ -0,0 +1,100 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package inventory
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sky-cloud-tec/netd/protocol"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testInventory = `devices:
+  - name: fw-bj-01
+    vendor: juniper
+    type: srx
+    version: "12.1"
+    address: 10.0.0.1:22
+    credential: juniper-ro
+    labels: {site: bj, role: edge}
+  - name: fw-sh-01
+    vendor: Juniper
+    type: srx
+    address: 10.0.1.1:22
+    labels: {site: sh, role: edge}
+  - name: sw-bj-01
+    vendor: cisco
+    type: ios
+    address: 10.0.0.2:23
+    protocol: telnet
+    labels: {site: bj}
+`
+
+func TestInventory(t *testing.T) {
+	Convey("load and select devices", t, func() {
+		dir, err := ioutil.TempDir("", "inventory")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "inventory.yaml")
+		So(ioutil.WriteFile(path, []byte(testInventory), 0600), ShouldBeNil)
+
+		inv, err := Load(path)
+		So(err, ShouldBeNil)
+		So(inv.Devices, ShouldHaveLength, 3)
+		So(inv.Devices[0].Protocol, ShouldEqual, "ssh")
+
+		names := func(sel *protocol.DeviceSelector) []string {
+			devices, err := inv.Select(sel)
+			So(err, ShouldBeNil)
+			var s []string
+			for _, v := range devices {
+				s = append(s, v.Name)
+			}
+			return s
+		}
+		So(names(&protocol.DeviceSelector{}), ShouldResemble, []string{"fw-bj-01", "fw-sh-01", "sw-bj-01"})
+		So(names(&protocol.DeviceSelector{Vendor: "juniper"}), ShouldResemble, []string{"fw-bj-01", "fw-sh-01"})
+		So(names(&protocol.DeviceSelector{Labels: map[string]string{"site": "bj"}}), ShouldResemble, []string{"fw-bj-01", "sw-bj-01"})
+		So(names(&protocol.DeviceSelector{Name: "^fw-", Labels: map[string]string{"site": "bj", "role": "edge"}}), ShouldResemble, []string{"fw-bj-01"})
+		So(names(&protocol.DeviceSelector{Labels: map[string]string{"role": "core"}}), ShouldBeEmpty)
+		_, err = inv.Select(&protocol.DeviceSelector{Name: "("})
+		So(err, ShouldNotBeNil)
+		So(inv.ByAddress("10.0.1.1:22").Name, ShouldEqual, "fw-sh-01")
+		So(inv.ByAddress("10.0.1.1:23"), ShouldBeNil)
+
+		req := inv.Devices[0].Request()
+		So(req.Device, ShouldEqual, "fw-bj-01")
+		So(req.Address, ShouldEqual, "10.0.0.1:22")
+		So(req.Auth.Credential, ShouldEqual, "juniper-ro")
+		So(req.Auth.Password, ShouldBeEmpty)
+	})
+
+	Convey("devices without address or duplicated rejected", t, func() {
+		dir, err := ioutil.TempDir("", "inventory")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "inventory.yaml")
+		So(ioutil.WriteFile(path, []byte("devices:\n  - name: a\n"), 0600), ShouldBeNil)
+		_, err = Load(path)
+		So(err, ShouldNotBeNil)
+		So(ioutil.WriteFile(path, []byte("devices:\n  - {name: a, address: x}\n  - {name: a, address: y}\n"), 0600), ShouldBeNil)
+		_, err = Load(path)
+		So(err, ShouldNotBeNil)
+	})
+}
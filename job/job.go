@@ -0,0 +1,294 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package job runs cli requests asynchronously. Jobs are stored in a bolt db so they survive restarts,
+// credentials are kept in memory only, jobs unfinished when netd stopped are failed on start.
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/sky-cloud-tec/netd/auth"
+	"github.com/sky-cloud-tec/netd/cli"
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/protocol"
+	"github.com/songtianyi/rrframework/logs"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Instance is the job manager, nil if jobs disabled
+var Instance *Manager
+
+var (
+	// ErrNotFound job not found
+	ErrNotFound = errors.New("job not found")
+	// ErrFinished job finished already
+	ErrFinished = errors.New("job finished already")
+)
+
+var bucket = []byte("jobs")
+
+// Runner handles request of job, emit is called with every command result once it's done.
+// ctx is canceled when job canceled, runner should return soon then.
+type Runner func(ctx context.Context, req *protocol.CliRequest, res *protocol.CliResponse, emit func(*protocol.CmdResult))
+
+// Manager runs jobs with up to workers runners at once
+type Manager struct {
+	db      *bolt.DB
+	run     Runner
+	sem     chan struct{}
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc // unfinished jobs
+}
+
+// New open job db at path, created if not exists
+func New(path string, workers int, run Runner) (*Manager, error) {
+	if workers <= 0 {
+		workers = 1
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open job db error: %s", err)
+	}
+	m := &Manager{db: db, run: run, sem: make(chan struct{}, workers), cancels: map[string]context.CancelFunc{}}
+	if err := m.recover(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// recover fail jobs left unfinished by last run, their credentials are gone
+// and commands may have been sent, rerunning them is not safe
+func (m *Manager) recover() error {
+	return m.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucket)
+		if err != nil {
+			return fmt.Errorf("create job bucket error: %s", err)
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var j protocol.Job
+			if err := json.Unmarshal(v, &j); err != nil {
+				return fmt.Errorf("decode job %s error: %s", k, err)
+			}
+			if j.State != protocol.JobPending && j.State != protocol.JobRunning {
+				return nil
+			}
+			j.State = protocol.JobFailed
+			j.Message = "interrupted by restart"
+			j.Finished = time.Now()
+			return put(b, &j)
+		})
+	})
+}
+
+func put(b *bolt.Bucket, j *protocol.Job) error {
+	v, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(j.ID), v)
+}
+
+// update job id with f, f returns false to leave it unchanged
+func (m *Manager) update(id string, f func(*protocol.Job) bool) (*protocol.Job, error) {
+	var j protocol.Job
+	err := m.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		v := b.Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(v, &j); err != nil {
+			return fmt.Errorf("decode job %s error: %s", id, err)
+		}
+		if !f(&j) {
+			return nil
+		}
+		return put(b, &j)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// Submit create pending job of req on behalf of client, it's run once a worker is free
+// with ctx carrying client identity
+func (m *Manager) Submit(req *protocol.CliRequest, client *protocol.Identity) (*protocol.Job, error) {
+	id := xid.New().String()
+	if req.Session == "" {
+		req.Session = id
+	}
+	// stored without credentials
+	stored := *req
+	stored.Auth.Password = ""
+	stored.EnablePwd = ""
+	stored.Commands = cli.RedactCommands(nil, req.Commands)
+	j := &protocol.Job{
+		ID:      id,
+		State:   protocol.JobPending,
+		Device:  req.Device,
+		Request: &stored,
+		Client:  client,
+		Created: time.Now(),
+	}
+	if err := m.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(bucket), j)
+	}); err != nil {
+		return nil, fmt.Errorf("store job error: %s", err)
+	}
+	ctx, cancel := context.WithCancel(auth.NewContext(context.Background(), client))
+	m.mu.Lock()
+	m.cancels[id] = cancel
+	m.mu.Unlock()
+	go m.start(ctx, id, req)
+	return j, nil
+}
+
+func (m *Manager) start(ctx context.Context, id string, req *protocol.CliRequest) {
+	defer func() {
+		m.mu.Lock()
+		if cancel, ok := m.cancels[id]; ok {
+			cancel()
+			delete(m.cancels, id)
+		}
+		m.mu.Unlock()
+	}()
+	select {
+	case m.sem <- struct{}{}:
+		defer func() { <-m.sem }()
+	case <-ctx.Done():
+		// canceled while pending
+		return
+	}
+	running := func(j *protocol.Job) bool { return j.State == protocol.JobRunning }
+	if _, err := m.update(id, func(j *protocol.Job) bool {
+		if j.State != protocol.JobPending {
+			return false
+		}
+		j.State = protocol.JobRunning
+		j.Started = time.Now()
+		return true
+	}); err != nil {
+		logs.Error("start job", id, "error:", err)
+		return
+	}
+	var res protocol.CliResponse
+	m.run(ctx, req, &res, func(r *protocol.CmdResult) {
+		if _, err := m.update(id, func(j *protocol.Job) bool {
+			if !running(j) {
+				return false
+			}
+			j.Results = append(j.Results, r)
+			return true
+		}); err != nil {
+			logs.Error(req.LogPrefix, "update job", id, "error:", err)
+		}
+	})
+	if _, err := m.update(id, func(j *protocol.Job) bool {
+		if !running(j) {
+			return false
+		}
+		j.Finished = time.Now()
+		j.Response = &res
+		j.Results = res.Results
+		switch {
+		case ctx.Err() != nil:
+			j.State = protocol.JobCanceled
+			j.Message = "canceled"
+		case res.Retcode == common.OK:
+			j.State = protocol.JobDone
+		default:
+			j.State = protocol.JobFailed
+			j.Message = res.Message
+		}
+		return true
+	}); err != nil {
+		logs.Error(req.LogPrefix, "finish job", id, "error:", err)
+	}
+}
+
+// Get return job of id
+func (m *Manager) Get(id string) (*protocol.Job, error) {
+	var j protocol.Job
+	err := m.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucket).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(v, &j)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// List return jobs of device in state, empty filter matches any, oldest first
+func (m *Manager) List(device, state string) ([]*protocol.Job, error) {
+	jobs := make([]*protocol.Job, 0)
+	err := m.db.View(func(tx *bolt.Tx) error {
+		// ids are ordered by creation time
+		return tx.Bucket(bucket).ForEach(func(k, v []byte) error {
+			var j protocol.Job
+			if err := json.Unmarshal(v, &j); err != nil {
+				return fmt.Errorf("decode job %s error: %s", k, err)
+			}
+			if (device == "" || j.Device == device) && (state == "" || j.State == state) {
+				jobs = append(jobs, &j)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Cancel job of id, pending job is canceled at once, running one once its runner returns
+func (m *Manager) Cancel(id string) (*protocol.Job, error) {
+	m.mu.Lock()
+	cancel, ok := m.cancels[id]
+	m.mu.Unlock()
+	if !ok {
+		if _, err := m.Get(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrFinished
+	}
+	cancel()
+	return m.update(id, func(j *protocol.Job) bool {
+		if j.State != protocol.JobPending {
+			return false
+		}
+		j.State = protocol.JobCanceled
+		j.Message = "canceled"
+		j.Finished = time.Now()
+		return true
+	})
+}
+
+// Close job db, unfinished jobs are failed on next start
+func (m *Manager) Close() error {
+	return m.db.Close()
+}
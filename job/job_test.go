@@ -0,0 +1,140 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package job
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sky-cloud-tec/netd/common"
+	"github.com/sky-cloud-tec/netd/protocol"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// blockingRunner emits a result per command, then blocks until released or canceled
+func blockingRunner(release chan struct{}) Runner {
+	return func(ctx context.Context, req *protocol.CliRequest, res *protocol.CliResponse, emit func(*protocol.CmdResult)) {
+		var results []*protocol.CmdResult
+		for _, v := range req.Commands {
+			r := &protocol.CmdResult{Command: v, Output: "ok"}
+			results = append(results, r)
+			emit(r)
+		}
+		select {
+		case <-release:
+			*res = protocol.CliResponse{Retcode: common.OK, Message: "OK", Results: results}
+		case <-ctx.Done():
+			*res = protocol.CliResponse{Retcode: common.ErrCliExec, Message: "canceled", Results: results}
+		}
+	}
+}
+
+// wait until job of id in state
+func wait(m *Manager, id, state string) *protocol.Job {
+	for i := 0; i < 100; i++ {
+		j, err := m.Get(id)
+		So(err, ShouldBeNil)
+		if j.State == state {
+			return j
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	So("job "+id+" not "+state, ShouldBeEmpty)
+	return nil
+}
+
+func TestJobs(t *testing.T) {
+	Convey("jobs run asynchronously and survive restart", t, func() {
+		dir, err := ioutil.TempDir("", "netd-job")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "jobs.db")
+		release := make(chan struct{})
+		m, err := New(path, 1, blockingRunner(release))
+		So(err, ShouldBeNil)
+
+		req := &protocol.CliRequest{Device: "fw1", Auth: protocol.Auth{Username: "admin", Password: "s3cr3t"}, Commands: []string{"show version", "show clock"}}
+		j1, err := m.Submit(req, nil)
+		So(err, ShouldBeNil)
+		So(j1.State, ShouldEqual, protocol.JobPending)
+		So(j1.Request.Auth.Password, ShouldBeEmpty)
+		So(req.Auth.Password, ShouldEqual, "s3cr3t")
+		So(req.Session, ShouldEqual, j1.ID)
+
+		// partial results while running
+		j := wait(m, j1.ID, protocol.JobRunning)
+		for len(j.Results) < 2 {
+			time.Sleep(10 * time.Millisecond)
+			j, _ = m.Get(j1.ID)
+		}
+		So(j.Results[1].Command, ShouldEqual, "show clock")
+
+		// one worker, the next one waits
+		j2, err := m.Submit(&protocol.CliRequest{Device: "fw2", Commands: []string{"show version"}}, nil)
+		So(err, ShouldBeNil)
+		j3, err := m.Submit(&protocol.CliRequest{Device: "fw2", Commands: []string{"show version"}}, nil)
+		So(err, ShouldBeNil)
+		time.Sleep(50 * time.Millisecond)
+		j, _ = m.Get(j2.ID)
+		So(j.State, ShouldEqual, protocol.JobPending)
+
+		Convey("pending job canceled at once", func() {
+			j, err := m.Cancel(j2.ID)
+			So(err, ShouldBeNil)
+			So(j.State, ShouldEqual, protocol.JobCanceled)
+		})
+		Convey("running job canceled once runner returns", func() {
+			_, err := m.Cancel(j1.ID)
+			So(err, ShouldBeNil)
+			j := wait(m, j1.ID, protocol.JobCanceled)
+			So(j.Response.Retcode, ShouldEqual, common.ErrCliExec)
+			So(j.Finished.IsZero(), ShouldBeFalse)
+			_, err = m.Cancel(j1.ID)
+			So(err, ShouldEqual, ErrFinished)
+			_, err = m.Cancel("nope")
+			So(err, ShouldEqual, ErrNotFound)
+		})
+		Convey("finished job done with response", func() {
+			release <- struct{}{}
+			j := wait(m, j1.ID, protocol.JobDone)
+			So(j.Response.Retcode, ShouldEqual, common.OK)
+			So(len(j.Results), ShouldEqual, 2)
+
+			jobs, err := m.List("fw2", "")
+			So(err, ShouldBeNil)
+			So(len(jobs), ShouldEqual, 2)
+			So(jobs[0].ID, ShouldEqual, j2.ID)
+			jobs, err = m.List("", protocol.JobDone)
+			So(err, ShouldBeNil)
+			So(len(jobs), ShouldEqual, 1)
+		})
+		Convey("unfinished jobs failed after restart", func() {
+			So(m.Close(), ShouldBeNil)
+			m, err = New(path, 1, blockingRunner(release))
+			So(err, ShouldBeNil)
+			j, err := m.Get(j3.ID)
+			So(err, ShouldBeNil)
+			So(j.State, ShouldEqual, protocol.JobFailed)
+			So(j.Message, ShouldEqual, "interrupted by restart")
+		})
+		close(release)
+		m.Close()
+	})
+}
@@ -0,0 +1,75 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"time"
+)
+
+// BatchRequest runs the same commands on many devices
+type BatchRequest struct {
+	Devices  []*CliRequest   `json:"devices"`  // devices, their empty commands, mode and timeout taken from batch
+	Selector *DeviceSelector `json:"selector"` // devices selected from inventory, in addition to Devices
+	Commands []string        `json:"commands"`
+	Mode     string          `json:"mode"`
+	Timeout  time.Duration   `json:"timeout"` // per device, in seconds
+	// Concurrency limits devices handled at once, server limit used if 0 or larger
+	Concurrency int `json:"concurrency"`
+	// VendorConcurrency limits devices of vendor handled at once, server limits are kept if lower
+	VendorConcurrency map[string]int `json:"vendorConcurrency"`
+	Session           string         `json:"session"` // batch uuid, device sessions derived from it
+}
+
+// DeviceSelector selects inventory devices, every field set must match
+type DeviceSelector struct {
+	Name   string            `json:"name"`   // regexp of device name
+	Vendor string            `json:"vendor"` // vendor, case insensitive
+	Labels map[string]string `json:"labels"` // labels device must have
+}
+
+// BatchResult is the result of one device
+type BatchResult struct {
+	Device   string       `json:"device"`
+	Address  string       `json:"address"`
+	Vendor   string       `json:"vendor"`
+	Response *CliResponse `json:"response"`
+}
+
+// BatchSummary aggregates device results
+type BatchSummary struct {
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Retcodes  map[int][]string `json:"retcodes"` // devices grouped by retcode
+	Start     time.Time        `json:"start"`
+	End       time.Time        `json:"end"`
+	Duration  time.Duration    `json:"duration"`
+}
+
+// BatchResponse carries summary and results in completion order
+type BatchResponse struct {
+	Retcode int
+	Message string
+	Summary *BatchSummary
+	Results []*BatchResult
+}
+
+// BatchStreamMessage is sent on batch stream, a result once each device is done,
+// then the response without results
+type BatchStreamMessage struct {
+	Result   *BatchResult   `json:"result,omitempty"`
+	Response *BatchResponse `json:"response,omitempty"`
+}
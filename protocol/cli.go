@@ -33,12 +33,35 @@ type CliRequest struct {
 	LogPrefix string        `json:"logPrefix"` // log prefix
 	EnablePwd string        `json:"enablePwd"` // enable password for cisco devices
 	Session   string        `json:"session"`   // session uuid
+	Raw       bool          `json:"raw"`       // output as device sent it, no normalization
+	Parse     bool          `json:"parse"`     // parse outputs with templates looked up from index
+	// Templates are templates to parse outputs with, keyed by command,
+	// value is a file name in templates dir or template text itself
+	Templates map[string]string `json:"templates"`
+	// InputEncoding is the charset commands transcoded to, operator encoding if empty
+	InputEncoding string `json:"inputEncoding"`
+	// OutputEncoding is the charset of device output, detected if empty
+	OutputEncoding string `json:"outputEncoding"`
+	// Record session in asciicast v2 format, devices may be recorded always, see netd --record-devices
+	Record bool `json:"record"`
+	// Redact secrets in outputs returned, they're always redacted in logs and recordings
+	Redact bool `json:"redact"`
+}
+
+// TxRequest config transaction request, Commands are config commands, Mode is decided by operator
+type TxRequest struct {
+	CliRequest
+	Confirm int  `json:"confirm"` // minutes before device rolls back unless confirmed, 0 to commit without confirmation
+	DryRun  bool `json:"dryRun"`  // show diff then discard changes
 }
 
 // Auth struct
 type Auth struct {
 	Username string `json:"Username"`
 	Password string `json:"Password"`
+	// Credential is the id of credential in vault, used when password is empty,
+	// fallbacks of device are tried after it, see netd --vault-file
+	Credential string `json:"credential"`
 }
 
 // CliResponse ...
@@ -46,5 +69,101 @@ type CliResponse struct {
 	Retcode int
 	Message string
 	Device  string
-	CmdsStd map[string]string
+	CmdsStd map[string]string // outputs keyed by command, the last one kept if command repeated
+	// Results are command results in order, failed command is the last one
+	Results []*CmdResult
+	// Records are records parsed from outputs, keyed by command
+	Records map[string][]map[string]interface{}
+	// ParseErrors are errors of parsing, keyed by command
+	ParseErrors map[string]string
+	// Recovered is true when commands failed but the session was brought back to a known prompt,
+	// false means the connection was closed
+	Recovered bool
+	// Recording is the id of session recording, empty if not recorded
+	Recording string
+}
+
+// CliStreamMessage is sent by streaming exec, a result for every command once it's done,
+// then the response
+type CliStreamMessage struct {
+	Result   *CmdResult   `json:"result,omitempty"`
+	Response *CliResponse `json:"response,omitempty"`
+}
+
+// ConnInfo describes a cached cli connection
+type ConnInfo struct {
+	Address    string    `json:"address"`
+	Device     string    `json:"device"`
+	Protocol   string    `json:"protocol"`
+	Username   string    `json:"username"`
+	Credential string    `json:"credential"` // vault credential logged in with, empty if not resolved from vault
+	Mode       string    `json:"mode"`       // current cli mode
+	Busy       bool      `json:"busy"`       // serving a request
+	Recording  string    `json:"recording"`  // id of current recording
+	Created    time.Time `json:"created"`
+}
+
+// ConnListResponse lists cached cli connections
+type ConnListResponse struct {
+	Retcode     int
+	Message     string
+	Connections []*ConnInfo
+}
+
+// CmdResult is the result of one command
+type CmdResult struct {
+	Command  string        `json:"command"`
+	Output   string        `json:"output"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"duration"` // nanoseconds
+	Mode     string        `json:"mode"`     // mode command ran in
+	Prompt   string        `json:"prompt"`   // prompt matched after output
+	Encoding string        `json:"encoding"` // encoding detected from output
+	// encoding command transcoded to and encoding output converted from, empty means UTF-8
+	InputEncoding  string                   `json:"inputEncoding"`
+	OutputEncoding string                   `json:"outputEncoding"`
+	Error          string                   `json:"error"` // empty if command succeeded
+	Records        []map[string]interface{} `json:"records,omitempty"`
+}
+
+// TxResponse ...
+type TxResponse struct {
+	Retcode   int
+	Message   string
+	Device    string
+	Diff      string // candidate diff before commit
+	Committed bool   // commit or confirmed commit done
+	Confirmed bool   // confirmed commit confirmed after reconnect
+}
+
+// ConfigResponse config fetching response, request is CliRequest,
+// Format selects operator backup, Mode is used by per vdom devices only
+type ConfigResponse struct {
+	Retcode  int
+	Message  string
+	Device   string
+	Format   string // format of config
+	Config   string // config without pager, echo and prompt noise
+	Checksum string // sha256 hex of config
+	Version  int    // archived version, 0 if archive disabled
+}
+
+// ConfigDiffRequest diff between two archived versions of Device,
+// or between archived version From and device current config when Current set
+type ConfigDiffRequest struct {
+	Device  string      `json:"device"`  // device identity configs archived with
+	From    int         `json:"from"`    // archived version, 0 for the latest one
+	To      int         `json:"to"`      // archived version, 0 for the latest one
+	Current *CliRequest `json:"current"` // fetch current config with it instead of archived To
+}
+
+// ConfigDiffResponse ...
+type ConfigDiffResponse struct {
+	Retcode int
+	Message string
+	Device  string
+	From    int    // version diffed from
+	To      int    // version diffed to, 0 for device current config
+	Diff    string // unified diff, empty if same
 }
@@ -0,0 +1,43 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+// Identity is the client a request came from, set by ingress, never taken from request
+type Identity struct {
+	Method  string   `json:"method"`  // how client authenticated, empty if anonymous
+	Name    string   `json:"name"`    // common name of verified client certificate or name of token, empty if anonymous
+	Subject string   `json:"subject"` // subject of verified client certificate
+	SANs    []string `json:"sans"`    // dns names, emails and uris of verified client certificate
+	Address string   `json:"address"` // client address
+}
+
+// how client authenticated
+const (
+	IdentityCertificate = "certificate" // verified client certificate
+	IdentityToken       = "token"       // api token
+)
+
+// LoginRequest authenticates jrpc connection with api token
+type LoginRequest struct {
+	Token string `json:"token"`
+}
+
+// LoginResponse carries identity of connection
+type LoginResponse struct {
+	Retcode  int
+	Message  string
+	Identity *Identity
+}
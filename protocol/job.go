@@ -0,0 +1,69 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+import (
+	"time"
+)
+
+// job states
+const (
+	JobPending  = "pending"  // waiting for a worker
+	JobRunning  = "running"  // request being handled
+	JobDone     = "done"     // handled with retcode OK
+	JobFailed   = "failed"   // handled with error retcode, or interrupted by restart
+	JobCanceled = "canceled" // canceled before finished
+)
+
+// Job is a cli request handled asynchronously
+type Job struct {
+	ID       string       `json:"id"`
+	State    string       `json:"state"`
+	Device   string       `json:"device"`
+	Request  *CliRequest  `json:"request"`  // credentials stripped
+	Client   *Identity    `json:"client"`   // client submitted job
+	Results  []*CmdResult `json:"results"`  // results of commands done so far
+	Response *CliResponse `json:"response"` // set once finished
+	Message  string       `json:"message"`  // why failed or canceled
+	Created  time.Time    `json:"created"`
+	Started  time.Time    `json:"started"`
+	Finished time.Time    `json:"finished"`
+}
+
+// JobRequest identifies a job
+type JobRequest struct {
+	ID string `json:"id"`
+}
+
+// JobListRequest filters jobs, empty field matches any
+type JobListRequest struct {
+	Device string `json:"device"`
+	State  string `json:"state"`
+}
+
+// JobResponse carries a job
+type JobResponse struct {
+	Retcode int
+	Message string
+	Job     *Job
+}
+
+// JobListResponse carries jobs, oldest first
+type JobListResponse struct {
+	Retcode int
+	Message string
+	Jobs    []*Job
+}
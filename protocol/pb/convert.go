@@ -0,0 +1,509 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pb holds protobuf messages and grpc stubs generated from netd.proto,
+// and converters between them and protocol structs. From* build messages from
+// protocol structs, To* the other way round, nil converted to nil.
+package pb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	tspb "github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/sky-cloud-tec/netd/protocol"
+)
+
+// FromCliRequest convert protocol.CliRequest to message
+func FromCliRequest(v *protocol.CliRequest) *CliRequest {
+	if v == nil {
+		return nil
+	}
+	return &CliRequest{
+		Vendor:         v.Vendor,
+		Type:           v.Type,
+		Version:        v.Version,
+		Device:         v.Device,
+		Mode:           v.Mode,
+		Protocol:       v.Protocol,
+		Auth:           &Auth{Username: v.Auth.Username, Password: v.Auth.Password, Credential: v.Auth.Credential},
+		Address:        v.Address,
+		Commands:       v.Commands,
+		Format:         v.Format,
+		Timeout:        int64(v.Timeout),
+		LogPrefix:      v.LogPrefix,
+		EnablePwd:      v.EnablePwd,
+		Session:        v.Session,
+		Raw:            v.Raw,
+		Parse:          v.Parse,
+		Templates:      v.Templates,
+		InputEncoding:  v.InputEncoding,
+		OutputEncoding: v.OutputEncoding,
+		Record:         v.Record,
+		Redact:         v.Redact,
+	}
+}
+
+// ToCliRequest convert message to protocol.CliRequest
+func ToCliRequest(v *CliRequest) *protocol.CliRequest {
+	if v == nil {
+		return nil
+	}
+	return &protocol.CliRequest{
+		Vendor:   v.Vendor,
+		Type:     v.Type,
+		Version:  v.Version,
+		Device:   v.Device,
+		Mode:     v.Mode,
+		Protocol: v.Protocol,
+		Auth: protocol.Auth{
+			Username:   v.GetAuth().GetUsername(),
+			Password:   v.GetAuth().GetPassword(),
+			Credential: v.GetAuth().GetCredential(),
+		},
+		Address:        v.Address,
+		Commands:       v.Commands,
+		Format:         v.Format,
+		Timeout:        time.Duration(v.Timeout),
+		LogPrefix:      v.LogPrefix,
+		EnablePwd:      v.EnablePwd,
+		Session:        v.Session,
+		Raw:            v.Raw,
+		Parse:          v.Parse,
+		Templates:      v.Templates,
+		InputEncoding:  v.InputEncoding,
+		OutputEncoding: v.OutputEncoding,
+		Record:         v.Record,
+		Redact:         v.Redact,
+	}
+}
+
+// FromCmdResult convert protocol.CmdResult to message
+func FromCmdResult(v *protocol.CmdResult) *CmdResult {
+	if v == nil {
+		return nil
+	}
+	return &CmdResult{
+		Command:        v.Command,
+		Output:         v.Output,
+		Start:          fromTime(v.Start),
+		End:            fromTime(v.End),
+		Duration:       int64(v.Duration),
+		Mode:           v.Mode,
+		Prompt:         v.Prompt,
+		Encoding:       v.Encoding,
+		InputEncoding:  v.InputEncoding,
+		OutputEncoding: v.OutputEncoding,
+		Error:          v.Error,
+		Records:        fromRecords(v.Records),
+	}
+}
+
+// ToCmdResult convert message to protocol.CmdResult
+func ToCmdResult(v *CmdResult) *protocol.CmdResult {
+	if v == nil {
+		return nil
+	}
+	return &protocol.CmdResult{
+		Command:        v.Command,
+		Output:         v.Output,
+		Start:          toTime(v.Start),
+		End:            toTime(v.End),
+		Duration:       time.Duration(v.Duration),
+		Mode:           v.Mode,
+		Prompt:         v.Prompt,
+		Encoding:       v.Encoding,
+		InputEncoding:  v.InputEncoding,
+		OutputEncoding: v.OutputEncoding,
+		Error:          v.Error,
+		Records:        toRecords(v.Records),
+	}
+}
+
+// FromCliResponse convert protocol.CliResponse to message
+func FromCliResponse(v *protocol.CliResponse) *CliResponse {
+	if v == nil {
+		return nil
+	}
+	m := &CliResponse{
+		Retcode:     int32(v.Retcode),
+		Message:     v.Message,
+		Device:      v.Device,
+		CmdsStd:     v.CmdsStd,
+		ParseErrors: v.ParseErrors,
+		Recovered:   v.Recovered,
+		Recording:   v.Recording,
+	}
+	for _, r := range v.Results {
+		m.Results = append(m.Results, FromCmdResult(r))
+	}
+	if v.Records != nil {
+		m.Records = make(map[string]*Records, len(v.Records))
+		for k, r := range v.Records {
+			m.Records[k] = &Records{Records: fromRecords(r)}
+		}
+	}
+	return m
+}
+
+// ToCliResponse convert message to protocol.CliResponse
+func ToCliResponse(v *CliResponse) *protocol.CliResponse {
+	if v == nil {
+		return nil
+	}
+	res := &protocol.CliResponse{
+		Retcode:     int(v.Retcode),
+		Message:     v.Message,
+		Device:      v.Device,
+		CmdsStd:     v.CmdsStd,
+		ParseErrors: v.ParseErrors,
+		Recovered:   v.Recovered,
+		Recording:   v.Recording,
+	}
+	for _, r := range v.Results {
+		res.Results = append(res.Results, ToCmdResult(r))
+	}
+	if v.Records != nil {
+		res.Records = make(map[string][]map[string]interface{}, len(v.Records))
+		for k, r := range v.Records {
+			res.Records[k] = toRecords(r.GetRecords())
+		}
+	}
+	return res
+}
+
+// FromConfigResponse convert protocol.ConfigResponse to message
+func FromConfigResponse(v *protocol.ConfigResponse) *ConfigResponse {
+	if v == nil {
+		return nil
+	}
+	return &ConfigResponse{
+		Retcode:  int32(v.Retcode),
+		Message:  v.Message,
+		Device:   v.Device,
+		Format:   v.Format,
+		Config:   v.Config,
+		Checksum: v.Checksum,
+		Version:  int32(v.Version),
+	}
+}
+
+// ToConfigResponse convert message to protocol.ConfigResponse
+func ToConfigResponse(v *ConfigResponse) *protocol.ConfigResponse {
+	if v == nil {
+		return nil
+	}
+	return &protocol.ConfigResponse{
+		Retcode:  int(v.Retcode),
+		Message:  v.Message,
+		Device:   v.Device,
+		Format:   v.Format,
+		Config:   v.Config,
+		Checksum: v.Checksum,
+		Version:  int(v.Version),
+	}
+}
+
+// FromPortCheckRequest convert protocol.PortCheckRequest to message
+func FromPortCheckRequest(v *protocol.PortCheckRequest) *PortCheckRequest {
+	if v == nil {
+		return nil
+	}
+	return &PortCheckRequest{
+		Ip:        v.IP,
+		Port:      v.Port,
+		Proto:     v.Proto,
+		Timeout:   int64(v.Timeout),
+		LogPrefix: v.LogPrefix,
+		EnablePwd: v.EnablePwd,
+		Session:   v.Session,
+	}
+}
+
+// ToPortCheckRequest convert message to protocol.PortCheckRequest
+func ToPortCheckRequest(v *PortCheckRequest) *protocol.PortCheckRequest {
+	if v == nil {
+		return nil
+	}
+	return &protocol.PortCheckRequest{
+		IP:        v.Ip,
+		Port:      v.Port,
+		Proto:     v.Proto,
+		Timeout:   time.Duration(v.Timeout),
+		LogPrefix: v.LogPrefix,
+		EnablePwd: v.EnablePwd,
+		Session:   v.Session,
+	}
+}
+
+// FromPortCheckResponse convert protocol.PortCheckResponse to message
+func FromPortCheckResponse(v *protocol.PortCheckResponse) *PortCheckResponse {
+	if v == nil {
+		return nil
+	}
+	return &PortCheckResponse{Retcode: int32(v.Retcode), Message: v.Message}
+}
+
+// ToPortCheckResponse convert message to protocol.PortCheckResponse
+func ToPortCheckResponse(v *PortCheckResponse) *protocol.PortCheckResponse {
+	if v == nil {
+		return nil
+	}
+	return &protocol.PortCheckResponse{Retcode: int(v.Retcode), Message: v.Message}
+}
+
+// FromBatchRequest convert protocol.BatchRequest to message
+func FromBatchRequest(v *protocol.BatchRequest) *BatchRequest {
+	if v == nil {
+		return nil
+	}
+	m := &BatchRequest{
+		Commands:    v.Commands,
+		Mode:        v.Mode,
+		Timeout:     int64(v.Timeout),
+		Concurrency: int32(v.Concurrency),
+		Session:     v.Session,
+	}
+	for _, d := range v.Devices {
+		m.Devices = append(m.Devices, FromCliRequest(d))
+	}
+	if v.Selector != nil {
+		m.Selector = &DeviceSelector{Name: v.Selector.Name, Vendor: v.Selector.Vendor, Labels: v.Selector.Labels}
+	}
+	if v.VendorConcurrency != nil {
+		m.VendorConcurrency = make(map[string]int32, len(v.VendorConcurrency))
+		for k, n := range v.VendorConcurrency {
+			m.VendorConcurrency[k] = int32(n)
+		}
+	}
+	return m
+}
+
+// ToBatchRequest convert message to protocol.BatchRequest
+func ToBatchRequest(v *BatchRequest) *protocol.BatchRequest {
+	if v == nil {
+		return nil
+	}
+	req := &protocol.BatchRequest{
+		Commands:    v.Commands,
+		Mode:        v.Mode,
+		Timeout:     time.Duration(v.Timeout),
+		Concurrency: int(v.Concurrency),
+		Session:     v.Session,
+	}
+	for _, d := range v.Devices {
+		req.Devices = append(req.Devices, ToCliRequest(d))
+	}
+	if v.Selector != nil {
+		req.Selector = &protocol.DeviceSelector{Name: v.Selector.Name, Vendor: v.Selector.Vendor, Labels: v.Selector.Labels}
+	}
+	if v.VendorConcurrency != nil {
+		req.VendorConcurrency = make(map[string]int, len(v.VendorConcurrency))
+		for k, n := range v.VendorConcurrency {
+			req.VendorConcurrency[k] = int(n)
+		}
+	}
+	return req
+}
+
+// FromBatchResult convert protocol.BatchResult to message
+func FromBatchResult(v *protocol.BatchResult) *BatchResult {
+	if v == nil {
+		return nil
+	}
+	return &BatchResult{Device: v.Device, Address: v.Address, Vendor: v.Vendor, Response: FromCliResponse(v.Response)}
+}
+
+// ToBatchResult convert message to protocol.BatchResult
+func ToBatchResult(v *BatchResult) *protocol.BatchResult {
+	if v == nil {
+		return nil
+	}
+	return &protocol.BatchResult{Device: v.Device, Address: v.Address, Vendor: v.Vendor, Response: ToCliResponse(v.Response)}
+}
+
+// FromBatchResponse convert protocol.BatchResponse to message
+func FromBatchResponse(v *protocol.BatchResponse) *BatchResponse {
+	if v == nil {
+		return nil
+	}
+	m := &BatchResponse{Retcode: int32(v.Retcode), Message: v.Message}
+	if s := v.Summary; s != nil {
+		m.Summary = &BatchSummary{
+			Total:     int32(s.Total),
+			Succeeded: int32(s.Succeeded),
+			Failed:    int32(s.Failed),
+			Start:     fromTime(s.Start),
+			End:       fromTime(s.End),
+			Duration:  int64(s.Duration),
+		}
+		if s.Retcodes != nil {
+			m.Summary.Retcodes = make(map[int32]*Devices, len(s.Retcodes))
+			for k, d := range s.Retcodes {
+				m.Summary.Retcodes[int32(k)] = &Devices{Devices: d}
+			}
+		}
+	}
+	for _, r := range v.Results {
+		m.Results = append(m.Results, FromBatchResult(r))
+	}
+	return m
+}
+
+// ToBatchResponse convert message to protocol.BatchResponse
+func ToBatchResponse(v *BatchResponse) *protocol.BatchResponse {
+	if v == nil {
+		return nil
+	}
+	res := &protocol.BatchResponse{Retcode: int(v.Retcode), Message: v.Message}
+	if s := v.Summary; s != nil {
+		res.Summary = &protocol.BatchSummary{
+			Total:     int(s.Total),
+			Succeeded: int(s.Succeeded),
+			Failed:    int(s.Failed),
+			Start:     toTime(s.Start),
+			End:       toTime(s.End),
+			Duration:  time.Duration(s.Duration),
+		}
+		if s.Retcodes != nil {
+			res.Summary.Retcodes = make(map[int][]string, len(s.Retcodes))
+			for k, d := range s.Retcodes {
+				res.Summary.Retcodes[int(k)] = d.GetDevices()
+			}
+		}
+	}
+	for _, r := range v.Results {
+		res.Results = append(res.Results, ToBatchResult(r))
+	}
+	return res
+}
+
+// fromTime convert t to timestamp, nil if zero
+func fromTime(t time.Time) *tspb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
+
+// toTime convert ts to local time, zero if nil
+func toTime(ts *tspb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	t, err := ptypes.Timestamp(ts)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.Local()
+}
+
+func fromRecords(records []map[string]interface{}) []*structpb.Struct {
+	if records == nil {
+		return nil
+	}
+	out := make([]*structpb.Struct, 0, len(records))
+	for _, r := range records {
+		out = append(out, fromMap(r))
+	}
+	return out
+}
+
+func toRecords(records []*structpb.Struct) []map[string]interface{} {
+	if records == nil {
+		return nil
+	}
+	out := make([]map[string]interface{}, 0, len(records))
+	for _, r := range records {
+		out = append(out, toMap(r))
+	}
+	return out
+}
+
+func fromMap(m map[string]interface{}) *structpb.Struct {
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(m))}
+	for k, v := range m {
+		s.Fields[k] = fromValue(v)
+	}
+	return s
+}
+
+func toMap(s *structpb.Struct) map[string]interface{} {
+	m := make(map[string]interface{}, len(s.GetFields()))
+	for k, v := range s.GetFields() {
+		m[k] = toValue(v)
+	}
+	return m
+}
+
+// fromValue convert record value, values of other types formatted as string
+func fromValue(v interface{}) *structpb.Value {
+	switch x := v.(type) {
+	case nil:
+		return &structpb.Value{Kind: &structpb.Value_NullValue{}}
+	case string:
+		return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: x}}
+	case bool:
+		return &structpb.Value{Kind: &structpb.Value_BoolValue{BoolValue: x}}
+	case int:
+		return &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(x)}}
+	case int64:
+		return &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(x)}}
+	case float64:
+		return &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: x}}
+	case []string:
+		l := &structpb.ListValue{}
+		for _, s := range x {
+			l.Values = append(l.Values, fromValue(s))
+		}
+		return &structpb.Value{Kind: &structpb.Value_ListValue{ListValue: l}}
+	case []interface{}:
+		l := &structpb.ListValue{}
+		for _, e := range x {
+			l.Values = append(l.Values, fromValue(e))
+		}
+		return &structpb.Value{Kind: &structpb.Value_ListValue{ListValue: l}}
+	case map[string]interface{}:
+		return &structpb.Value{Kind: &structpb.Value_StructValue{StructValue: fromMap(x)}}
+	}
+	return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: fmt.Sprint(v)}}
+}
+
+// toValue convert record value like json does, lists are []interface{}
+func toValue(v *structpb.Value) interface{} {
+	switch x := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return x.StringValue
+	case *structpb.Value_BoolValue:
+		return x.BoolValue
+	case *structpb.Value_NumberValue:
+		return x.NumberValue
+	case *structpb.Value_ListValue:
+		l := make([]interface{}, 0, len(x.ListValue.GetValues()))
+		for _, e := range x.ListValue.GetValues() {
+			l = append(l, toValue(e))
+		}
+		return l
+	case *structpb.Value_StructValue:
+		return toMap(x.StructValue)
+	}
+	return nil
+}
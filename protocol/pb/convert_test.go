@@ -0,0 +1,76 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sky-cloud-tec/netd/protocol"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConvert(t *testing.T) {
+	Convey("cli request and response survive the wire", t, func() {
+		req := &protocol.CliRequest{
+			Vendor: "juniper", Type: "srx", Version: "6.0", Device: "fw1", Mode: "login", Protocol: "ssh",
+			Auth:    protocol.Auth{Username: "admin", Credential: "fw-ro"},
+			Address: "10.0.0.1:22", Commands: []string{"show version"}, Timeout: 30,
+			Parse: true, Templates: map[string]string{"show version": "show_version.textfsm"},
+			OutputEncoding: "gb18030", Record: true, Redact: true,
+		}
+		b, err := proto.Marshal(FromCliRequest(req))
+		So(err, ShouldBeNil)
+		m := new(CliRequest)
+		So(proto.Unmarshal(b, m), ShouldBeNil)
+		So(ToCliRequest(m), ShouldResemble, req)
+
+		start := time.Now()
+		res := &protocol.CliResponse{
+			Retcode: 0, Message: "OK", Device: "fw1",
+			CmdsStd: map[string]string{"show version": "JUNOS 12.1"},
+			Results: []*protocol.CmdResult{{
+				Command: "show version", Output: "JUNOS 12.1", Start: start, End: start.Add(time.Second),
+				Duration: time.Second, Mode: "login", Prompt: "admin@fw1> ",
+				Records: []map[string]interface{}{{"VERSION": "12.1", "SERIALS": []interface{}{"a", "b"}}},
+			}},
+			Records:   map[string][]map[string]interface{}{"show version": {{"VERSION": "12.1"}}},
+			Recovered: true, Recording: "r1",
+		}
+		b, err = proto.Marshal(FromCliResponse(res))
+		So(err, ShouldBeNil)
+		rm := new(CliResponse)
+		So(proto.Unmarshal(b, rm), ShouldBeNil)
+		back := ToCliResponse(rm)
+		So(back.Results[0].Start.Equal(start), ShouldBeTrue)
+		So(back.Results[0].Duration, ShouldEqual, time.Second)
+		So(back.Results[0].Records, ShouldResemble, res.Results[0].Records)
+		So(back.Records, ShouldResemble, res.Records)
+		So(back.CmdsStd, ShouldResemble, res.CmdsStd)
+		So(back.Recovered, ShouldBeTrue)
+		So(back.Recording, ShouldEqual, "r1")
+	})
+	Convey("batch summary keeps devices by retcode", t, func() {
+		res := &protocol.BatchResponse{
+			Summary: &protocol.BatchSummary{Total: 2, Succeeded: 1, Failed: 1, Retcodes: map[int][]string{0: {"fw1"}, 1005: {"fw2"}}},
+		}
+		back := ToBatchResponse(FromBatchResponse(res))
+		So(back.Summary.Retcodes, ShouldResemble, res.Summary.Retcodes)
+		So(back.Summary.Start.IsZero(), ShouldBeTrue)
+		So(ToBatchResponse(nil), ShouldBeNil)
+	})
+}
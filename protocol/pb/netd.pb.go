@@ -0,0 +1,1678 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: protocol/pb/netd.proto
+
+package pb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	_struct "github.com/golang/protobuf/ptypes/struct"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Auth struct {
+	Username             string   `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password             string   `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	Credential           string   `protobuf:"bytes,3,opt,name=credential,proto3" json:"credential,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Auth) Reset()         { *m = Auth{} }
+func (m *Auth) String() string { return proto.CompactTextString(m) }
+func (*Auth) ProtoMessage()    {}
+func (*Auth) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{0}
+}
+
+func (m *Auth) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Auth.Unmarshal(m, b)
+}
+func (m *Auth) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Auth.Marshal(b, m, deterministic)
+}
+func (m *Auth) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Auth.Merge(m, src)
+}
+func (m *Auth) XXX_Size() int {
+	return xxx_messageInfo_Auth.Size(m)
+}
+func (m *Auth) XXX_DiscardUnknown() {
+	xxx_messageInfo_Auth.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Auth proto.InternalMessageInfo
+
+func (m *Auth) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *Auth) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+func (m *Auth) GetCredential() string {
+	if m != nil {
+		return m.Credential
+	}
+	return ""
+}
+
+type CliRequest struct {
+	Vendor               string            `protobuf:"bytes,1,opt,name=vendor,proto3" json:"vendor,omitempty"`
+	Type                 string            `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Version              string            `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	Device               string            `protobuf:"bytes,4,opt,name=device,proto3" json:"device,omitempty"`
+	Mode                 string            `protobuf:"bytes,5,opt,name=mode,proto3" json:"mode,omitempty"`
+	Protocol             string            `protobuf:"bytes,6,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Auth                 *Auth             `protobuf:"bytes,7,opt,name=auth,proto3" json:"auth,omitempty"`
+	Address              string            `protobuf:"bytes,8,opt,name=address,proto3" json:"address,omitempty"`
+	Commands             []string          `protobuf:"bytes,9,rep,name=commands,proto3" json:"commands,omitempty"`
+	Format               string            `protobuf:"bytes,10,opt,name=format,proto3" json:"format,omitempty"`
+	Timeout              int64             `protobuf:"varint,11,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	LogPrefix            string            `protobuf:"bytes,12,opt,name=log_prefix,json=logPrefix,proto3" json:"log_prefix,omitempty"`
+	EnablePwd            string            `protobuf:"bytes,13,opt,name=enable_pwd,json=enablePwd,proto3" json:"enable_pwd,omitempty"`
+	Session              string            `protobuf:"bytes,14,opt,name=session,proto3" json:"session,omitempty"`
+	Raw                  bool              `protobuf:"varint,15,opt,name=raw,proto3" json:"raw,omitempty"`
+	Parse                bool              `protobuf:"varint,16,opt,name=parse,proto3" json:"parse,omitempty"`
+	Templates            map[string]string `protobuf:"bytes,17,rep,name=templates,proto3" json:"templates,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	InputEncoding        string            `protobuf:"bytes,18,opt,name=input_encoding,json=inputEncoding,proto3" json:"input_encoding,omitempty"`
+	OutputEncoding       string            `protobuf:"bytes,19,opt,name=output_encoding,json=outputEncoding,proto3" json:"output_encoding,omitempty"`
+	Record               bool              `protobuf:"varint,20,opt,name=record,proto3" json:"record,omitempty"`
+	Redact               bool              `protobuf:"varint,21,opt,name=redact,proto3" json:"redact,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *CliRequest) Reset()         { *m = CliRequest{} }
+func (m *CliRequest) String() string { return proto.CompactTextString(m) }
+func (*CliRequest) ProtoMessage()    {}
+func (*CliRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{1}
+}
+
+func (m *CliRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CliRequest.Unmarshal(m, b)
+}
+func (m *CliRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CliRequest.Marshal(b, m, deterministic)
+}
+func (m *CliRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CliRequest.Merge(m, src)
+}
+func (m *CliRequest) XXX_Size() int {
+	return xxx_messageInfo_CliRequest.Size(m)
+}
+func (m *CliRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CliRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CliRequest proto.InternalMessageInfo
+
+func (m *CliRequest) GetVendor() string {
+	if m != nil {
+		return m.Vendor
+	}
+	return ""
+}
+
+func (m *CliRequest) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *CliRequest) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *CliRequest) GetDevice() string {
+	if m != nil {
+		return m.Device
+	}
+	return ""
+}
+
+func (m *CliRequest) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+func (m *CliRequest) GetProtocol() string {
+	if m != nil {
+		return m.Protocol
+	}
+	return ""
+}
+
+func (m *CliRequest) GetAuth() *Auth {
+	if m != nil {
+		return m.Auth
+	}
+	return nil
+}
+
+func (m *CliRequest) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *CliRequest) GetCommands() []string {
+	if m != nil {
+		return m.Commands
+	}
+	return nil
+}
+
+func (m *CliRequest) GetFormat() string {
+	if m != nil {
+		return m.Format
+	}
+	return ""
+}
+
+func (m *CliRequest) GetTimeout() int64 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *CliRequest) GetLogPrefix() string {
+	if m != nil {
+		return m.LogPrefix
+	}
+	return ""
+}
+
+func (m *CliRequest) GetEnablePwd() string {
+	if m != nil {
+		return m.EnablePwd
+	}
+	return ""
+}
+
+func (m *CliRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+func (m *CliRequest) GetRaw() bool {
+	if m != nil {
+		return m.Raw
+	}
+	return false
+}
+
+func (m *CliRequest) GetParse() bool {
+	if m != nil {
+		return m.Parse
+	}
+	return false
+}
+
+func (m *CliRequest) GetTemplates() map[string]string {
+	if m != nil {
+		return m.Templates
+	}
+	return nil
+}
+
+func (m *CliRequest) GetInputEncoding() string {
+	if m != nil {
+		return m.InputEncoding
+	}
+	return ""
+}
+
+func (m *CliRequest) GetOutputEncoding() string {
+	if m != nil {
+		return m.OutputEncoding
+	}
+	return ""
+}
+
+func (m *CliRequest) GetRecord() bool {
+	if m != nil {
+		return m.Record
+	}
+	return false
+}
+
+func (m *CliRequest) GetRedact() bool {
+	if m != nil {
+		return m.Redact
+	}
+	return false
+}
+
+type CmdResult struct {
+	Command              string               `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Output               string               `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
+	Start                *timestamp.Timestamp `protobuf:"bytes,3,opt,name=start,proto3" json:"start,omitempty"`
+	End                  *timestamp.Timestamp `protobuf:"bytes,4,opt,name=end,proto3" json:"end,omitempty"`
+	Duration             int64                `protobuf:"varint,5,opt,name=duration,proto3" json:"duration,omitempty"`
+	Mode                 string               `protobuf:"bytes,6,opt,name=mode,proto3" json:"mode,omitempty"`
+	Prompt               string               `protobuf:"bytes,7,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	Encoding             string               `protobuf:"bytes,8,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	InputEncoding        string               `protobuf:"bytes,9,opt,name=input_encoding,json=inputEncoding,proto3" json:"input_encoding,omitempty"`
+	OutputEncoding       string               `protobuf:"bytes,10,opt,name=output_encoding,json=outputEncoding,proto3" json:"output_encoding,omitempty"`
+	Error                string               `protobuf:"bytes,11,opt,name=error,proto3" json:"error,omitempty"`
+	Records              []*_struct.Struct    `protobuf:"bytes,12,rep,name=records,proto3" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *CmdResult) Reset()         { *m = CmdResult{} }
+func (m *CmdResult) String() string { return proto.CompactTextString(m) }
+func (*CmdResult) ProtoMessage()    {}
+func (*CmdResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{2}
+}
+
+func (m *CmdResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CmdResult.Unmarshal(m, b)
+}
+func (m *CmdResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CmdResult.Marshal(b, m, deterministic)
+}
+func (m *CmdResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CmdResult.Merge(m, src)
+}
+func (m *CmdResult) XXX_Size() int {
+	return xxx_messageInfo_CmdResult.Size(m)
+}
+func (m *CmdResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_CmdResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CmdResult proto.InternalMessageInfo
+
+func (m *CmdResult) GetCommand() string {
+	if m != nil {
+		return m.Command
+	}
+	return ""
+}
+
+func (m *CmdResult) GetOutput() string {
+	if m != nil {
+		return m.Output
+	}
+	return ""
+}
+
+func (m *CmdResult) GetStart() *timestamp.Timestamp {
+	if m != nil {
+		return m.Start
+	}
+	return nil
+}
+
+func (m *CmdResult) GetEnd() *timestamp.Timestamp {
+	if m != nil {
+		return m.End
+	}
+	return nil
+}
+
+func (m *CmdResult) GetDuration() int64 {
+	if m != nil {
+		return m.Duration
+	}
+	return 0
+}
+
+func (m *CmdResult) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+func (m *CmdResult) GetPrompt() string {
+	if m != nil {
+		return m.Prompt
+	}
+	return ""
+}
+
+func (m *CmdResult) GetEncoding() string {
+	if m != nil {
+		return m.Encoding
+	}
+	return ""
+}
+
+func (m *CmdResult) GetInputEncoding() string {
+	if m != nil {
+		return m.InputEncoding
+	}
+	return ""
+}
+
+func (m *CmdResult) GetOutputEncoding() string {
+	if m != nil {
+		return m.OutputEncoding
+	}
+	return ""
+}
+
+func (m *CmdResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *CmdResult) GetRecords() []*_struct.Struct {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+// Records are records parsed from output of a command
+type Records struct {
+	Records              []*_struct.Struct `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *Records) Reset()         { *m = Records{} }
+func (m *Records) String() string { return proto.CompactTextString(m) }
+func (*Records) ProtoMessage()    {}
+func (*Records) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{3}
+}
+
+func (m *Records) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Records.Unmarshal(m, b)
+}
+func (m *Records) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Records.Marshal(b, m, deterministic)
+}
+func (m *Records) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Records.Merge(m, src)
+}
+func (m *Records) XXX_Size() int {
+	return xxx_messageInfo_Records.Size(m)
+}
+func (m *Records) XXX_DiscardUnknown() {
+	xxx_messageInfo_Records.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Records proto.InternalMessageInfo
+
+func (m *Records) GetRecords() []*_struct.Struct {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+type CliResponse struct {
+	Retcode              int32               `protobuf:"varint,1,opt,name=retcode,proto3" json:"retcode,omitempty"`
+	Message              string              `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Device               string              `protobuf:"bytes,3,opt,name=device,proto3" json:"device,omitempty"`
+	CmdsStd              map[string]string   `protobuf:"bytes,4,rep,name=cmds_std,json=cmdsStd,proto3" json:"cmds_std,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Results              []*CmdResult        `protobuf:"bytes,5,rep,name=results,proto3" json:"results,omitempty"`
+	Records              map[string]*Records `protobuf:"bytes,6,rep,name=records,proto3" json:"records,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	ParseErrors          map[string]string   `protobuf:"bytes,7,rep,name=parse_errors,json=parseErrors,proto3" json:"parse_errors,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Recovered            bool                `protobuf:"varint,8,opt,name=recovered,proto3" json:"recovered,omitempty"`
+	Recording            string              `protobuf:"bytes,9,opt,name=recording,proto3" json:"recording,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *CliResponse) Reset()         { *m = CliResponse{} }
+func (m *CliResponse) String() string { return proto.CompactTextString(m) }
+func (*CliResponse) ProtoMessage()    {}
+func (*CliResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{4}
+}
+
+func (m *CliResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CliResponse.Unmarshal(m, b)
+}
+func (m *CliResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CliResponse.Marshal(b, m, deterministic)
+}
+func (m *CliResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CliResponse.Merge(m, src)
+}
+func (m *CliResponse) XXX_Size() int {
+	return xxx_messageInfo_CliResponse.Size(m)
+}
+func (m *CliResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CliResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CliResponse proto.InternalMessageInfo
+
+func (m *CliResponse) GetRetcode() int32 {
+	if m != nil {
+		return m.Retcode
+	}
+	return 0
+}
+
+func (m *CliResponse) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *CliResponse) GetDevice() string {
+	if m != nil {
+		return m.Device
+	}
+	return ""
+}
+
+func (m *CliResponse) GetCmdsStd() map[string]string {
+	if m != nil {
+		return m.CmdsStd
+	}
+	return nil
+}
+
+func (m *CliResponse) GetResults() []*CmdResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+func (m *CliResponse) GetRecords() map[string]*Records {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+func (m *CliResponse) GetParseErrors() map[string]string {
+	if m != nil {
+		return m.ParseErrors
+	}
+	return nil
+}
+
+func (m *CliResponse) GetRecovered() bool {
+	if m != nil {
+		return m.Recovered
+	}
+	return false
+}
+
+func (m *CliResponse) GetRecording() string {
+	if m != nil {
+		return m.Recording
+	}
+	return ""
+}
+
+// CliStreamMessage carries either a command result or the final response
+type CliStreamMessage struct {
+	Result               *CmdResult   `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	Response             *CliResponse `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *CliStreamMessage) Reset()         { *m = CliStreamMessage{} }
+func (m *CliStreamMessage) String() string { return proto.CompactTextString(m) }
+func (*CliStreamMessage) ProtoMessage()    {}
+func (*CliStreamMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{5}
+}
+
+func (m *CliStreamMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CliStreamMessage.Unmarshal(m, b)
+}
+func (m *CliStreamMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CliStreamMessage.Marshal(b, m, deterministic)
+}
+func (m *CliStreamMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CliStreamMessage.Merge(m, src)
+}
+func (m *CliStreamMessage) XXX_Size() int {
+	return xxx_messageInfo_CliStreamMessage.Size(m)
+}
+func (m *CliStreamMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_CliStreamMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CliStreamMessage proto.InternalMessageInfo
+
+func (m *CliStreamMessage) GetResult() *CmdResult {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *CliStreamMessage) GetResponse() *CliResponse {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+type ConfigResponse struct {
+	Retcode              int32    `protobuf:"varint,1,opt,name=retcode,proto3" json:"retcode,omitempty"`
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Device               string   `protobuf:"bytes,3,opt,name=device,proto3" json:"device,omitempty"`
+	Format               string   `protobuf:"bytes,4,opt,name=format,proto3" json:"format,omitempty"`
+	Config               string   `protobuf:"bytes,5,opt,name=config,proto3" json:"config,omitempty"`
+	Checksum             string   `protobuf:"bytes,6,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	Version              int32    `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ConfigResponse) Reset()         { *m = ConfigResponse{} }
+func (m *ConfigResponse) String() string { return proto.CompactTextString(m) }
+func (*ConfigResponse) ProtoMessage()    {}
+func (*ConfigResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{6}
+}
+
+func (m *ConfigResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConfigResponse.Unmarshal(m, b)
+}
+func (m *ConfigResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConfigResponse.Marshal(b, m, deterministic)
+}
+func (m *ConfigResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConfigResponse.Merge(m, src)
+}
+func (m *ConfigResponse) XXX_Size() int {
+	return xxx_messageInfo_ConfigResponse.Size(m)
+}
+func (m *ConfigResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConfigResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConfigResponse proto.InternalMessageInfo
+
+func (m *ConfigResponse) GetRetcode() int32 {
+	if m != nil {
+		return m.Retcode
+	}
+	return 0
+}
+
+func (m *ConfigResponse) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *ConfigResponse) GetDevice() string {
+	if m != nil {
+		return m.Device
+	}
+	return ""
+}
+
+func (m *ConfigResponse) GetFormat() string {
+	if m != nil {
+		return m.Format
+	}
+	return ""
+}
+
+func (m *ConfigResponse) GetConfig() string {
+	if m != nil {
+		return m.Config
+	}
+	return ""
+}
+
+func (m *ConfigResponse) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+func (m *ConfigResponse) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type PortCheckRequest struct {
+	Ip                   string   `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+	Port                 string   `protobuf:"bytes,2,opt,name=port,proto3" json:"port,omitempty"`
+	Proto                string   `protobuf:"bytes,3,opt,name=proto,proto3" json:"proto,omitempty"`
+	Timeout              int64    `protobuf:"varint,4,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	LogPrefix            string   `protobuf:"bytes,5,opt,name=log_prefix,json=logPrefix,proto3" json:"log_prefix,omitempty"`
+	EnablePwd            string   `protobuf:"bytes,6,opt,name=enable_pwd,json=enablePwd,proto3" json:"enable_pwd,omitempty"`
+	Session              string   `protobuf:"bytes,7,opt,name=session,proto3" json:"session,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PortCheckRequest) Reset()         { *m = PortCheckRequest{} }
+func (m *PortCheckRequest) String() string { return proto.CompactTextString(m) }
+func (*PortCheckRequest) ProtoMessage()    {}
+func (*PortCheckRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{7}
+}
+
+func (m *PortCheckRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PortCheckRequest.Unmarshal(m, b)
+}
+func (m *PortCheckRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PortCheckRequest.Marshal(b, m, deterministic)
+}
+func (m *PortCheckRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PortCheckRequest.Merge(m, src)
+}
+func (m *PortCheckRequest) XXX_Size() int {
+	return xxx_messageInfo_PortCheckRequest.Size(m)
+}
+func (m *PortCheckRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PortCheckRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PortCheckRequest proto.InternalMessageInfo
+
+func (m *PortCheckRequest) GetIp() string {
+	if m != nil {
+		return m.Ip
+	}
+	return ""
+}
+
+func (m *PortCheckRequest) GetPort() string {
+	if m != nil {
+		return m.Port
+	}
+	return ""
+}
+
+func (m *PortCheckRequest) GetProto() string {
+	if m != nil {
+		return m.Proto
+	}
+	return ""
+}
+
+func (m *PortCheckRequest) GetTimeout() int64 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *PortCheckRequest) GetLogPrefix() string {
+	if m != nil {
+		return m.LogPrefix
+	}
+	return ""
+}
+
+func (m *PortCheckRequest) GetEnablePwd() string {
+	if m != nil {
+		return m.EnablePwd
+	}
+	return ""
+}
+
+func (m *PortCheckRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+type PortCheckResponse struct {
+	Retcode              int32    `protobuf:"varint,1,opt,name=retcode,proto3" json:"retcode,omitempty"`
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PortCheckResponse) Reset()         { *m = PortCheckResponse{} }
+func (m *PortCheckResponse) String() string { return proto.CompactTextString(m) }
+func (*PortCheckResponse) ProtoMessage()    {}
+func (*PortCheckResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{8}
+}
+
+func (m *PortCheckResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PortCheckResponse.Unmarshal(m, b)
+}
+func (m *PortCheckResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PortCheckResponse.Marshal(b, m, deterministic)
+}
+func (m *PortCheckResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PortCheckResponse.Merge(m, src)
+}
+func (m *PortCheckResponse) XXX_Size() int {
+	return xxx_messageInfo_PortCheckResponse.Size(m)
+}
+func (m *PortCheckResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PortCheckResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PortCheckResponse proto.InternalMessageInfo
+
+func (m *PortCheckResponse) GetRetcode() int32 {
+	if m != nil {
+		return m.Retcode
+	}
+	return 0
+}
+
+func (m *PortCheckResponse) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type DeviceSelector struct {
+	Name                 string            `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Vendor               string            `protobuf:"bytes,2,opt,name=vendor,proto3" json:"vendor,omitempty"`
+	Labels               map[string]string `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *DeviceSelector) Reset()         { *m = DeviceSelector{} }
+func (m *DeviceSelector) String() string { return proto.CompactTextString(m) }
+func (*DeviceSelector) ProtoMessage()    {}
+func (*DeviceSelector) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{9}
+}
+
+func (m *DeviceSelector) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeviceSelector.Unmarshal(m, b)
+}
+func (m *DeviceSelector) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeviceSelector.Marshal(b, m, deterministic)
+}
+func (m *DeviceSelector) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeviceSelector.Merge(m, src)
+}
+func (m *DeviceSelector) XXX_Size() int {
+	return xxx_messageInfo_DeviceSelector.Size(m)
+}
+func (m *DeviceSelector) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeviceSelector.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeviceSelector proto.InternalMessageInfo
+
+func (m *DeviceSelector) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *DeviceSelector) GetVendor() string {
+	if m != nil {
+		return m.Vendor
+	}
+	return ""
+}
+
+func (m *DeviceSelector) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+type BatchRequest struct {
+	Devices              []*CliRequest    `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+	Selector             *DeviceSelector  `protobuf:"bytes,2,opt,name=selector,proto3" json:"selector,omitempty"`
+	Commands             []string         `protobuf:"bytes,3,rep,name=commands,proto3" json:"commands,omitempty"`
+	Mode                 string           `protobuf:"bytes,4,opt,name=mode,proto3" json:"mode,omitempty"`
+	Timeout              int64            `protobuf:"varint,5,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Concurrency          int32            `protobuf:"varint,6,opt,name=concurrency,proto3" json:"concurrency,omitempty"`
+	VendorConcurrency    map[string]int32 `protobuf:"bytes,7,rep,name=vendor_concurrency,json=vendorConcurrency,proto3" json:"vendor_concurrency,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	Session              string           `protobuf:"bytes,8,opt,name=session,proto3" json:"session,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *BatchRequest) Reset()         { *m = BatchRequest{} }
+func (m *BatchRequest) String() string { return proto.CompactTextString(m) }
+func (*BatchRequest) ProtoMessage()    {}
+func (*BatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{10}
+}
+
+func (m *BatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchRequest.Unmarshal(m, b)
+}
+func (m *BatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchRequest.Marshal(b, m, deterministic)
+}
+func (m *BatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchRequest.Merge(m, src)
+}
+func (m *BatchRequest) XXX_Size() int {
+	return xxx_messageInfo_BatchRequest.Size(m)
+}
+func (m *BatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchRequest proto.InternalMessageInfo
+
+func (m *BatchRequest) GetDevices() []*CliRequest {
+	if m != nil {
+		return m.Devices
+	}
+	return nil
+}
+
+func (m *BatchRequest) GetSelector() *DeviceSelector {
+	if m != nil {
+		return m.Selector
+	}
+	return nil
+}
+
+func (m *BatchRequest) GetCommands() []string {
+	if m != nil {
+		return m.Commands
+	}
+	return nil
+}
+
+func (m *BatchRequest) GetMode() string {
+	if m != nil {
+		return m.Mode
+	}
+	return ""
+}
+
+func (m *BatchRequest) GetTimeout() int64 {
+	if m != nil {
+		return m.Timeout
+	}
+	return 0
+}
+
+func (m *BatchRequest) GetConcurrency() int32 {
+	if m != nil {
+		return m.Concurrency
+	}
+	return 0
+}
+
+func (m *BatchRequest) GetVendorConcurrency() map[string]int32 {
+	if m != nil {
+		return m.VendorConcurrency
+	}
+	return nil
+}
+
+func (m *BatchRequest) GetSession() string {
+	if m != nil {
+		return m.Session
+	}
+	return ""
+}
+
+type BatchResult struct {
+	Device               string       `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	Address              string       `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Vendor               string       `protobuf:"bytes,3,opt,name=vendor,proto3" json:"vendor,omitempty"`
+	Response             *CliResponse `protobuf:"bytes,4,opt,name=response,proto3" json:"response,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *BatchResult) Reset()         { *m = BatchResult{} }
+func (m *BatchResult) String() string { return proto.CompactTextString(m) }
+func (*BatchResult) ProtoMessage()    {}
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{11}
+}
+
+func (m *BatchResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchResult.Unmarshal(m, b)
+}
+func (m *BatchResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchResult.Marshal(b, m, deterministic)
+}
+func (m *BatchResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchResult.Merge(m, src)
+}
+func (m *BatchResult) XXX_Size() int {
+	return xxx_messageInfo_BatchResult.Size(m)
+}
+func (m *BatchResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchResult proto.InternalMessageInfo
+
+func (m *BatchResult) GetDevice() string {
+	if m != nil {
+		return m.Device
+	}
+	return ""
+}
+
+func (m *BatchResult) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *BatchResult) GetVendor() string {
+	if m != nil {
+		return m.Vendor
+	}
+	return ""
+}
+
+func (m *BatchResult) GetResponse() *CliResponse {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+// Devices are device names
+type Devices struct {
+	Devices              []string `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Devices) Reset()         { *m = Devices{} }
+func (m *Devices) String() string { return proto.CompactTextString(m) }
+func (*Devices) ProtoMessage()    {}
+func (*Devices) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{12}
+}
+
+func (m *Devices) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Devices.Unmarshal(m, b)
+}
+func (m *Devices) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Devices.Marshal(b, m, deterministic)
+}
+func (m *Devices) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Devices.Merge(m, src)
+}
+func (m *Devices) XXX_Size() int {
+	return xxx_messageInfo_Devices.Size(m)
+}
+func (m *Devices) XXX_DiscardUnknown() {
+	xxx_messageInfo_Devices.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Devices proto.InternalMessageInfo
+
+func (m *Devices) GetDevices() []string {
+	if m != nil {
+		return m.Devices
+	}
+	return nil
+}
+
+type BatchSummary struct {
+	Total                int32                `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Succeeded            int32                `protobuf:"varint,2,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	Failed               int32                `protobuf:"varint,3,opt,name=failed,proto3" json:"failed,omitempty"`
+	Retcodes             map[int32]*Devices   `protobuf:"bytes,4,rep,name=retcodes,proto3" json:"retcodes,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Start                *timestamp.Timestamp `protobuf:"bytes,5,opt,name=start,proto3" json:"start,omitempty"`
+	End                  *timestamp.Timestamp `protobuf:"bytes,6,opt,name=end,proto3" json:"end,omitempty"`
+	Duration             int64                `protobuf:"varint,7,opt,name=duration,proto3" json:"duration,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *BatchSummary) Reset()         { *m = BatchSummary{} }
+func (m *BatchSummary) String() string { return proto.CompactTextString(m) }
+func (*BatchSummary) ProtoMessage()    {}
+func (*BatchSummary) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{13}
+}
+
+func (m *BatchSummary) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchSummary.Unmarshal(m, b)
+}
+func (m *BatchSummary) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchSummary.Marshal(b, m, deterministic)
+}
+func (m *BatchSummary) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchSummary.Merge(m, src)
+}
+func (m *BatchSummary) XXX_Size() int {
+	return xxx_messageInfo_BatchSummary.Size(m)
+}
+func (m *BatchSummary) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchSummary.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchSummary proto.InternalMessageInfo
+
+func (m *BatchSummary) GetTotal() int32 {
+	if m != nil {
+		return m.Total
+	}
+	return 0
+}
+
+func (m *BatchSummary) GetSucceeded() int32 {
+	if m != nil {
+		return m.Succeeded
+	}
+	return 0
+}
+
+func (m *BatchSummary) GetFailed() int32 {
+	if m != nil {
+		return m.Failed
+	}
+	return 0
+}
+
+func (m *BatchSummary) GetRetcodes() map[int32]*Devices {
+	if m != nil {
+		return m.Retcodes
+	}
+	return nil
+}
+
+func (m *BatchSummary) GetStart() *timestamp.Timestamp {
+	if m != nil {
+		return m.Start
+	}
+	return nil
+}
+
+func (m *BatchSummary) GetEnd() *timestamp.Timestamp {
+	if m != nil {
+		return m.End
+	}
+	return nil
+}
+
+func (m *BatchSummary) GetDuration() int64 {
+	if m != nil {
+		return m.Duration
+	}
+	return 0
+}
+
+type BatchResponse struct {
+	Retcode              int32          `protobuf:"varint,1,opt,name=retcode,proto3" json:"retcode,omitempty"`
+	Message              string         `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Summary              *BatchSummary  `protobuf:"bytes,3,opt,name=summary,proto3" json:"summary,omitempty"`
+	Results              []*BatchResult `protobuf:"bytes,4,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *BatchResponse) Reset()         { *m = BatchResponse{} }
+func (m *BatchResponse) String() string { return proto.CompactTextString(m) }
+func (*BatchResponse) ProtoMessage()    {}
+func (*BatchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{14}
+}
+
+func (m *BatchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchResponse.Unmarshal(m, b)
+}
+func (m *BatchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchResponse.Marshal(b, m, deterministic)
+}
+func (m *BatchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchResponse.Merge(m, src)
+}
+func (m *BatchResponse) XXX_Size() int {
+	return xxx_messageInfo_BatchResponse.Size(m)
+}
+func (m *BatchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchResponse proto.InternalMessageInfo
+
+func (m *BatchResponse) GetRetcode() int32 {
+	if m != nil {
+		return m.Retcode
+	}
+	return 0
+}
+
+func (m *BatchResponse) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *BatchResponse) GetSummary() *BatchSummary {
+	if m != nil {
+		return m.Summary
+	}
+	return nil
+}
+
+func (m *BatchResponse) GetResults() []*BatchResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+// BatchStreamMessage carries either a device result or the final response
+type BatchStreamMessage struct {
+	Result               *BatchResult   `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	Response             *BatchResponse `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *BatchStreamMessage) Reset()         { *m = BatchStreamMessage{} }
+func (m *BatchStreamMessage) String() string { return proto.CompactTextString(m) }
+func (*BatchStreamMessage) ProtoMessage()    {}
+func (*BatchStreamMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dc153b35ed1fcca, []int{15}
+}
+
+func (m *BatchStreamMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchStreamMessage.Unmarshal(m, b)
+}
+func (m *BatchStreamMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchStreamMessage.Marshal(b, m, deterministic)
+}
+func (m *BatchStreamMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchStreamMessage.Merge(m, src)
+}
+func (m *BatchStreamMessage) XXX_Size() int {
+	return xxx_messageInfo_BatchStreamMessage.Size(m)
+}
+func (m *BatchStreamMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchStreamMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchStreamMessage proto.InternalMessageInfo
+
+func (m *BatchStreamMessage) GetResult() *BatchResult {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *BatchStreamMessage) GetResponse() *BatchResponse {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Auth)(nil), "netd.Auth")
+	proto.RegisterType((*CliRequest)(nil), "netd.CliRequest")
+	proto.RegisterMapType((map[string]string)(nil), "netd.CliRequest.TemplatesEntry")
+	proto.RegisterType((*CmdResult)(nil), "netd.CmdResult")
+	proto.RegisterType((*Records)(nil), "netd.Records")
+	proto.RegisterType((*CliResponse)(nil), "netd.CliResponse")
+	proto.RegisterMapType((map[string]string)(nil), "netd.CliResponse.CmdsStdEntry")
+	proto.RegisterMapType((map[string]string)(nil), "netd.CliResponse.ParseErrorsEntry")
+	proto.RegisterMapType((map[string]*Records)(nil), "netd.CliResponse.RecordsEntry")
+	proto.RegisterType((*CliStreamMessage)(nil), "netd.CliStreamMessage")
+	proto.RegisterType((*ConfigResponse)(nil), "netd.ConfigResponse")
+	proto.RegisterType((*PortCheckRequest)(nil), "netd.PortCheckRequest")
+	proto.RegisterType((*PortCheckResponse)(nil), "netd.PortCheckResponse")
+	proto.RegisterType((*DeviceSelector)(nil), "netd.DeviceSelector")
+	proto.RegisterMapType((map[string]string)(nil), "netd.DeviceSelector.LabelsEntry")
+	proto.RegisterType((*BatchRequest)(nil), "netd.BatchRequest")
+	proto.RegisterMapType((map[string]int32)(nil), "netd.BatchRequest.VendorConcurrencyEntry")
+	proto.RegisterType((*BatchResult)(nil), "netd.BatchResult")
+	proto.RegisterType((*Devices)(nil), "netd.Devices")
+	proto.RegisterType((*BatchSummary)(nil), "netd.BatchSummary")
+	proto.RegisterMapType((map[int32]*Devices)(nil), "netd.BatchSummary.RetcodesEntry")
+	proto.RegisterType((*BatchResponse)(nil), "netd.BatchResponse")
+	proto.RegisterType((*BatchStreamMessage)(nil), "netd.BatchStreamMessage")
+}
+
+func init() { proto.RegisterFile("protocol/pb/netd.proto", fileDescriptor_2dc153b35ed1fcca) }
+
+var fileDescriptor_2dc153b35ed1fcca = []byte{
+	// 1469 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0x6d, 0x6f, 0x1b, 0xc5,
+	0x13, 0xd7, 0xd9, 0x77, 0x7e, 0x18, 0x27, 0x69, 0xb2, 0xcd, 0xdf, 0x5d, 0x59, 0xfd, 0xb7, 0x96,
+	0x2b, 0xd4, 0x94, 0xb6, 0x76, 0x09, 0x42, 0xa4, 0x25, 0x20, 0x51, 0x37, 0x20, 0x10, 0xa0, 0xe8,
+	0x52, 0x21, 0xc4, 0x0b, 0xa2, 0xf3, 0xdd, 0xc6, 0x39, 0x7a, 0x4f, 0xec, 0xee, 0x25, 0xcd, 0x17,
+	0xe0, 0x03, 0xf0, 0x12, 0x24, 0xf8, 0x0c, 0x7c, 0x01, 0x5e, 0xc0, 0x17, 0xe1, 0xa3, 0xa0, 0x7d,
+	0xb2, 0xf7, 0x6c, 0x93, 0x36, 0x95, 0x78, 0xb7, 0x33, 0x3b, 0x33, 0x37, 0x3b, 0xf3, 0x9b, 0x07,
+	0x1d, 0x74, 0x0b, 0x9a, 0xf3, 0x3c, 0xcc, 0x93, 0x51, 0x31, 0x19, 0x65, 0x84, 0x47, 0x43, 0xc9,
+	0x40, 0xae, 0x38, 0xf7, 0x6e, 0x4e, 0xf3, 0x7c, 0x9a, 0x90, 0x91, 0xe4, 0x4d, 0xca, 0x93, 0x11,
+	0xe3, 0xb4, 0x0c, 0xb9, 0x92, 0xe9, 0xdd, 0x5e, 0xbc, 0xe5, 0x71, 0x4a, 0x18, 0x0f, 0xd2, 0x42,
+	0x09, 0x0c, 0xbe, 0x03, 0xf7, 0xe3, 0x92, 0x9f, 0xa2, 0x1e, 0xb4, 0x4a, 0x46, 0x68, 0x16, 0xa4,
+	0x04, 0x3b, 0x7d, 0x67, 0xa7, 0xed, 0xcf, 0x68, 0x71, 0x57, 0x04, 0x8c, 0x9d, 0xe7, 0x34, 0xc2,
+	0x35, 0x75, 0x67, 0x68, 0x74, 0x0b, 0x20, 0xa4, 0x24, 0x22, 0x19, 0x8f, 0x83, 0x04, 0xd7, 0xe5,
+	0xad, 0xc5, 0x19, 0xfc, 0xe6, 0x01, 0x8c, 0x93, 0xd8, 0x27, 0x3f, 0x94, 0x84, 0x71, 0xd4, 0x85,
+	0xc6, 0x19, 0xc9, 0xa2, 0x9c, 0xea, 0x8f, 0x68, 0x0a, 0x21, 0x70, 0xf9, 0x45, 0x41, 0xb4, 0x79,
+	0x79, 0x46, 0x18, 0x9a, 0x67, 0x84, 0xb2, 0x38, 0xcf, 0xb4, 0x5d, 0x43, 0x0a, 0x2b, 0x11, 0x39,
+	0x8b, 0x43, 0x82, 0x5d, 0x65, 0x45, 0x51, 0xc2, 0x4a, 0x9a, 0x47, 0x04, 0x7b, 0xca, 0x8a, 0x38,
+	0x4b, 0xe7, 0x75, 0xfc, 0x70, 0x43, 0x3b, 0xaf, 0x69, 0x74, 0x0b, 0xdc, 0xa0, 0xe4, 0xa7, 0xb8,
+	0xd9, 0x77, 0x76, 0x3a, 0xbb, 0x30, 0x94, 0xc1, 0x15, 0xe1, 0xf0, 0x25, 0x5f, 0x78, 0x10, 0x44,
+	0x11, 0x25, 0x8c, 0xe1, 0x96, 0xf2, 0x40, 0x93, 0xc2, 0x6a, 0x98, 0xa7, 0x69, 0x90, 0x45, 0x0c,
+	0xb7, 0xfb, 0x75, 0x61, 0xd5, 0xd0, 0xc2, 0xbb, 0x93, 0x9c, 0xa6, 0x01, 0xc7, 0xa0, 0xbc, 0x53,
+	0x94, 0xb0, 0x26, 0xa2, 0x9f, 0x97, 0x1c, 0x77, 0xfa, 0xce, 0x4e, 0xdd, 0x37, 0x24, 0xfa, 0x3f,
+	0x40, 0x92, 0x4f, 0x8f, 0x0b, 0x4a, 0x4e, 0xe2, 0x97, 0x78, 0x4d, 0x6a, 0xb5, 0x93, 0x7c, 0x7a,
+	0x28, 0x19, 0xe2, 0x9a, 0x64, 0xc1, 0x24, 0x21, 0xc7, 0xc5, 0x79, 0x84, 0xd7, 0xd5, 0xb5, 0xe2,
+	0x1c, 0x9e, 0x47, 0xc2, 0x2e, 0x23, 0x4c, 0xc6, 0x69, 0x43, 0x79, 0xa9, 0x49, 0xb4, 0x09, 0x75,
+	0x1a, 0x9c, 0xe3, 0x6b, 0x7d, 0x67, 0xa7, 0xe5, 0x8b, 0x23, 0xda, 0x06, 0xaf, 0x08, 0x28, 0x23,
+	0x78, 0x53, 0xf2, 0x14, 0x81, 0x3e, 0x84, 0x36, 0x27, 0x69, 0x91, 0x04, 0x9c, 0x30, 0xbc, 0xd5,
+	0xaf, 0xef, 0x74, 0x76, 0x6f, 0xab, 0x60, 0xcc, 0x53, 0x37, 0x7c, 0x6e, 0x24, 0x0e, 0x32, 0x4e,
+	0x2f, 0xfc, 0xb9, 0x06, 0x7a, 0x0b, 0x36, 0xe2, 0xac, 0x28, 0xf9, 0x31, 0xc9, 0xc2, 0x3c, 0x8a,
+	0xb3, 0x29, 0x46, 0xd2, 0x8f, 0x75, 0xc9, 0x3d, 0xd0, 0x4c, 0x74, 0x17, 0xae, 0xe5, 0x25, 0xaf,
+	0xc8, 0x5d, 0x97, 0x72, 0x1b, 0x8a, 0x3d, 0x13, 0xec, 0x42, 0x83, 0x92, 0x50, 0xa0, 0x6d, 0x5b,
+	0x7a, 0xa9, 0x29, 0xc5, 0x8f, 0x82, 0x90, 0xe3, 0xff, 0x19, 0xbe, 0xa0, 0x7a, 0xfb, 0xb0, 0x51,
+	0x75, 0x4e, 0x3c, 0xfc, 0x05, 0xb9, 0xd0, 0x18, 0x13, 0x47, 0xf1, 0xf0, 0xb3, 0x20, 0x29, 0x0d,
+	0xc2, 0x14, 0xf1, 0xa4, 0xb6, 0xe7, 0x0c, 0x7e, 0xae, 0x43, 0x7b, 0x9c, 0x46, 0x3e, 0x61, 0x65,
+	0x22, 0x93, 0xa4, 0x13, 0xa9, 0xb5, 0x0d, 0x29, 0xbe, 0xae, 0xfc, 0xd4, 0x26, 0x34, 0x85, 0x1e,
+	0x81, 0xc7, 0x78, 0x40, 0xb9, 0x04, 0x69, 0x67, 0xb7, 0x37, 0x54, 0x25, 0x37, 0x34, 0x25, 0x37,
+	0x7c, 0x6e, 0x4a, 0xce, 0x57, 0x82, 0xe8, 0x01, 0xd4, 0x49, 0x16, 0x61, 0xf7, 0x95, 0xf2, 0x42,
+	0x4c, 0x40, 0x2d, 0x2a, 0x69, 0xc0, 0x45, 0x7e, 0x3d, 0x89, 0x9b, 0x19, 0x3d, 0x03, 0x7c, 0xc3,
+	0x02, 0x7c, 0x17, 0x1a, 0x05, 0xcd, 0xd3, 0x82, 0x4b, 0x58, 0xb7, 0x7d, 0x4d, 0x09, 0x3b, 0xb3,
+	0xb8, 0x2b, 0x34, 0xcf, 0xe8, 0x15, 0x19, 0x6c, 0xbf, 0x66, 0x06, 0x61, 0x65, 0x06, 0xb7, 0xc1,
+	0x23, 0x94, 0xe6, 0x54, 0x02, 0xbd, 0xed, 0x2b, 0x02, 0xbd, 0x03, 0x4d, 0x95, 0x49, 0x86, 0xd7,
+	0x24, 0xc8, 0x6e, 0x2c, 0xbd, 0xfd, 0x48, 0x36, 0x2f, 0xdf, 0xc8, 0x0d, 0xf6, 0xa1, 0xe9, 0xab,
+	0xa3, 0xad, 0xed, 0xbc, 0xa6, 0xf6, 0x5f, 0x2e, 0x74, 0x24, 0x82, 0x59, 0x91, 0x67, 0x4c, 0x76,
+	0x14, 0x4a, 0x78, 0x28, 0x22, 0x26, 0x92, 0xeb, 0xf9, 0x86, 0x14, 0x37, 0x29, 0x61, 0x2c, 0x98,
+	0x1a, 0x80, 0x18, 0xd2, 0xea, 0x35, 0xf5, 0x4a, 0xaf, 0x79, 0x0c, 0xad, 0x30, 0x8d, 0xd8, 0x31,
+	0xe3, 0x22, 0x93, 0xc2, 0x9f, 0x5b, 0x56, 0xc9, 0xa8, 0x0f, 0x0e, 0xc7, 0x69, 0xc4, 0x8e, 0x78,
+	0xa4, 0x2a, 0xa6, 0x19, 0x2a, 0x0a, 0xdd, 0x13, 0x6e, 0x08, 0xb4, 0x31, 0xec, 0x49, 0xcd, 0x6b,
+	0x5a, 0xd3, 0xa0, 0xd0, 0x37, 0xf7, 0x68, 0x6f, 0xfe, 0xe8, 0xc6, 0xbf, 0x7d, 0x44, 0x07, 0x48,
+	0x7f, 0x44, 0x8b, 0xa3, 0x03, 0x58, 0x93, 0xc5, 0x7d, 0x2c, 0x63, 0xcf, 0x70, 0x53, 0xaa, 0x0f,
+	0x96, 0xd5, 0x0f, 0x85, 0xd4, 0x81, 0x14, 0x52, 0x26, 0x3a, 0xc5, 0x9c, 0x83, 0x6e, 0x42, 0x5b,
+	0x58, 0x3c, 0x23, 0x94, 0x44, 0x12, 0x36, 0x2d, 0x7f, 0xce, 0x30, 0xb7, 0xd4, 0x82, 0xcc, 0x9c,
+	0xd1, 0x7b, 0x02, 0x6b, 0x76, 0x00, 0xae, 0x52, 0x95, 0xbd, 0xcf, 0x60, 0xcd, 0x7e, 0xd7, 0x0a,
+	0xdd, 0x3b, 0xb6, 0x6e, 0x67, 0x77, 0x5d, 0xbd, 0x4c, 0x2b, 0xd9, 0xa6, 0x3e, 0x82, 0xcd, 0xc5,
+	0x37, 0x5e, 0xa9, 0x41, 0x7c, 0x0f, 0x9b, 0xe3, 0x24, 0x3e, 0xe2, 0x94, 0x04, 0xe9, 0x97, 0x1a,
+	0x15, 0x77, 0xa1, 0xa1, 0x52, 0x24, 0x4d, 0xac, 0xc8, 0xa0, 0xbe, 0x46, 0x0f, 0xa1, 0x45, 0x75,
+	0xa4, 0xb5, 0xa3, 0x5b, 0x4b, 0x29, 0xf0, 0x67, 0x22, 0x83, 0x3f, 0x1d, 0xd8, 0x18, 0xe7, 0xd9,
+	0x49, 0x3c, 0xfd, 0x4f, 0x40, 0x3b, 0x1f, 0x4d, 0x6e, 0x65, 0x34, 0x75, 0xa1, 0x11, 0xca, 0xaf,
+	0xea, 0xd1, 0xa9, 0x29, 0x39, 0xe6, 0x4e, 0x49, 0xf8, 0x82, 0x95, 0xa9, 0x19, 0x9e, 0x86, 0xb6,
+	0xc7, 0x73, 0x53, 0xf9, 0xa5, 0xc9, 0xc1, 0x1f, 0x0e, 0x6c, 0x1e, 0xe6, 0x94, 0x8f, 0x85, 0xa8,
+	0x99, 0xfc, 0x1b, 0x50, 0x8b, 0x0b, 0x1d, 0xf0, 0x5a, 0x5c, 0x88, 0xd6, 0x55, 0xe4, 0xd4, 0x34,
+	0x53, 0x79, 0x96, 0xd3, 0x49, 0xd4, 0xb2, 0xf6, 0x5a, 0x11, 0xf6, 0xdc, 0x74, 0x2f, 0x9b, 0x9b,
+	0xde, 0xe5, 0x73, 0xb3, 0x71, 0xc9, 0xdc, 0x6c, 0x56, 0xe6, 0xe6, 0xe0, 0x53, 0xd8, 0xb2, 0xfc,
+	0x7f, 0xf3, 0x3c, 0x0c, 0x7e, 0x77, 0x60, 0xe3, 0x99, 0x0c, 0xfd, 0x11, 0x49, 0x48, 0xc8, 0xd5,
+	0xa6, 0x63, 0x2d, 0x59, 0xf2, 0x6c, 0x6d, 0x45, 0xb5, 0xca, 0x56, 0xb4, 0x07, 0x8d, 0x24, 0x98,
+	0x90, 0x84, 0xe1, 0xba, 0xac, 0xde, 0xbe, 0x82, 0x4e, 0xd5, 0xe2, 0xf0, 0x0b, 0x29, 0xa2, 0x6a,
+	0x57, 0xcb, 0xf7, 0x1e, 0x43, 0xc7, 0x62, 0x5f, 0x09, 0xee, 0x3f, 0xd5, 0x61, 0xed, 0x69, 0xc0,
+	0xc3, 0x53, 0x93, 0xb9, 0xb7, 0xa1, 0xa9, 0xe0, 0x63, 0x1a, 0xef, 0xe6, 0xe2, 0x6e, 0xe0, 0x1b,
+	0x01, 0xf4, 0x08, 0x5a, 0x4c, 0xfb, 0xa5, 0xe1, 0xbe, 0xbd, 0xca, 0x67, 0x7f, 0x26, 0x55, 0xd9,
+	0xa4, 0xea, 0x0b, 0x9b, 0x94, 0x19, 0x6f, 0xae, 0x35, 0xde, 0x2c, 0x34, 0x78, 0x55, 0x34, 0xf4,
+	0xa1, 0x13, 0xe6, 0x59, 0x58, 0x52, 0x4a, 0xb2, 0xf0, 0x42, 0xe6, 0xdb, 0xf3, 0x6d, 0x16, 0xfa,
+	0x06, 0x90, 0x8a, 0xec, 0xb1, 0x2d, 0xa8, 0x3a, 0xe3, 0x3d, 0xe5, 0xa7, 0xfd, 0xf2, 0xe1, 0xd7,
+	0x52, 0x78, 0x3c, 0x97, 0x55, 0x41, 0xde, 0x3a, 0x5b, 0xe4, 0xdb, 0x58, 0x6a, 0x55, 0xb0, 0xd4,
+	0x7b, 0x06, 0xdd, 0xd5, 0x66, 0x5e, 0x95, 0x14, 0xcf, 0x4e, 0xca, 0x8f, 0x0e, 0x74, 0xb4, 0x6b,
+	0xb2, 0xad, 0xcc, 0x0b, 0xdc, 0xa9, 0x14, 0xb8, 0xb5, 0xb1, 0xd6, 0xaa, 0x1b, 0xeb, 0x1c, 0x63,
+	0xf5, 0x0a, 0xc6, 0xec, 0x06, 0xe5, 0xbe, 0xba, 0x41, 0xdd, 0x81, 0xe6, 0x33, 0x9d, 0x6b, 0x5c,
+	0xc5, 0x45, 0x7b, 0x86, 0x82, 0xc1, 0xdf, 0x35, 0x0d, 0xa1, 0xa3, 0x32, 0x4d, 0x03, 0x2a, 0x1f,
+	0xc6, 0x73, 0x1e, 0x24, 0xba, 0x72, 0x14, 0x21, 0xa6, 0x07, 0x2b, 0xc3, 0x90, 0x90, 0x88, 0x44,
+	0xfa, 0xc9, 0x73, 0x86, 0xec, 0x55, 0x41, 0x9c, 0x90, 0x48, 0x3a, 0xec, 0xf9, 0x9a, 0x42, 0xfb,
+	0xd0, 0xd2, 0x85, 0xc7, 0xb0, 0x6b, 0x97, 0x85, 0xfd, 0xc5, 0xa1, 0xaf, 0x45, 0x54, 0xc6, 0x66,
+	0x1a, 0xf3, 0x6d, 0xcd, 0xbb, 0xe2, 0xb6, 0xd6, 0xb8, 0xfa, 0xb6, 0xd6, 0xac, 0x6e, 0x6b, 0xbd,
+	0xcf, 0x61, 0xbd, 0xe2, 0x96, 0x8d, 0x00, 0xef, 0xb2, 0xa1, 0xa6, 0x23, 0x6e, 0x03, 0xe2, 0x57,
+	0x07, 0xd6, 0x0d, 0x20, 0xde, 0x7c, 0x4e, 0x3c, 0x80, 0x26, 0x53, 0x01, 0xd3, 0xdb, 0x2b, 0x5a,
+	0x0e, 0xa5, 0x6f, 0x44, 0xd0, 0xfd, 0xf9, 0xde, 0xa2, 0x02, 0xbf, 0x55, 0xa9, 0x99, 0xca, 0xe6,
+	0x32, 0x28, 0x00, 0x29, 0x2b, 0x95, 0xb9, 0x79, 0x6f, 0x61, 0x6e, 0xae, 0xb0, 0xa0, 0x05, 0xd0,
+	0x68, 0x69, 0x72, 0x5e, 0xaf, 0x0a, 0x2f, 0x40, 0x73, 0xf7, 0x97, 0x1a, 0xb8, 0x5f, 0x11, 0x1e,
+	0xa1, 0xfb, 0xe0, 0x1e, 0xbc, 0x24, 0x21, 0x5a, 0xea, 0x53, 0xbd, 0x65, 0x68, 0xa3, 0x3d, 0x00,
+	0x21, 0xac, 0xdc, 0x5c, 0xa1, 0xd2, 0x9d, 0x71, 0x2a, 0x2f, 0x79, 0xe4, 0xa0, 0xf7, 0xa0, 0xf3,
+	0x09, 0xe1, 0xe1, 0xa9, 0x9a, 0xd7, 0x2b, 0x54, 0x75, 0xeb, 0x5b, 0x98, 0xe7, 0xfb, 0xd0, 0x96,
+	0x83, 0x45, 0x4c, 0x18, 0xa4, 0xad, 0x2f, 0x4e, 0xcb, 0xde, 0x8d, 0x25, 0xbe, 0xd6, 0x7e, 0x1f,
+	0x3c, 0xf9, 0x7e, 0x84, 0x96, 0xfb, 0x55, 0x0f, 0xdb, 0xd9, 0xab, 0x7a, 0xfb, 0x74, 0xf8, 0xed,
+	0x83, 0x69, 0xcc, 0x4f, 0xcb, 0xc9, 0x30, 0xcc, 0xd3, 0x11, 0x7b, 0x71, 0xf1, 0x30, 0x4c, 0xf2,
+	0x32, 0x7a, 0xc8, 0x49, 0x28, 0x7f, 0x2a, 0x8c, 0xac, 0xbf, 0x0c, 0x1f, 0x14, 0x93, 0x49, 0x43,
+	0xd2, 0xef, 0xfe, 0x33, 0x00, 0xdc, 0x8e, 0x37, 0xdc, 0x7e, 0x10, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// NetdClient is the client API for Netd service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type NetdClient interface {
+	Exec(ctx context.Context, in *CliRequest, opts ...grpc.CallOption) (*CliResponse, error)
+	// ExecStream sends result of every command once it's done, then the response
+	ExecStream(ctx context.Context, in *CliRequest, opts ...grpc.CallOption) (Netd_ExecStreamClient, error)
+	FetchConfig(ctx context.Context, in *CliRequest, opts ...grpc.CallOption) (*ConfigResponse, error)
+	CheckPort(ctx context.Context, in *PortCheckRequest, opts ...grpc.CallOption) (*PortCheckResponse, error)
+	// Batch sends result of every device once it's done, then the response without results
+	Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (Netd_BatchClient, error)
+}
+
+type netdClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNetdClient(cc grpc.ClientConnInterface) NetdClient {
+	return &netdClient{cc}
+}
+
+func (c *netdClient) Exec(ctx context.Context, in *CliRequest, opts ...grpc.CallOption) (*CliResponse, error) {
+	out := new(CliResponse)
+	err := c.cc.Invoke(ctx, "/netd.Netd/Exec", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *netdClient) ExecStream(ctx context.Context, in *CliRequest, opts ...grpc.CallOption) (Netd_ExecStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Netd_serviceDesc.Streams[0], "/netd.Netd/ExecStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &netdExecStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Netd_ExecStreamClient interface {
+	Recv() (*CliStreamMessage, error)
+	grpc.ClientStream
+}
+
+type netdExecStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *netdExecStreamClient) Recv() (*CliStreamMessage, error) {
+	m := new(CliStreamMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *netdClient) FetchConfig(ctx context.Context, in *CliRequest, opts ...grpc.CallOption) (*ConfigResponse, error) {
+	out := new(ConfigResponse)
+	err := c.cc.Invoke(ctx, "/netd.Netd/FetchConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *netdClient) CheckPort(ctx context.Context, in *PortCheckRequest, opts ...grpc.CallOption) (*PortCheckResponse, error) {
+	out := new(PortCheckResponse)
+	err := c.cc.Invoke(ctx, "/netd.Netd/CheckPort", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *netdClient) Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (Netd_BatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Netd_serviceDesc.Streams[1], "/netd.Netd/Batch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &netdBatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Netd_BatchClient interface {
+	Recv() (*BatchStreamMessage, error)
+	grpc.ClientStream
+}
+
+type netdBatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *netdBatchClient) Recv() (*BatchStreamMessage, error) {
+	m := new(BatchStreamMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NetdServer is the server API for Netd service.
+type NetdServer interface {
+	Exec(context.Context, *CliRequest) (*CliResponse, error)
+	// ExecStream sends result of every command once it's done, then the response
+	ExecStream(*CliRequest, Netd_ExecStreamServer) error
+	FetchConfig(context.Context, *CliRequest) (*ConfigResponse, error)
+	CheckPort(context.Context, *PortCheckRequest) (*PortCheckResponse, error)
+	// Batch sends result of every device once it's done, then the response without results
+	Batch(*BatchRequest, Netd_BatchServer) error
+}
+
+// UnimplementedNetdServer can be embedded to have forward compatible implementations.
+type UnimplementedNetdServer struct {
+}
+
+func (*UnimplementedNetdServer) Exec(ctx context.Context, req *CliRequest) (*CliResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Exec not implemented")
+}
+func (*UnimplementedNetdServer) ExecStream(req *CliRequest, srv Netd_ExecStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExecStream not implemented")
+}
+func (*UnimplementedNetdServer) FetchConfig(ctx context.Context, req *CliRequest) (*ConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FetchConfig not implemented")
+}
+func (*UnimplementedNetdServer) CheckPort(ctx context.Context, req *PortCheckRequest) (*PortCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckPort not implemented")
+}
+func (*UnimplementedNetdServer) Batch(req *BatchRequest, srv Netd_BatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Batch not implemented")
+}
+
+func RegisterNetdServer(s *grpc.Server, srv NetdServer) {
+	s.RegisterService(&_Netd_serviceDesc, srv)
+}
+
+func _Netd_Exec_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CliRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetdServer).Exec(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.Netd/Exec",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetdServer).Exec(ctx, req.(*CliRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Netd_ExecStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CliRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NetdServer).ExecStream(m, &netdExecStreamServer{stream})
+}
+
+type Netd_ExecStreamServer interface {
+	Send(*CliStreamMessage) error
+	grpc.ServerStream
+}
+
+type netdExecStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *netdExecStreamServer) Send(m *CliStreamMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Netd_FetchConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CliRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetdServer).FetchConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.Netd/FetchConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetdServer).FetchConfig(ctx, req.(*CliRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Netd_CheckPort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PortCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetdServer).CheckPort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/netd.Netd/CheckPort",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetdServer).CheckPort(ctx, req.(*PortCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Netd_Batch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NetdServer).Batch(m, &netdBatchServer{stream})
+}
+
+type Netd_BatchServer interface {
+	Send(*BatchStreamMessage) error
+	grpc.ServerStream
+}
+
+type netdBatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *netdBatchServer) Send(m *BatchStreamMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Netd_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "netd.Netd",
+	HandlerType: (*NetdServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Exec",
+			Handler:    _Netd_Exec_Handler,
+		},
+		{
+			MethodName: "FetchConfig",
+			Handler:    _Netd_FetchConfig_Handler,
+		},
+		{
+			MethodName: "CheckPort",
+			Handler:    _Netd_CheckPort_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExecStream",
+			Handler:       _Netd_ExecStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Batch",
+			Handler:       _Netd_Batch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "protocol/pb/netd.proto",
+}
@@ -0,0 +1,35 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package protocol
+
+// terminal message types
+const (
+	TerminalOpen   = "open"   // client opens terminal, Request, Cols and Rows set
+	TerminalInput  = "input"  // client keystrokes in Data
+	TerminalResize = "resize" // client terminal resized to Cols x Rows
+	TerminalError  = "error"  // server failed, reason in Data, connection closed then
+	TerminalOpened = "opened" // server opened terminal, recording id in Data
+)
+
+// TerminalMessage is exchanged as websocket text message on web terminal,
+// device output is sent as binary messages
+type TerminalMessage struct {
+	Type    string      `json:"type"`
+	Data    string      `json:"data,omitempty"`
+	Cols    int         `json:"cols,omitempty"`
+	Rows    int         `json:"rows,omitempty"`
+	Request *CliRequest `json:"request,omitempty"`
+}
@@ -0,0 +1,294 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package record records cli sessions in asciicast v2 format, both output and input
+// with timestamps, recordings are stored under dir by device and can be replayed.
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sky-cloud-tec/netd/protocol"
+)
+
+// Instance is the recorder sessions recorded with, nil if recording disabled
+var Instance *Recorder
+
+const (
+	ext = ".cast"
+	// terminal size put in header, players need one
+	width  = 80
+	height = 40
+)
+
+// Header is the first line of asciicast v2 file
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Info describes a recording
+type Info struct {
+	ID     string    `json:"id"`     // file name of recording
+	Device string    `json:"device"` // device recorded
+	Time   time.Time `json:"time"`   // recording started
+	Size   int64     `json:"size"`   // file size in bytes
+}
+
+// Recorder creates recordings in dir
+type Recorder struct {
+	dir     string
+	devices []*regexp.Regexp // devices always recorded
+}
+
+// New create recorder storing recordings in dir, devices matching any pattern are always recorded
+func New(dir string, devices []string) (*Recorder, error) {
+	s := &Recorder{dir: dir}
+	for _, v := range devices {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("compile device pattern %s error: %s", v, err)
+		}
+		s.devices = append(s.devices, re)
+	}
+	return s, nil
+}
+
+// Device return device name recordings of req stored by, address if device not set
+func Device(req *protocol.CliRequest) string {
+	if req.Device != "" {
+		return req.Device
+	}
+	return req.Address
+}
+
+// Enabled return true if req asks for recording or its device is always recorded
+func (s *Recorder) Enabled(req *protocol.CliRequest) bool {
+	if req.Record {
+		return true
+	}
+	device := Device(req)
+	for _, v := range s.devices {
+		if v.MatchString(device) {
+			return true
+		}
+	}
+	return false
+}
+
+// Start create recording of req
+func (s *Recorder) Start(req *protocol.CliRequest) (*Cast, error) {
+	device := Device(req)
+	dir := s.deviceDir(device)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create recording dir error: %s", err)
+	}
+	now := time.Now()
+	id := now.Format("20060102T150405.000000000")
+	if req.Session != "" {
+		id += "-" + url.PathEscape(req.Session)
+	}
+	id += ext
+	f, err := os.OpenFile(filepath.Join(dir, id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("create recording error: %s", err)
+	}
+	c := &Cast{ID: id, f: f, start: now}
+	h := &Header{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: now.Unix(),
+		Title:     strings.TrimSpace(device + " " + req.Session),
+		Env:       map[string]string{"TERM": "vt100"},
+	}
+	if err := c.writeLine(h); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// List return recordings of device, oldest first
+func (s *Recorder) List(device string) ([]*Info, error) {
+	fis, err := ioutil.ReadDir(s.deviceDir(device))
+	if os.IsNotExist(err) {
+		return []*Info{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list recordings error: %s", err)
+	}
+	infos := make([]*Info, 0, len(fis))
+	for _, v := range fis {
+		if v.IsDir() || filepath.Ext(v.Name()) != ext {
+			continue
+		}
+		infos = append(infos, &Info{ID: v.Name(), Device: device, Time: v.ModTime(), Size: v.Size()})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	for _, v := range infos {
+		// started time is in file name, mod time is when it ended
+		if t, err := time.ParseInLocation("20060102T150405.000000000", strings.SplitN(v.ID, "-", 2)[0], time.Local); err == nil {
+			v.Time = t
+		}
+	}
+	return infos, nil
+}
+
+// Open return recording of device with id
+func (s *Recorder) Open(device, id string) (*os.File, error) {
+	if id == "" || id != filepath.Base(id) || filepath.Ext(id) != ext {
+		return nil, fmt.Errorf("invalid recording id %s", id)
+	}
+	f, err := os.Open(filepath.Join(s.deviceDir(device), id))
+	if err != nil {
+		return nil, fmt.Errorf("open recording error: %s", err)
+	}
+	return f, nil
+}
+
+// deviceDir return recordings dir of device, device is escaped as one path element,
+// dot-only names are encoded too so they can't refer to recordings dir or its parent
+func (s *Recorder) deviceDir(device string) string {
+	name := url.PathEscape(device)
+	if strings.Trim(name, ".") == "" {
+		name = strings.Replace(name, ".", "%2E", -1)
+	}
+	return filepath.Join(s.dir, name)
+}
+
+// Cast is an asciicast v2 recording in progress, safe for concurrent use
+type Cast struct {
+	ID    string // file name of recording
+	mu    sync.Mutex
+	f     *os.File
+	start time.Time
+}
+
+// Output record data device sent
+func (c *Cast) Output(b []byte) {
+	c.event("o", b)
+}
+
+// Input record data sent to device
+func (c *Cast) Input(b []byte) {
+	c.event("i", b)
+}
+
+func (c *Cast) event(t string, b []byte) {
+	if c == nil || len(b) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.f == nil {
+		return
+	}
+	// invalid utf-8 is replaced when marshaled
+	c.writeLine([]interface{}{time.Since(c.start).Seconds(), t, string(b)})
+}
+
+func (c *Cast) writeLine(v interface{}) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// prompts are full of <>
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if _, err := c.f.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("write recording error: %s", err)
+	}
+	return nil
+}
+
+// Close finish recording
+func (c *Cast) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.f == nil {
+		return nil
+	}
+	err := c.f.Close()
+	c.f = nil
+	return err
+}
+
+// Replay write output events of recording r to w with recorded timing,
+// speed scales timing, idle longer than maxIdle is cut to maxIdle if it's positive,
+// flush is called after every event if not nil
+func Replay(r io.Reader, w io.Writer, speed float64, maxIdle time.Duration, flush func()) error {
+	if speed <= 0 {
+		speed = 1
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		return fmt.Errorf("read recording header error: %v", scanner.Err())
+	}
+	var h Header
+	if err := json.Unmarshal(scanner.Bytes(), &h); err != nil || h.Version != 2 {
+		return fmt.Errorf("not an asciicast v2 recording")
+	}
+	last := 0.0
+	for scanner.Scan() {
+		var ev []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil || len(ev) != 3 {
+			return fmt.Errorf("invalid recording event %s", scanner.Text())
+		}
+		t, _ := ev[0].(float64)
+		typ, _ := ev[1].(string)
+		data, _ := ev[2].(string)
+		if typ != "o" {
+			continue
+		}
+		d := time.Duration((t - last) / speed * float64(time.Second))
+		if maxIdle > 0 && d > maxIdle {
+			d = maxIdle
+		}
+		last = t
+		time.Sleep(d)
+		if _, err := io.WriteString(w, data); err != nil {
+			return err
+		}
+		if flush != nil {
+			flush()
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,90 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package record
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sky-cloud-tec/netd/protocol"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecorder(t *testing.T) {
+	Convey("record and replay sessions", t, func() {
+		dir, err := ioutil.TempDir("", "netd-record")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		r, err := New(dir, []string{"^fw-"})
+		So(err, ShouldBeNil)
+
+		So(r.Enabled(&protocol.CliRequest{Device: "fw-01"}), ShouldBeTrue)
+		So(r.Enabled(&protocol.CliRequest{Device: "sw-01"}), ShouldBeFalse)
+		So(r.Enabled(&protocol.CliRequest{Device: "sw-01", Record: true}), ShouldBeTrue)
+
+		req := &protocol.CliRequest{Device: "fw/01", Session: "s1"}
+		c, err := r.Start(req)
+		So(err, ShouldBeNil)
+		c.Output([]byte("admin@fw> "))
+		c.Input([]byte("show version\n"))
+		c.Output([]byte("show version\nJUNOS 12.1\nadmin@fw> "))
+		So(c.Close(), ShouldBeNil)
+		// closed or nil casts are ignored
+		c.Output([]byte("late"))
+		var nc *Cast
+		nc.Input([]byte("x"))
+
+		infos, err := r.List("fw/01")
+		So(err, ShouldBeNil)
+		So(infos, ShouldHaveLength, 1)
+		So(infos[0].ID, ShouldEqual, c.ID)
+		So(infos[0].ID, ShouldEndWith, "-s1.cast")
+		infos, err = r.List("none")
+		So(err, ShouldBeNil)
+		So(infos, ShouldBeEmpty)
+
+		_, err = r.Open("fw/01", "../fw%2F01/"+c.ID)
+		So(err, ShouldNotBeNil)
+		f, err := r.Open("fw/01", c.ID)
+		So(err, ShouldBeNil)
+		b, err := ioutil.ReadAll(f)
+		f.Close()
+		So(err, ShouldBeNil)
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		So(lines, ShouldHaveLength, 4)
+		So(lines[0], ShouldContainSubstring, `"version":2`)
+		So(lines[2], ShouldEndWith, `"i","show version\n"]`)
+
+		var out bytes.Buffer
+		So(Replay(bytes.NewReader(b), &out, 100, 0, nil), ShouldBeNil)
+		So(out.String(), ShouldEqual, "admin@fw> show version\nJUNOS 12.1\nadmin@fw> ")
+		So(Replay(strings.NewReader("not a cast\n"), &out, 1, 0, nil), ShouldNotBeNil)
+
+		// dot-only device names stay in recordings dir
+		c, err = r.Start(&protocol.CliRequest{Device: ".."})
+		So(err, ShouldBeNil)
+		So(c.Close(), ShouldBeNil)
+		_, err = os.Stat(filepath.Join(dir, "%2E%2E", c.ID))
+		So(err, ShouldBeNil)
+		infos, err = r.List("..")
+		So(err, ShouldBeNil)
+		So(infos, ShouldHaveLength, 1)
+	})
+}
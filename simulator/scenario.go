@@ -0,0 +1,186 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package simulator is a scriptable fake device, it serves ssh and telnet sessions
+// driven by scenario files, so operators can be tested end to end without lab devices.
+package simulator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Scenario describes how a fake device behaves
+type Scenario struct {
+	Name string `yaml:"name"`
+	// operator the device is served by, see protocol.CliRequest
+	Vendor  string `yaml:"vendor"`
+	Type    string `yaml:"type"`
+	Version string `yaml:"version"`
+	// credentials, enable password is asked by commands with password set
+	Username       string `yaml:"username"`
+	Password       string `yaml:"password"`
+	EnablePassword string `yaml:"enablePassword"`
+	Banner         string `yaml:"banner"`    // sent before the first prompt
+	Linebreak      string `yaml:"linebreak"` // linebreak of output, \n by default
+	Encoding       string `yaml:"encoding"`  // device charset, UTF-8 by default
+	Start          string `yaml:"start"`     // mode session starts in
+	// Unknown is the output of unknown commands, {command} is replaced with the command
+	Unknown  string           `yaml:"unknown"`
+	Modes    map[string]*Mode `yaml:"modes"`
+	Commands []*Command       `yaml:"commands"`
+	Pager    *Pager           `yaml:"pager"`
+	Cases    []*Case          `yaml:"cases"`
+}
+
+// Mode is a cli mode of device
+type Mode struct {
+	Prompt string `yaml:"prompt"`
+}
+
+// Command is how device answers a command
+type Command struct {
+	Command  string `yaml:"command"`  // command, leading and trailing spaces ignored
+	Match    string `yaml:"match"`    // regexp command matched with if command is empty
+	Mode     string `yaml:"mode"`     // mode command accepted in, any mode if empty
+	Output   string `yaml:"output"`   // output before prompt
+	Goto     string `yaml:"goto"`     // mode entered after command
+	Password bool   `yaml:"password"` // ask for enable password before entering goto mode
+	Pager    string `yaml:"pager"`    // on or off, turn pager on or off
+
+	re *regexp.Regexp
+}
+
+// Pager paginates long outputs, it's on when session starts
+type Pager struct {
+	Lines  int    `yaml:"lines"`  // lines per page
+	Prompt string `yaml:"prompt"` // eg. --More--, space for next page, q to quit
+}
+
+// Case is a request the scenario is tested with
+type Case struct {
+	Name     string   `yaml:"name"`
+	Mode     string   `yaml:"mode"`
+	Commands []string `yaml:"commands"`
+	// Expect maps command to substring expected in its output
+	Expect         map[string]string `yaml:"expect"`
+	Retcode        int               `yaml:"retcode"`
+	OutputEncoding string            `yaml:"outputEncoding"` // see protocol.CliRequest
+
+}
+
+// LoadScenario load scenario from yaml file
+func LoadScenario(path string) (*Scenario, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scenario error: %s", err)
+	}
+	sc := new(Scenario)
+	if err := yaml.UnmarshalStrict(b, sc); err != nil {
+		return nil, fmt.Errorf("parse scenario %s error: %s", path, err)
+	}
+	if sc.Name == "" {
+		sc.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := sc.init(); err != nil {
+		return nil, fmt.Errorf("scenario %s: %s", sc.Name, err)
+	}
+	return sc, nil
+}
+
+// LoadScenarios load scenario files, dirs are expanded to yaml files in them
+func LoadScenarios(paths ...string) ([]*Scenario, error) {
+	var files []string
+	for _, v := range paths {
+		fi, err := os.Stat(v)
+		if err != nil {
+			return nil, fmt.Errorf("stat scenario error: %s", err)
+		}
+		if !fi.IsDir() {
+			files = append(files, v)
+			continue
+		}
+		for _, ext := range []string{"*.yaml", "*.yml"} {
+			matches, _ := filepath.Glob(filepath.Join(v, ext))
+			files = append(files, matches...)
+		}
+	}
+	scs := make([]*Scenario, 0, len(files))
+	for _, v := range files {
+		sc, err := LoadScenario(v)
+		if err != nil {
+			return nil, err
+		}
+		scs = append(scs, sc)
+	}
+	return scs, nil
+}
+
+// Operator return vendor.type.version the device is served by
+func (sc *Scenario) Operator() string {
+	return strings.Join([]string{sc.Vendor, sc.Type, sc.Version}, ".")
+}
+
+func (sc *Scenario) init() error {
+	if sc.Linebreak == "" {
+		sc.Linebreak = "\n"
+	}
+	if _, ok := sc.Modes[sc.Start]; !ok {
+		return fmt.Errorf("start mode %q not defined", sc.Start)
+	}
+	for _, v := range sc.Commands {
+		if v.Mode != "" {
+			if _, ok := sc.Modes[v.Mode]; !ok {
+				return fmt.Errorf("mode %q of command %q not defined", v.Mode, v.Command)
+			}
+		}
+		if v.Goto != "" {
+			if _, ok := sc.Modes[v.Goto]; !ok {
+				return fmt.Errorf("goto mode %q of command %q not defined", v.Goto, v.Command)
+			}
+		}
+		v.Command = strings.TrimSpace(v.Command)
+		if v.Command != "" {
+			continue
+		}
+		re, err := regexp.Compile(v.Match)
+		if err != nil {
+			return fmt.Errorf("compile match %s error: %s", v.Match, err)
+		}
+		v.re = re
+	}
+	return nil
+}
+
+// lookup return the first command matching cmd in mode, exact commands are tried before regexps
+func (sc *Scenario) lookup(mode, cmd string) *Command {
+	for _, v := range sc.Commands {
+		if (v.Mode == "" || v.Mode == mode) && v.Command == cmd {
+			return v
+		}
+	}
+	for _, v := range sc.Commands {
+		if (v.Mode == "" || v.Mode == mode) && v.re != nil && v.re.MatchString(cmd) {
+			return v
+		}
+	}
+	return nil
+}
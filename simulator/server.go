@@ -0,0 +1,286 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package simulator
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/songtianyi/rrframework/logs"
+	"golang.org/x/crypto/ssh"
+)
+
+// telnet commands
+const (
+	iac  = 255
+	sb   = 250
+	se   = 240
+	will = 251
+	dont = 254
+)
+
+// Server serves a scenario on ssh and telnet listeners
+type Server struct {
+	sc     *Scenario
+	config *ssh.ServerConfig
+
+	mu        sync.Mutex
+	listeners []net.Listener
+	closed    bool
+}
+
+// NewServer create server of scenario with a fresh host key
+func NewServer(sc *Scenario) (*Server, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate host key error: %s", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("create host key signer error: %s", err)
+	}
+	s := &Server{sc: sc}
+	s.config = &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if s.auth(c.User(), string(pass)) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("password rejected for %s", c.User())
+		},
+	}
+	s.config.AddHostKey(signer)
+	return s, nil
+}
+
+// Scenario return scenario served
+func (s *Server) Scenario() *Scenario {
+	return s.sc
+}
+
+func (s *Server) auth(user, pass string) bool {
+	return (s.sc.Username == "" || user == s.sc.Username) && (s.sc.Password == "" || pass == s.sc.Password)
+}
+
+// ListenSSH serve ssh on addr in background, return address listened
+func (s *Server) ListenSSH(addr string) (net.Addr, error) {
+	l, err := s.listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	go s.serve(l, s.handleSSH)
+	return l.Addr(), nil
+}
+
+// ListenTelnet serve telnet on addr in background, return address listened
+func (s *Server) ListenTelnet(addr string) (net.Addr, error) {
+	l, err := s.listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	go s.serve(l, s.handleTelnet)
+	return l.Addr(), nil
+}
+
+// Close stop listeners, sessions in progress are not closed
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for _, v := range s.listeners {
+		v.Close()
+	}
+	s.listeners = nil
+	return nil
+}
+
+func (s *Server) listen(addr string) (net.Listener, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s error: %s", addr, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		l.Close()
+		return nil, fmt.Errorf("server closed")
+	}
+	s.listeners = append(s.listeners, l)
+	return l, nil
+}
+
+func (s *Server) serve(l net.Listener, handle func(net.Conn)) {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go handle(c)
+	}
+}
+
+func (s *Server) handleSSH(c net.Conn) {
+	defer c.Close()
+	conn, chans, reqs, err := ssh.NewServerConn(c, s.config)
+	if err != nil {
+		logs.Error("[ simulator", s.sc.Name, "] ssh handshake error:", err)
+		return
+	}
+	defer conn.Close()
+	go ssh.DiscardRequests(reqs)
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		ch, requests, err := nc.Accept()
+		if err != nil {
+			logs.Error("[ simulator", s.sc.Name, "] accept channel error:", err)
+			return
+		}
+		go s.session(ch, requests)
+	}
+}
+
+// session run shell once requested, pty and env requests are accepted and ignored
+func (s *Server) session(ch ssh.Channel, requests <-chan *ssh.Request) {
+	defer ch.Close()
+	shell := make(chan struct{})
+	go func() {
+		for req := range requests {
+			ok := false
+			switch req.Type {
+			case "shell":
+				ok = true
+				close(shell)
+			case "pty-req", "env", "window-change", "break":
+				ok = true
+			}
+			if req.WantReply {
+				req.Reply(ok, nil)
+			}
+		}
+	}()
+	<-shell
+	if err := newShell(s.sc, ch).run(ch); err != nil {
+		logs.Error("[ simulator", s.sc.Name, "] ssh session error:", err)
+	}
+}
+
+func (s *Server) handleTelnet(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(&telnetReader{r: bufio.NewReader(c)})
+	// login: and Password: prompts, see netd --log-cfg-flag
+	for {
+		if _, err := io.WriteString(c, "login: "); err != nil {
+			return
+		}
+		user, err := readLine(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(c, "\r\nPassword: "); err != nil {
+			return
+		}
+		pass, err := readLine(r)
+		if err != nil {
+			return
+		}
+		if s.auth(strings.TrimSpace(user), strings.TrimSpace(pass)) {
+			if _, err := io.WriteString(c, "\r\n"); err != nil {
+				return
+			}
+			break
+		}
+		if _, err := io.WriteString(c, "\r\nLogin incorrect\r\n"); err != nil {
+			return
+		}
+	}
+	if err := newShell(s.sc, c).run(r); err != nil {
+		logs.Error("[ simulator", s.sc.Name, "] telnet session error:", err)
+	}
+}
+
+// readLine read line ended with \r, \r\n or \r\0
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\r')
+	if err != nil {
+		return "", err
+	}
+	if r.Buffered() > 0 {
+		if b, _ := r.Peek(1); b[0] == '\n' || b[0] == 0 {
+			r.ReadByte()
+		}
+	}
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// telnetReader strip telnet commands from input
+type telnetReader struct {
+	r *bufio.Reader
+}
+
+func (t *telnetReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if n > 0 && t.r.Buffered() == 0 {
+			break
+		}
+		b, err := t.r.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		if b != iac {
+			p[n] = b
+			n++
+			continue
+		}
+		cmd, err := t.r.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		switch {
+		case cmd == iac:
+			// escaped 255
+			p[n] = iac
+			n++
+		case cmd >= will && cmd <= dont:
+			// option follows
+			if _, err := t.r.ReadByte(); err != nil {
+				return n, err
+			}
+		case cmd == sb:
+			// skip until IAC SE
+			for prev := byte(0); ; {
+				b, err := t.r.ReadByte()
+				if err != nil {
+					return n, err
+				}
+				if prev == iac && b == se {
+					break
+				}
+				prev = b
+			}
+		}
+	}
+	return n, nil
+}
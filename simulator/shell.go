@@ -0,0 +1,208 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package simulator
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/sky-cloud-tec/netd/common"
+)
+
+const (
+	ctrlC = 0x03
+	ctrlZ = 0x1a
+)
+
+// shell plays scenario on one session
+type shell struct {
+	sc     *Scenario
+	w      io.Writer
+	mode   string
+	paging bool
+
+	line    []byte
+	cr      bool     // last byte is \r, \n follows is part of the linebreak
+	swallow bool     // drop linebreak after pager key
+	pending []string // lines left to page
+	enable  *Command // command waiting for enable password
+}
+
+func newShell(sc *Scenario, w io.Writer) *shell {
+	return &shell{sc: sc, w: w, mode: sc.Start, paging: sc.Pager != nil && sc.Pager.Lines > 0}
+}
+
+// run play scenario until r is closed
+func (s *shell) run(r io.Reader) error {
+	if err := s.write(s.text(s.sc.Banner) + s.prompt()); err != nil {
+		return err
+	}
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := s.feed(b); err != nil {
+			return err
+		}
+	}
+}
+
+// feed handle one input byte
+func (s *shell) feed(b byte) error {
+	cr := s.cr
+	s.cr = b == '\r'
+	if (b == '\n' || b == 0) && cr {
+		return nil
+	}
+	if s.swallow {
+		s.swallow = false
+		if b == '\r' || b == '\n' {
+			return nil
+		}
+	}
+	if s.pending != nil {
+		return s.page(b)
+	}
+	switch b {
+	case ctrlC, ctrlZ:
+		s.line = s.line[:0]
+		s.enable = nil
+		return s.write("^C" + s.sc.Linebreak + s.prompt())
+	case '\r', '\n':
+		line := string(s.line)
+		s.line = s.line[:0]
+		return s.exec(line)
+	case 0x08, 0x7f:
+		if len(s.line) > 0 {
+			s.line = s.line[:len(s.line)-1]
+		}
+		return nil
+	}
+	s.line = append(s.line, b)
+	return nil
+}
+
+// exec answer a line, the answer is written at once
+func (s *shell) exec(line string) error {
+	lb := s.sc.Linebreak
+	if s.enable != nil {
+		// password not echoed
+		c := s.enable
+		s.enable = nil
+		if s.sc.EnablePassword != "" && line != s.sc.EnablePassword {
+			return s.write(lb + "% Access denied" + lb + s.prompt())
+		}
+		s.mode = c.Goto
+		return s.write(lb + s.prompt())
+	}
+	echo := s.decode(line) + lb
+	cmd := strings.TrimSpace(s.decode(line))
+	if cmd == "" {
+		return s.write(echo + s.prompt())
+	}
+	c := s.sc.lookup(s.mode, cmd)
+	if c == nil {
+		return s.output(echo, strings.Replace(s.sc.Unknown, "{command}", cmd, -1))
+	}
+	switch c.Pager {
+	case "on":
+		s.paging = s.sc.Pager != nil && s.sc.Pager.Lines > 0
+	case "off":
+		s.paging = false
+	}
+	if c.Password {
+		s.enable = c
+		return s.write(echo + s.text(c.Output) + "Password: ")
+	}
+	if c.Goto != "" {
+		s.mode = c.Goto
+	}
+	return s.output(echo, c.Output)
+}
+
+// output write echo and output, paginated if pager on
+func (s *shell) output(echo, out string) error {
+	out = strings.TrimSuffix(out, "\n")
+	if out == "" {
+		return s.write(echo + s.prompt())
+	}
+	lines := strings.Split(out, "\n")
+	if !s.paging || len(lines) <= s.sc.Pager.Lines {
+		return s.write(echo + s.text(out+"\n") + s.prompt())
+	}
+	s.pending = lines[s.sc.Pager.Lines:]
+	return s.write(echo + s.text(strings.Join(lines[:s.sc.Pager.Lines], "\n")+"\n") + s.sc.Pager.Prompt)
+}
+
+// page answer pager prompt, q or ctrl-c quits, other keys show next page
+func (s *shell) page(b byte) error {
+	// erase pager prompt like devices do
+	erase := "\r" + strings.Repeat(" ", len(s.sc.Pager.Prompt)) + "\r"
+	if b == 'q' || b == 'Q' || b == ctrlC {
+		s.pending = nil
+		return s.write(erase + s.prompt())
+	}
+	s.swallow = b == ' '
+	lines := s.pending
+	if len(lines) <= s.sc.Pager.Lines {
+		s.pending = nil
+		return s.write(erase + s.text(strings.Join(lines, "\n")+"\n") + s.prompt())
+	}
+	s.pending = lines[s.sc.Pager.Lines:]
+	return s.write(erase + s.text(strings.Join(lines[:s.sc.Pager.Lines], "\n")+"\n") + s.sc.Pager.Prompt)
+}
+
+func (s *shell) prompt() string {
+	return s.text(s.sc.Modes[s.mode].Prompt)
+}
+
+// text convert linebreaks of scenario text to device linebreak
+func (s *shell) text(x string) string {
+	if s.sc.Linebreak == "\n" {
+		return x
+	}
+	return strings.Replace(x, "\n", s.sc.Linebreak, -1)
+}
+
+// decode input from device charset
+func (s *shell) decode(x string) string {
+	if s.sc.Encoding == "" {
+		return x
+	}
+	b, err := common.ConvToUTF8(s.sc.Encoding, []byte(x))
+	if err != nil {
+		return x
+	}
+	return string(b)
+}
+
+// write output in device charset
+func (s *shell) write(x string) error {
+	b := []byte(x)
+	if s.sc.Encoding != "" {
+		if v, err := common.ConvFromUTF8(s.sc.Encoding, b); err == nil {
+			b = v
+		}
+	}
+	_, err := s.w.Write(b)
+	return err
+}
@@ -0,0 +1,106 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package simulator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func testScenario() *Scenario {
+	sc := &Scenario{
+		Name:           "test",
+		EnablePassword: "secret",
+		Start:          "login",
+		Unknown:        "% Unknown command {command}\n",
+		Modes: map[string]*Mode{
+			"login":  {Prompt: "dev> "},
+			"enable": {Prompt: "dev# "},
+		},
+		Commands: []*Command{
+			{Command: "enable", Mode: "login", Goto: "enable", Password: true},
+			{Command: "no pager", Pager: "off"},
+			{Match: "^show (lines|all)$", Output: "1\n2\n3\n4\n5\n"},
+		},
+		Pager: &Pager{Lines: 2, Prompt: "--More--"},
+	}
+	if err := sc.init(); err != nil {
+		panic(err)
+	}
+	return sc
+}
+
+func play(s *shell, input string) error {
+	for _, b := range []byte(input) {
+		if err := s.feed(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestShell(t *testing.T) {
+	Convey("shell plays scenario", t, func() {
+		var out bytes.Buffer
+		s := newShell(testScenario(), &out)
+
+		Convey("unknown command", func() {
+			So(play(s, "foo bar\r\n"), ShouldBeNil)
+			So(out.String(), ShouldEqual, "foo bar\n% Unknown command foo bar\ndev> ")
+		})
+		Convey("enable password", func() {
+			So(play(s, "enable\nwrong\n"), ShouldBeNil)
+			So(s.mode, ShouldEqual, "login")
+			So(play(s, "enable\rsecret\r\n"), ShouldBeNil)
+			So(s.mode, ShouldEqual, "enable")
+			So(out.String(), ShouldNotContainSubstring, "secret")
+		})
+		Convey("pager", func() {
+			So(play(s, "show lines\n"), ShouldBeNil)
+			So(out.String(), ShouldEndWith, "1\n2\n--More--")
+			// linebreak after space is dropped
+			So(play(s, " \n"), ShouldBeNil)
+			So(out.String(), ShouldEndWith, "3\n4\n--More--")
+			So(play(s, "q"), ShouldBeNil)
+			So(out.String(), ShouldEndWith, "\rdev> ")
+			So(out.String(), ShouldNotContainSubstring, "5\n")
+			out.Reset()
+			So(play(s, "no pager\nshow all\n"), ShouldBeNil)
+			So(out.String(), ShouldEqual, "no pager\ndev> show all\n1\n2\n3\n4\n5\ndev> ")
+		})
+		Convey("ctrl-c", func() {
+			So(play(s, "show\x03"), ShouldBeNil)
+			So(out.String(), ShouldEqual, "^C\ndev> ")
+		})
+	})
+}
+
+func TestLoadScenarios(t *testing.T) {
+	Convey("shipped scenarios load", t, func() {
+		scs, err := LoadScenarios("scenarios")
+		So(err, ShouldBeNil)
+		So(len(scs), ShouldBeGreaterThan, 0)
+		for _, v := range scs {
+			So(v.Cases, ShouldNotBeEmpty)
+			So(strings.Count(v.Operator(), "."), ShouldBeGreaterThanOrEqualTo, 2)
+		}
+		_, err = LoadScenario("scenarios/none.yaml")
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,208 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package textfsm parses command output into records with TextFSM templates,
+// templates dir may have an ntc-templates style index to select templates by platform and command.
+package textfsm
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sirikothe/gotextfsm"
+)
+
+// Instance is the engine command outputs parsed with, nil if templates dir not configured
+var Instance *Engine
+
+// Record is a parsed record, value is string or []string
+type Record map[string]interface{}
+
+// Engine parse text with templates in dir
+type Engine struct {
+	dir   string
+	index []*entry
+	cache map[string]gotextfsm.TextFSM
+	mu    sync.Mutex
+}
+
+// entry is an index row
+type entry struct {
+	templates []string
+	hostname  *regexp.Regexp
+	platform  *regexp.Regexp
+	command   *regexp.Regexp
+}
+
+// New create engine with templates in dir, index file is loaded if exists
+func New(dir string) (*Engine, error) {
+	s := &Engine{dir: dir, cache: make(map[string]gotextfsm.TextFSM)}
+	f, err := os.Open(filepath.Join(dir, "index"))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open index error: %s", err)
+	}
+	defer f.Close()
+	if s.index, err = parseIndex(f); err != nil {
+		return nil, fmt.Errorf("parse index error: %s", err)
+	}
+	return s, nil
+}
+
+// completion is the [[...]] syntax of index command column, sh[[ow]] matches sh, sho and show
+var completion = regexp.MustCompile(`\[\[(.+?)\]\]`)
+
+func complete(x string) string {
+	return completion.ReplaceAllStringFunc(x, func(m string) string {
+		rs := []rune(m[2 : len(m)-2])
+		out := ""
+		for i := len(rs) - 1; i >= 0; i-- {
+			out = "(" + regexp.QuoteMeta(string(rs[i])) + out + ")?"
+		}
+		return out
+	})
+}
+
+// parseIndex parse index like clitable, the first row is header,
+// columns are matched from start of platform and command
+func parseIndex(f *os.File) ([]*entry, error) {
+	var header []string
+	entries := make([]*entry, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if header == nil {
+			for _, v := range strings.Split(line, ",") {
+				header = append(header, strings.ToLower(strings.TrimSpace(v)))
+			}
+			continue
+		}
+		cols := strings.SplitN(line, ",", len(header))
+		e := &entry{}
+		for i, v := range cols {
+			v = strings.TrimSpace(v)
+			var err error
+			switch header[i] {
+			case "template":
+				e.templates = strings.Split(v, ":")
+			case "hostname":
+				e.hostname, err = regexp.Compile("^(" + v + ")")
+			case "platform":
+				e.platform, err = regexp.Compile("^(" + v + ")")
+			case "command":
+				e.command, err = regexp.Compile("^(" + complete(v) + ")")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("bad index row %q: %s", line, err)
+			}
+		}
+		if len(e.templates) == 0 || e.command == nil {
+			return nil, fmt.Errorf("bad index row %q: template and command required", line)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Lookup return templates of the first index row which matches platform, hostname and command
+func (s *Engine) Lookup(platform, hostname, command string) []string {
+	command = strings.TrimSpace(command)
+	for _, e := range s.index {
+		if e.platform != nil && !e.platform.MatchString(platform) {
+			continue
+		}
+		if e.hostname != nil && !e.hostname.MatchString(hostname) {
+			continue
+		}
+		if e.command.MatchString(command) {
+			return e.templates
+		}
+	}
+	return nil
+}
+
+// Parse parse text with template, template is a file name in templates dir or template text itself
+func (s *Engine) Parse(template, text string) ([]Record, error) {
+	fsm, err := s.load(template)
+	if err != nil {
+		return nil, err
+	}
+	parser := gotextfsm.ParserOutput{}
+	if err := parser.ParseTextString(text, fsm, true); err != nil {
+		return nil, fmt.Errorf("parse text error: %s", err)
+	}
+	records := make([]Record, 0, len(parser.Dict))
+	for _, v := range parser.Dict {
+		records = append(records, Record(v))
+	}
+	return records, nil
+}
+
+// ParseCommand parse command output with explicit template, or templates looked up from index,
+// records of multiple templates are concatenated
+func (s *Engine) ParseCommand(platform, hostname, command, template, text string) ([]Record, error) {
+	templates := []string{template}
+	if template == "" {
+		if templates = s.Lookup(platform, hostname, command); templates == nil {
+			return nil, fmt.Errorf("no template for %s %q", platform, command)
+		}
+	}
+	records := make([]Record, 0)
+	for _, v := range templates {
+		r, err := s.Parse(v, text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", v, err)
+		}
+		records = append(records, r...)
+	}
+	return records, nil
+}
+
+func (s *Engine) load(template string) (gotextfsm.TextFSM, error) {
+	fsm := gotextfsm.TextFSM{}
+	if strings.Contains(template, "\n") {
+		// template text, not cached
+		if err := fsm.ParseString(template); err != nil {
+			return fsm, fmt.Errorf("bad template: %s", err)
+		}
+		return fsm, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.cache[template]; ok {
+		return v, nil
+	}
+	// file in templates dir
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, filepath.Base(template)))
+	if err != nil {
+		return fsm, fmt.Errorf("read template error: %s", err)
+	}
+	if err := fsm.ParseString(string(b)); err != nil {
+		return fsm, fmt.Errorf("bad template %s: %s", template, err)
+	}
+	s.cache[template] = fsm
+	return fsm, nil
+}
@@ -0,0 +1,67 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package textfsm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const showClock = `Value Time (\d+:\d+:\d+)
+Value Timezone (\S+)
+
+Start
+  ^\*?${Time}\.\d+ ${Timezone} -> Record
+`
+
+const index = `
+# comments ignored
+Template, Hostname, Platform, Command
+
+cisco_ios_show_clock.textfsm, .*, cisco_ios, sh[[ow]] clo[[ck]]
+`
+
+func TestEngine(t *testing.T) {
+	Convey("parse with templates", t, func() {
+		dir, err := ioutil.TempDir("", "netd-templates")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		So(ioutil.WriteFile(filepath.Join(dir, "index"), []byte(index), 0600), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(dir, "cisco_ios_show_clock.textfsm"), []byte(showClock), 0600), ShouldBeNil)
+		e, err := New(dir)
+		So(err, ShouldBeNil)
+
+		So(e.Lookup("cisco_ios", "sw1", "sh clo"), ShouldResemble, []string{"cisco_ios_show_clock.textfsm"})
+		So(e.Lookup("cisco_ios", "sw1", "show clock"), ShouldNotBeNil)
+		So(e.Lookup("cisco_ios", "sw1", "show version"), ShouldBeNil)
+		So(e.Lookup("cisco_nxos", "sw1", "show clock"), ShouldBeNil)
+
+		out := "*10:11:12.345 UTC Mon Oct 19 2026\n"
+		records, err := e.ParseCommand("cisco_ios", "sw1", "show clock", "", out)
+		So(err, ShouldBeNil)
+		So(records, ShouldResemble, []Record{{"Time": "10:11:12", "Timezone": "UTC"}})
+		// template text in request
+		records, err = e.ParseCommand("cisco_nxos", "sw1", "show clock", showClock, out)
+		So(err, ShouldBeNil)
+		So(records, ShouldHaveLength, 1)
+		_, err = e.ParseCommand("cisco_nxos", "sw1", "show clock", "", out)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,106 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package tlsconf serves certificates loaded from files to tls listeners, they're loaded again on Reload,
+// eg. on SIGHUP, so certificates can be renewed without restart.
+package tlsconf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+// Config holds server certificate and client CAs of listeners
+type Config struct {
+	certFile, keyFile, caFile string
+	optional                  bool // clients without certificate accepted
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	cas  *x509.CertPool
+}
+
+// New load server certificate and key, clients are verified against CAs of caFile if given,
+// those without certificate are rejected unless optional
+func New(certFile, keyFile, caFile string, optional bool) (*Config, error) {
+	s := &Config{certFile: certFile, keyFile: keyFile, caFile: caFile, optional: optional}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload load files again, certificates in use are kept if any fails,
+// established connections keep certificates they handshaked with
+func (s *Config) Reload() error {
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate error: %s", err)
+	}
+	var cas *x509.CertPool
+	if s.caFile != "" {
+		b, err := ioutil.ReadFile(s.caFile)
+		if err != nil {
+			return fmt.Errorf("read client ca error: %s", err)
+		}
+		cas = x509.NewCertPool()
+		if !cas.AppendCertsFromPEM(b) {
+			return fmt.Errorf("no certificate found in client ca %s", s.caFile)
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cert, s.cas = &cert, cas
+	return nil
+}
+
+// TLS return tls config of a listener, it always serves certificates last loaded,
+// nextProtos are application protocols negotiated, eg. h2 for grpc
+func (s *Config) TLS(nextProtos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+			return s.cert, nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return s.current(nextProtos), nil
+		},
+	}
+}
+
+// current return config of a handshake
+func (s *Config) current(nextProtos []string) *tls.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*s.cert},
+		NextProtos:   nextProtos,
+	}
+	if s.cas != nil {
+		c.ClientCAs = s.cas
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+		if s.optional {
+			c.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+	return c
+}
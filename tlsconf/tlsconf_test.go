@@ -0,0 +1,145 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tlsconf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// handshake return server side state of handshake with client presenting cert, empty for none
+func handshake(cfg *Config, cert string) (*tls.ConnectionState, string, error) {
+	b, err := ioutil.ReadFile("testdata/ca.pem")
+	if err != nil {
+		return nil, "", err
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(b)
+	cc := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+	if cert != "" {
+		pair, err := tls.LoadX509KeyPair("testdata/"+cert+".pem", "testdata/"+cert+".key")
+		if err != nil {
+			return nil, "", err
+		}
+		// sent even if not issued by CAs server accepts
+		cc.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &pair, nil
+		}
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, "", err
+	}
+	defer l.Close()
+	ch := make(chan error, 1)
+	var cn string
+	go func() {
+		client, err := tls.Dial("tcp", l.Addr().String(), cc)
+		if err != nil {
+			ch <- err
+			return
+		}
+		defer client.Close()
+		cn = client.ConnectionState().PeerCertificates[0].Subject.CommonName
+		// tls 1.3 client finishes before server verified it
+		_, err = client.Read(make([]byte, 1))
+		ch <- err
+	}()
+	c, err := l.Accept()
+	if err != nil {
+		return nil, "", err
+	}
+	defer c.Close()
+	server := tls.Server(c, cfg.TLS())
+	if err := server.Handshake(); err != nil {
+		return nil, "", err
+	}
+	server.Write([]byte{0})
+	if err := <-ch; err != nil {
+		return nil, "", err
+	}
+	state := server.ConnectionState()
+	return &state, cn, nil
+}
+
+func TestConfig(t *testing.T) {
+	Convey("client certificates verified", t, func() {
+		cfg, err := New("testdata/server.pem", "testdata/server.key", "testdata/ca.pem", false)
+		So(err, ShouldBeNil)
+		state, server, err := handshake(cfg, "client")
+		So(err, ShouldBeNil)
+		So(server, ShouldEqual, "netd")
+		So(state.VerifiedChains, ShouldNotBeEmpty)
+		So(state.VerifiedChains[0][0].Subject.CommonName, ShouldEqual, "ops-portal")
+		_, _, err = handshake(cfg, "")
+		So(err, ShouldNotBeNil)
+		_, _, err = handshake(cfg, "rogue")
+		So(err, ShouldNotBeNil)
+
+		cfg, err = New("testdata/server.pem", "testdata/server.key", "testdata/ca.pem", true)
+		So(err, ShouldBeNil)
+		state, _, err = handshake(cfg, "")
+		So(err, ShouldBeNil)
+		So(state.VerifiedChains, ShouldBeEmpty)
+		_, _, err = handshake(cfg, "rogue")
+		So(err, ShouldNotBeNil)
+
+		cfg, err = New("testdata/server.pem", "testdata/server.key", "", false)
+		So(err, ShouldBeNil)
+		state, _, err = handshake(cfg, "")
+		So(err, ShouldBeNil)
+		So(state.VerifiedChains, ShouldBeEmpty)
+	})
+
+	Convey("certificates reloaded, kept if reload fails", t, func() {
+		dir, err := ioutil.TempDir("", "tlsconf")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		copyFile := func(src, dst string) {
+			b, err := ioutil.ReadFile(filepath.Join("testdata", src))
+			So(err, ShouldBeNil)
+			So(ioutil.WriteFile(filepath.Join(dir, dst), b, 0600), ShouldBeNil)
+		}
+		copyFile("server.pem", "cert.pem")
+		copyFile("server.key", "key.pem")
+		cfg, err := New(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), "", false)
+		So(err, ShouldBeNil)
+		_, server, err := handshake(cfg, "")
+		So(err, ShouldBeNil)
+		So(server, ShouldEqual, "netd")
+
+		copyFile("server2.pem", "cert.pem")
+		copyFile("server2.key", "key.pem")
+		So(cfg.Reload(), ShouldBeNil)
+		_, server, err = handshake(cfg, "")
+		So(err, ShouldBeNil)
+		So(server, ShouldEqual, "netd renewed")
+
+		// key no longer matches certificate
+		copyFile("server.key", "key.pem")
+		So(cfg.Reload(), ShouldNotBeNil)
+		_, server, err = handshake(cfg, "")
+		So(err, ShouldBeNil)
+		So(server, ShouldEqual, "netd renewed")
+	})
+}
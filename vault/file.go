@@ -0,0 +1,237 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// fileData is the plaintext of vault file
+type fileData struct {
+	Credentials map[string]*Credential `json:"credentials"`
+	Devices     map[string][]string    `json:"devices"`
+}
+
+// fileEnvelope is how vault file stored, data is aes-256-gcm sealed json of fileData,
+// key derived from master key by scrypt with salt
+type fileEnvelope struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// FileBackend keeps credentials in an encrypted file, whole file rewritten on every change
+type FileBackend struct {
+	path string
+	salt []byte
+	aead cipher.AEAD
+
+	mu   sync.RWMutex
+	data *fileData
+}
+
+// NewFileBackend open vault file at path with master key, file created on first write
+func NewFileBackend(path string, key []byte) (*FileBackend, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("vault master key is empty")
+	}
+	s := &FileBackend{path: path, data: &fileData{Credentials: map[string]*Credential{}, Devices: map[string][]string{}}}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		s.salt = make([]byte, 16)
+		if _, err := rand.Read(s.salt); err != nil {
+			return nil, err
+		}
+		if s.aead, err = newAEAD(key, s.salt); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read vault error: %s", err)
+	}
+	var env fileEnvelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return nil, fmt.Errorf("parse vault error: %s", err)
+	}
+	s.salt = env.Salt
+	if s.aead, err = newAEAD(key, s.salt); err != nil {
+		return nil, err
+	}
+	plain, err := s.aead.Open(nil, env.Nonce, env.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt vault error, wrong master key? %s", err)
+	}
+	if err := json.Unmarshal(plain, s.data); err != nil {
+		return nil, fmt.Errorf("parse vault data error: %s", err)
+	}
+	if s.data.Credentials == nil {
+		s.data.Credentials = map[string]*Credential{}
+	}
+	if s.data.Devices == nil {
+		s.data.Devices = map[string][]string{}
+	}
+	return s, nil
+}
+
+func newAEAD(key, salt []byte) (cipher.AEAD, error) {
+	dk, err := scrypt.Key(key, salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, fmt.Errorf("derive vault key error: %s", err)
+	}
+	block, err := aes.NewCipher(dk)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Get return a copy of credential
+func (s *FileBackend) Get(id string) (*Credential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.data.Credentials[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	n := *c
+	return &n, nil
+}
+
+// Put add or replace credential
+func (s *FileBackend) Put(c *Credential) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.data.Credentials[c.ID]
+	n := *c
+	s.data.Credentials[c.ID] = &n
+	if err := s.save(); err != nil {
+		// keep memory same as file
+		if old == nil {
+			delete(s.data.Credentials, c.ID)
+		} else {
+			s.data.Credentials[c.ID] = old
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete remove credential, it's removed from fallback lists too
+func (s *FileBackend) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Credentials[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.data.Credentials, id)
+	for k, v := range s.data.Devices {
+		ids := v[:0:0]
+		for _, x := range v {
+			if x != id {
+				ids = append(ids, x)
+			}
+		}
+		s.data.Devices[k] = ids
+	}
+	return s.save()
+}
+
+// List return copies of all credentials ordered by id
+func (s *FileBackend) List() ([]*Credential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cs := make([]*Credential, 0, len(s.data.Credentials))
+	for _, v := range s.data.Credentials {
+		n := *v
+		cs = append(cs, &n)
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].ID < cs[j].ID })
+	return cs, nil
+}
+
+// Fallbacks return credential ids of device
+func (s *FileBackend) Fallbacks(device string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.data.Devices[device]...), nil
+}
+
+// SetFallbacks set credential ids of device, empty ids remove the list
+func (s *FileBackend) SetFallbacks(device string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.data.Devices[device]
+	if len(ids) == 0 {
+		delete(s.data.Devices, device)
+	} else {
+		s.data.Devices[device] = append([]string(nil), ids...)
+	}
+	if err := s.save(); err != nil {
+		if ok {
+			s.data.Devices[device] = old
+		} else {
+			delete(s.data.Devices, device)
+		}
+		return err
+	}
+	return nil
+}
+
+// save seal data and replace vault file
+func (s *FileBackend) save() error {
+	plain, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	b, err := json.Marshal(&fileEnvelope{Version: 1, Salt: s.salt, Nonce: nonce, Data: s.aead.Seal(nil, nonce, plain, nil)})
+	if err != nil {
+		return err
+	}
+	// write then rename, so vault is never half written
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), ".vault")
+	if err != nil {
+		return fmt.Errorf("write vault error: %s", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write vault error: %s", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write vault error: %s", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write vault error: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package vault resolves credential references of requests from a secret store,
+// so clients name credentials instead of sending passwords.
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/sky-cloud-tec/netd/inventory"
+	"github.com/sky-cloud-tec/netd/protocol"
+)
+
+// Instance is the vault credentials resolved from, nil if vault disabled
+var Instance *Vault
+
+// ErrNotFound is returned by backends when credential not found
+var ErrNotFound = errors.New("credential not found")
+
+var idPattern = regexp.MustCompile(`^[[:alnum:]._@:-]{1,128}$`)
+
+// Credential is a set of secrets to login device with
+type Credential struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Password  string    `json:"password,omitempty"`
+	EnablePwd string    `json:"enablePwd,omitempty"`
+	Version   int       `json:"version"` // increased on every rotation
+	Updated   time.Time `json:"updated"`
+}
+
+// Backend stores credentials and per device fallback lists
+type Backend interface {
+	Get(id string) (*Credential, error)
+	Put(c *Credential) error
+	Delete(id string) error
+	List() ([]*Credential, error)
+	// Fallbacks return credential ids tried in order for inventory device
+	Fallbacks(device string) ([]string, error)
+	SetFallbacks(device string, ids []string) error
+}
+
+// Vault manages credentials stored in backend
+type Vault struct {
+	backend Backend
+}
+
+// New create vault on backend
+func New(backend Backend) *Vault {
+	return &Vault{backend: backend}
+}
+
+// Create add a new credential
+func (s *Vault) Create(c *Credential) (*Credential, error) {
+	if !idPattern.MatchString(c.ID) {
+		return nil, fmt.Errorf("invalid credential id %q", c.ID)
+	}
+	if _, err := s.backend.Get(c.ID); err == nil {
+		return nil, fmt.Errorf("credential %s exists", c.ID)
+	} else if err != ErrNotFound {
+		return nil, err
+	}
+	n := *c
+	n.Version, n.Updated = 1, time.Now()
+	if err := s.backend.Put(&n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Rotate replace secrets of credential, empty username is kept
+func (s *Vault) Rotate(c *Credential) (*Credential, error) {
+	old, err := s.backend.Get(c.ID)
+	if err != nil {
+		return nil, err
+	}
+	n := *c
+	if n.Username == "" {
+		n.Username = old.Username
+	}
+	n.Version, n.Updated = old.Version+1, time.Now()
+	if err := s.backend.Put(&n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Delete remove credential
+func (s *Vault) Delete(id string) error {
+	return s.backend.Delete(id)
+}
+
+// List return credentials without secrets
+func (s *Vault) List() ([]*Credential, error) {
+	cs, err := s.backend.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range cs {
+		v.Password, v.EnablePwd = "", ""
+	}
+	return cs, nil
+}
+
+// Fallbacks return credential ids tried in order for inventory device
+func (s *Vault) Fallbacks(device string) ([]string, error) {
+	return s.backend.Fallbacks(device)
+}
+
+// SetFallbacks set credential ids tried in order for inventory device, all of them must exist
+func (s *Vault) SetFallbacks(device string, ids []string) error {
+	if device == "" {
+		return fmt.Errorf("device not specified")
+	}
+	for _, v := range ids {
+		if _, err := s.backend.Get(v); err != nil {
+			return fmt.Errorf("credential %s: %s", v, err)
+		}
+	}
+	return s.backend.SetFallbacks(device, ids)
+}
+
+// Resolve return credentials to login device of req with, in order.
+// Device is the inventory device at req.Address, device name of request is not trusted,
+// credentials are sent only to addresses they are bound to: the credential named by request
+// must be the one of inventory device or in its fallbacks, fallbacks of device follow it.
+// Nil is returned if request carries password itself or names nothing.
+func (s *Vault) Resolve(req *protocol.CliRequest) ([]*Credential, error) {
+	if req.Auth.Password != "" {
+		return nil, nil
+	}
+	var bound, fbs []string
+	if d := device(req.Address); d != nil {
+		if d.Credential != "" {
+			bound = append(bound, d.Credential)
+		}
+		var err error
+		if fbs, err = s.backend.Fallbacks(d.Name); err != nil {
+			return nil, err
+		}
+		bound = append(bound, fbs...)
+	}
+	var ids []string
+	if req.Auth.Credential != "" {
+		if !contains(bound, req.Auth.Credential) {
+			return nil, fmt.Errorf("credential %s not bound to %s", req.Auth.Credential, req.Address)
+		}
+		ids = append(ids, req.Auth.Credential)
+	}
+	for _, v := range fbs {
+		if v != req.Auth.Credential {
+			ids = append(ids, v)
+		}
+	}
+	cs := make([]*Credential, 0, len(ids))
+	for _, v := range ids {
+		c, err := s.backend.Get(v)
+		if err != nil {
+			return nil, fmt.Errorf("credential %s: %s", v, err)
+		}
+		cs = append(cs, c)
+	}
+	return cs, nil
+}
+
+// device return inventory device at addr, nil if not found
+func device(addr string) *inventory.Device {
+	if inventory.Instance == nil || addr == "" {
+		return nil
+	}
+	return inventory.Instance.ByAddress(addr)
+}
+
+func contains(ss []string, x string) bool {
+	for _, v := range ss {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply set credential to req
+func Apply(req *protocol.CliRequest, c *Credential) {
+	req.Auth.Username = c.Username
+	req.Auth.Password = c.Password
+	if c.EnablePwd != "" {
+		req.EnablePwd = c.EnablePwd
+	}
+	req.Auth.Credential = c.ID
+}
@@ -0,0 +1,127 @@
+// NetD makes network device operations easy.
+// Copyright (C) 2019  sky-cloud.net
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package vault
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sky-cloud-tec/netd/inventory"
+	"github.com/sky-cloud-tec/netd/protocol"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFileVault(t *testing.T) {
+	Convey("credentials in encrypted file", t, func() {
+		dir, err := ioutil.TempDir("", "netd-vault")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "vault.json")
+
+		b, err := NewFileBackend(path, []byte("master"))
+		So(err, ShouldBeNil)
+		v := New(b)
+		c, err := v.Create(&Credential{ID: "fw-admin", Username: "admin", Password: "s3cr3t", EnablePwd: "en"})
+		So(err, ShouldBeNil)
+		So(c.Version, ShouldEqual, 1)
+		_, err = v.Create(&Credential{ID: "fw-admin", Username: "x"})
+		So(err, ShouldNotBeNil)
+		_, err = v.Create(&Credential{ID: "bad id", Username: "x"})
+		So(err, ShouldNotBeNil)
+		_, err = v.Create(&Credential{ID: "fw-ro", Username: "ro", Password: "ro"})
+		So(err, ShouldBeNil)
+
+		Convey("secrets encrypted at rest", func() {
+			raw, err := ioutil.ReadFile(path)
+			So(err, ShouldBeNil)
+			So(strings.Contains(string(raw), "s3cr3t"), ShouldBeFalse)
+			So(strings.Contains(string(raw), "fw-admin"), ShouldBeFalse)
+			fi, err := os.Stat(path)
+			So(err, ShouldBeNil)
+			So(fi.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+
+			_, err = NewFileBackend(path, []byte("wrong"))
+			So(err, ShouldNotBeNil)
+			b2, err := NewFileBackend(path, []byte("master"))
+			So(err, ShouldBeNil)
+			got, err := b2.Get("fw-admin")
+			So(err, ShouldBeNil)
+			So(got.Password, ShouldEqual, "s3cr3t")
+		})
+
+		Convey("rotate and list", func() {
+			c, err := v.Rotate(&Credential{ID: "fw-admin", Password: "n3w"})
+			So(err, ShouldBeNil)
+			So(c.Version, ShouldEqual, 2)
+			So(c.Username, ShouldEqual, "admin")
+			So(c.EnablePwd, ShouldBeEmpty)
+			_, err = v.Rotate(&Credential{ID: "none", Password: "x"})
+			So(err, ShouldEqual, ErrNotFound)
+			cs, err := v.List()
+			So(err, ShouldBeNil)
+			So(cs, ShouldHaveLength, 2)
+			So(cs[0].ID, ShouldEqual, "fw-admin")
+			So(cs[0].Password, ShouldBeEmpty)
+		})
+
+		Convey("resolve with fallbacks", func() {
+			inventory.Instance = &inventory.Inventory{Devices: []*inventory.Device{
+				{Name: "fw1", Address: "10.0.0.1:22"},
+				{Name: "fw2", Address: "10.0.0.2:22", Credential: "fw-ro"},
+			}}
+			defer func() { inventory.Instance = nil }()
+			So(v.SetFallbacks("fw1", []string{"fw-ro", "none"}), ShouldNotBeNil)
+			So(v.SetFallbacks("fw1", []string{"fw-ro", "fw-admin"}), ShouldBeNil)
+
+			req := &protocol.CliRequest{Device: "sw9", Address: "10.0.0.1:22", Auth: protocol.Auth{Credential: "fw-admin"}}
+			cs, err := v.Resolve(req)
+			So(err, ShouldBeNil)
+			So(cs, ShouldHaveLength, 2)
+			So(cs[0].ID, ShouldEqual, "fw-admin")
+			So(cs[1].ID, ShouldEqual, "fw-ro")
+			Apply(req, cs[0])
+			So(req.Auth.Password, ShouldEqual, "s3cr3t")
+			So(req.EnablePwd, ShouldEqual, "en")
+
+			// password in request wins
+			cs, err = v.Resolve(&protocol.CliRequest{Device: "fw1", Auth: protocol.Auth{Username: "u", Password: "p"}})
+			So(err, ShouldBeNil)
+			So(cs, ShouldBeNil)
+
+			// credentials only sent to addresses bound to them, device name not trusted
+			_, err = v.Resolve(&protocol.CliRequest{Device: "fw1", Address: "10.6.6.6:22", Auth: protocol.Auth{Credential: "fw-admin"}})
+			So(err, ShouldNotBeNil)
+			_, err = v.Resolve(&protocol.CliRequest{Address: "10.0.0.2:22", Auth: protocol.Auth{Credential: "fw-admin"}})
+			So(err, ShouldNotBeNil)
+			cs, err = v.Resolve(&protocol.CliRequest{Device: "fw1", Address: "10.6.6.6:22"})
+			So(err, ShouldBeNil)
+			So(cs, ShouldBeEmpty)
+			cs, err = v.Resolve(&protocol.CliRequest{Address: "10.0.0.2:22", Auth: protocol.Auth{Credential: "fw-ro"}})
+			So(err, ShouldBeNil)
+			So(cs[0].Username, ShouldEqual, "ro")
+
+			So(v.Delete("fw-ro"), ShouldBeNil)
+			ids, err := v.Fallbacks("fw1")
+			So(err, ShouldBeNil)
+			So(ids, ShouldResemble, []string{"fw-admin"})
+			_, err = v.Resolve(&protocol.CliRequest{Address: "10.0.0.2:22", Auth: protocol.Auth{Credential: "fw-ro"}})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}